@@ -3,8 +3,10 @@ package auth
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
 	"github.com/justtrackio/gosoline/pkg/cfg"
 	"github.com/justtrackio/gosoline/pkg/funk"
 )
@@ -42,6 +44,18 @@ func GetSubject(ctx context.Context) *Subject {
 	panic(fmt.Errorf("there is no subject in the context"))
 }
 
+// abortUnauthorized rejects the request with status 401, rendered as problem if the error middleware is configured so.
+func abortUnauthorized(ginCtx *gin.Context, err error) {
+	if httpserver.IsErrorFormatProblem(ginCtx) {
+		httpserver.AbortWithError(ginCtx, httpserver.NewErrorWithStatus(http.StatusUnauthorized, err))
+
+		return
+	}
+
+	ginCtx.JSON(http.StatusUnauthorized, gin.H{"err": err.Error()})
+	ginCtx.Abort()
+}
+
 func configAuthKey(name string) string {
 	return fmt.Sprintf("httpserver.%s.auth", name)
 }
@@ -5,7 +5,6 @@ import (
 	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -53,8 +52,7 @@ func NewBasicAuthHandler(config cfg.Config, logger log.Logger, name string) (gin
 		}
 
 		ginCtx.Header(httpserver.HeaderWWWAuthenticate, fmt.Sprintf(httpserver.HeaderValueBasicRealmFormat, appId.Name))
-		ginCtx.JSON(http.StatusUnauthorized, gin.H{"err": err.Error()})
-		ginCtx.Abort()
+		abortUnauthorized(ginCtx, err)
 	}, nil
 }
 
@@ -1,9 +1,13 @@
 package auth
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
 )
 
 func NewChainHandler(authenticators map[string]Authenticator) gin.HandlerFunc {
@@ -25,7 +29,29 @@ func NewChainHandler(authenticators map[string]Authenticator) gin.HandlerFunc {
 			}
 		}
 
+		if httpserver.IsErrorFormatProblem(ginCtx) {
+			httpserver.AbortWithError(ginCtx, newChainProblem(errors))
+
+			return
+		}
+
 		ginCtx.JSON(http.StatusUnauthorized, errors)
 		ginCtx.Abort()
 	}
 }
+
+// newChainProblem describes the errors of all authenticators in the detail and as "errors" extension member.
+func newChainProblem(errors map[string]string) *httpserver.Problem {
+	details := make([]string, 0, len(errors))
+	for name, err := range errors {
+		details = append(details, fmt.Sprintf("%s: %s", name, err))
+	}
+	sort.Strings(details)
+
+	detail := "no authenticator accepted the request"
+	if len(details) > 0 {
+		detail = strings.Join(details, "; ")
+	}
+
+	return httpserver.NewProblem(http.StatusUnauthorized, detail).WithExtension("errors", errors)
+}
@@ -0,0 +1,61 @@
+package auth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/gosoline-project/httpserver/auth"
+	"github.com/gosoline-project/httpserver/auth/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestChainHandler_RejectsWithProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	apiKey := mocks.NewAuthenticator(t)
+	apiKey.EXPECT().IsValid(mock.Anything).Return(false, errors.New("no api key provided"))
+
+	r := gin.New()
+	r.Use(httpserver.ErrorMiddlewareWithSettings(httpserver.ErrorsSettings{Format: httpserver.ErrorFormatProblem}))
+	r.Use(auth.NewChainHandler(map[string]auth.Authenticator{"apiKey": apiKey}))
+	r.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.JSONEq(t, `{
+		"type":"about:blank",
+		"title":"Unauthorized",
+		"status":401,
+		"detail":"apiKey: no api key provided",
+		"instance":"/",
+		"errors":{"apiKey":"no api key provided"}
+	}`, recorder.Body.String())
+}
+
+func TestChainHandler_RejectsWithJson(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	apiKey := mocks.NewAuthenticator(t)
+	apiKey.EXPECT().IsValid(mock.Anything).Return(false, errors.New("no api key provided"))
+
+	r := gin.New()
+	r.Use(auth.NewChainHandler(map[string]auth.Authenticator{"apiKey": apiKey}))
+	r.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.JSONEq(t, `{"apiKey":"no api key provided"}`, recorder.Body.String())
+}
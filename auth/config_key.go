@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/subtle"
 	"fmt"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gosoline-project/httpserver"
@@ -53,8 +52,7 @@ func NewConfigKeyHandler(config cfg.Config, logger log.Logger, name string, prov
 			err = fmt.Errorf("the api key wasn't valid nor was there an error")
 		}
 
-		ginCtx.JSON(http.StatusUnauthorized, gin.H{"err": err.Error()})
-		ginCtx.Abort()
+		abortUnauthorized(ginCtx, err)
 	}, nil
 }
 
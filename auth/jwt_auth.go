@@ -3,7 +3,6 @@ package auth
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -42,8 +41,7 @@ func NewJwtAuthHandler(config cfg.Config, name string) (gin.HandlerFunc, error)
 			err = fmt.Errorf("the user jwt token isn't valid nor was there an error")
 		}
 
-		ginCtx.JSON(http.StatusUnauthorized, gin.H{"err": err.Error()})
-		ginCtx.Abort()
+		abortUnauthorized(ginCtx, err)
 	}, nil
 }
 
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+)
+
+// RateLimitKeySubject assigns requests to rate limit buckets by the authenticated subject.
+const RateLimitKeySubject = "subject"
+
+func init() {
+	httpserver.AddRateLimitKeyFunc(RateLimitKeySubject, SubjectRateLimitKey)
+}
+
+// SubjectRateLimitKey returns the rate limit key of the subject authenticated for the request. Anonymous subjects
+// are identified by their user, api key, or token bearer attribute. Subjects without any of those are limited by
+// the client IP.
+func SubjectRateLimitKey(ginCtx *gin.Context, _ httpserver.RateLimitSettings) (string, error) {
+	subject, ok := ginCtx.Request.Context().Value(subjectKey).(*Subject)
+	if !ok {
+		return "", fmt.Errorf("there is no subject in the context, the rate limited route has to be authenticated")
+	}
+
+	return getSubjectKey(ginCtx, subject)
+}
+
+// getSubjectKey identifies a subject by the authenticator and its name. Anonymous subjects are identified by their
+// user, api key, or token bearer attribute, subjects without any of those by the client IP.
+func getSubjectKey(ginCtx *gin.Context, subject *Subject) (string, error) {
+	if !subject.Anonymous {
+		return fmt.Sprintf("%s:%s", subject.AuthenticatedBy, subject.Name), nil
+	}
+
+	for _, attribute := range []string{AttributeUser, AttributeApiKey, AttributeTokenBearerId} {
+		if value, ok := subject.Attributes[attribute]; ok {
+			return fmt.Sprintf("%s:%v", subject.AuthenticatedBy, value), nil
+		}
+	}
+
+	clientIp, err := httpserver.ResolveClientIP(ginCtx.Request)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s:%s", subject.AuthenticatedBy, clientIp), nil
+}
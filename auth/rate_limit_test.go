@@ -0,0 +1,74 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/gosoline-project/httpserver/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubjectRateLimitKey(t *testing.T) {
+	cases := []struct {
+		name      string
+		subject   *auth.Subject
+		expectKey string
+		expectErr bool
+	}{
+		{
+			name:      "no subject",
+			expectErr: true,
+		},
+		{
+			name:      "named subject",
+			subject:   &auth.Subject{Name: "user@example.com", AuthenticatedBy: auth.ByJWT},
+			expectKey: "jwtAuth:user@example.com",
+		},
+		{
+			name: "anonymous subject with api key",
+			subject: &auth.Subject{
+				Name:            auth.Anonymous,
+				Anonymous:       true,
+				AuthenticatedBy: auth.ByApiKey,
+				Attributes:      map[string]any{auth.AttributeApiKey: "key"},
+			},
+			expectKey: "apiKey:key",
+		},
+		{
+			name: "anonymous subject without identity",
+			subject: &auth.Subject{
+				Name:            auth.Anonymous,
+				Anonymous:       true,
+				AuthenticatedBy: auth.ByAnonymous,
+				Attributes:      map[string]any{},
+			},
+			expectKey: "anonymous:192.0.2.1",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ginCtx.Request = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			ginCtx.Request.RemoteAddr = "192.0.2.1:1234"
+
+			if tc.subject != nil {
+				auth.RequestWithSubject(ginCtx, tc.subject)
+			}
+
+			key, err := auth.SubjectRateLimitKey(ginCtx, httpserver.RateLimitSettings{})
+
+			if tc.expectErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectKey, key)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+)
+
+// ResponseCacheKeySubject derives the cache key of a request from the authenticated subject.
+const ResponseCacheKeySubject = "subject"
+
+func init() {
+	httpserver.AddPrivateResponseCacheKeyFunc(ResponseCacheKeySubject, SubjectResponseCacheKey)
+}
+
+// SubjectResponseCacheKey returns the cache key of the subject authenticated for the request, identified like by
+// SubjectRateLimitKey. Cached responses of routes using it are shared only between requests of the same subject, so
+// private responses and responses to authenticated requests are cached, too.
+func SubjectResponseCacheKey(ginCtx *gin.Context) (string, error) {
+	subject, ok := ginCtx.Request.Context().Value(subjectKey).(*Subject)
+	if !ok {
+		return "", fmt.Errorf("there is no subject in the context, the cached route has to be authenticated")
+	}
+
+	return getSubjectKey(ginCtx, subject)
+}
@@ -0,0 +1,25 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubjectResponseCacheKey(t *testing.T) {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	_, err := auth.SubjectResponseCacheKey(ginCtx)
+	assert.EqualError(t, err, "there is no subject in the context, the cached route has to be authenticated")
+
+	auth.RequestWithSubject(ginCtx, &auth.Subject{Name: "user@example.com", AuthenticatedBy: auth.ByJWT})
+
+	key, err := auth.SubjectResponseCacheKey(ginCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, "jwtAuth:user@example.com", key)
+}
@@ -5,7 +5,6 @@ import (
 	"crypto/subtle"
 	"errors"
 	"fmt"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gosoline-project/httpserver"
@@ -111,8 +110,7 @@ func NewTokenBearerHandlerWithInterfaces(auth Authenticator) gin.HandlerFunc {
 			err = fmt.Errorf("the token wasn't valid nor was there an error")
 		}
 
-		ginCtx.JSON(http.StatusUnauthorized, gin.H{"err": err.Error()})
-		ginCtx.Abort()
+		abortUnauthorized(ginCtx, err)
 	}
 }
 
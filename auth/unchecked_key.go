@@ -2,7 +2,6 @@ package auth
 
 import (
 	"fmt"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/justtrackio/gosoline/pkg/cfg"
@@ -28,8 +27,7 @@ func NewUncheckedKeyHandler(config cfg.Config, logger log.Logger, provider ApiKe
 			err = fmt.Errorf("the api key wasn't valid nor was there an error")
 		}
 
-		ginCtx.JSON(http.StatusUnauthorized, gin.H{"err": err.Error()})
-		ginCtx.Abort()
+		abortUnauthorized(ginCtx, err)
 	}
 }
 
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/justtrackio/gosoline/pkg/encoding/json"
 	"github.com/justtrackio/gosoline/pkg/funk"
 	"github.com/justtrackio/gosoline/pkg/refl"
 )
@@ -27,13 +29,13 @@ func Bind[I any](handler func(ctx context.Context, input *I) (Response, error),
 func BindR[I any](handler func(ctx context.Context, req *http.Request, input *I) (Response, error), binders ...binding.Binding) gin.HandlerFunc {
 	tags := refl.GetTagNames(new(I))
 
-	return func(ginCtx *gin.Context) {
+	return registerRouteSpec(func(ginCtx *gin.Context) {
 		var err error
 		var input *I
 		var response Response
 
 		if input, err = BindHandleRequest[I](ginCtx, tags, binders); err != nil {
-			reportGinErrorWithType(ginCtx, NewErrorWithStatus(http.StatusBadRequest, err), gin.ErrorTypeBind)
+			reportGinErrorWithType(ginCtx, newBindError(err), gin.ErrorTypeBind)
 
 			return
 		}
@@ -47,7 +49,7 @@ func BindR[I any](handler func(ctx context.Context, req *http.Request, input *I)
 		if err = BindHandleResponse(response, ginCtx); err != nil {
 			reportGinError(ginCtx, fmt.Errorf("response error: %w", err))
 		}
-	}
+	}, newBindRouteSpec[I](tags, binders))
 }
 
 // BindN adapts a typed handler that does not need request input binding.
@@ -60,7 +62,7 @@ func BindN(handler func(ctx context.Context) (Response, error)) gin.HandlerFunc
 // BindNR adapts a typed handler that does not need request input binding, but
 // still needs access to the raw HTTP request.
 func BindNR(handler func(ctx context.Context, req *http.Request) (Response, error)) gin.HandlerFunc {
-	return func(ginCtx *gin.Context) {
+	return registerRouteSpec(func(ginCtx *gin.Context) {
 		var err error
 		var response Response
 
@@ -73,7 +75,7 @@ func BindNR(handler func(ctx context.Context, req *http.Request) (Response, erro
 		if err = BindHandleResponse(response, ginCtx); err != nil {
 			reportGinError(ginCtx, fmt.Errorf("response error: %w", err))
 		}
-	}
+	}, &RouteSpec{})
 }
 
 // BindSse adapts a typed SSE handler into a Gin handler by binding request data
@@ -88,34 +90,26 @@ func BindSse[I any](handler func(ctx context.Context, input *I, writer *SseWrite
 // HTTP request to the handler.
 func BindSseR[I any](handler func(ctx context.Context, req *http.Request, input *I, writer *SseWriter) error, binders ...binding.Binding) gin.HandlerFunc {
 	tags := refl.GetTagNames(new(I))
+	spec := newBindRouteSpec[I](tags, binders)
+	spec.setResponse(sseRouteResponse())
 
-	return func(ginCtx *gin.Context) {
+	return registerRouteSpec(func(ginCtx *gin.Context) {
 		var err error
 		var input *I
 
 		if input, err = BindHandleRequest[I](ginCtx, tags, binders); err != nil {
-			reportGinErrorWithType(ginCtx, NewErrorWithStatus(http.StatusBadRequest, err), gin.ErrorTypeBind)
+			reportGinErrorWithType(ginCtx, newBindError(err), gin.ErrorTypeBind)
 
 			return
 		}
 
-		writer := NewSseWriter(ginCtx.Request.Context(), ginCtx.Writer)
+		writer := newBindSseWriter(ginCtx)
 		defer writer.Close()
 
 		if err = handler(ginCtx, ginCtx.Request, input, writer); err != nil {
-			// If client disconnected, this is a clean exit - no error logging
-			if errors.Is(err, ErrClientDisconnected) {
-				return
-			}
-
-			// Send error as an SSE event instead of letting ErrorMiddleware corrupt the stream
-			if sendErr := writer.SendEvent(SseEvent{Event: "error", Data: err.Error()}); sendErr != nil && !errors.Is(sendErr, ErrClientDisconnected) {
-				reportGinError(ginCtx, fmt.Errorf("sse error event: %w", sendErr))
-			}
-
-			ginCtx.Abort()
+			handleSseError(ginCtx, writer, err)
 		}
-	}
+	}, spec)
 }
 
 // BindSseN adapts an SSE handler that does not need request input binding.
@@ -128,26 +122,99 @@ func BindSseN(handler func(ctx context.Context, writer *SseWriter) error) gin.Ha
 // BindSseNR adapts an SSE handler that does not need request input binding, but
 // still needs access to the raw HTTP request.
 func BindSseNR(handler func(ctx context.Context, req *http.Request, writer *SseWriter) error) gin.HandlerFunc {
-	return func(ginCtx *gin.Context) {
+	return registerRouteSpec(func(ginCtx *gin.Context) {
 		var err error
 
-		writer := NewSseWriter(ginCtx.Request.Context(), ginCtx.Writer)
+		writer := newBindSseWriter(ginCtx)
 		defer writer.Close()
 
 		if err = handler(ginCtx, ginCtx.Request, writer); err != nil {
-			// If client disconnected, this is a clean exit - no error logging
-			if errors.Is(err, ErrClientDisconnected) {
-				return
-			}
+			handleSseError(ginCtx, writer, err)
+		}
+	}, &RouteSpec{
+		Responses: []RouteResponse{sseRouteResponse()},
+	})
+}
 
-			// Send error as an SSE event instead of letting ErrorMiddleware corrupt the stream
-			if sendErr := writer.SendEvent(SseEvent{Event: "error", Data: err.Error()}); sendErr != nil && !errors.Is(sendErr, ErrClientDisconnected) {
-				reportGinError(ginCtx, fmt.Errorf("sse error event: %w", sendErr))
-			}
+func newBindSseWriter(ginCtx *gin.Context) *SseWriter {
+	writer := NewSseWriterWithSettings(ginCtx.Request.Context(), ginCtx.Writer, getSseSettings(ginCtx))
+	writer.lastEventId = ginCtx.GetHeader(HeaderLastEventId)
 
-			ginCtx.Abort()
+	return writer
+}
+
+func handleSseError(ginCtx *gin.Context, writer *SseWriter, err error) {
+	// If client disconnected, this is a clean exit - no error logging
+	if errors.Is(err, ErrClientDisconnected) {
+		return
+	}
+
+	var event SseEvent
+	var eventErr error
+
+	if event, eventErr = newSseErrorEvent(ginCtx, err); eventErr != nil {
+		reportGinError(ginCtx, fmt.Errorf("sse error event: %w", eventErr))
+		ginCtx.Abort()
+
+		return
+	}
+
+	// Send error as an SSE event instead of letting ErrorMiddleware corrupt the stream.
+	// The event is meant for this client only and is not recorded for replay.
+	if sendErr := writer.SendEvent(event); sendErr != nil && !errors.Is(sendErr, ErrClientDisconnected) {
+		reportGinError(ginCtx, fmt.Errorf("sse error event: %w", sendErr))
+	}
+
+	ginCtx.Abort()
+}
+
+// newSseErrorEvent renders the error like the error middleware would: as SseError or as problem, depending on the
+// errors settings of the server.
+func newSseErrorEvent(ginCtx *gin.Context, err error) (SseEvent, error) {
+	settings := getErrorsSettings(ginCtx)
+	statusCode := GetErrorStatusCode(err)
+	err = getPublicError(settings, statusCode, err)
+
+	var payload any = SseError{
+		Status:     statusCode,
+		Err:        err.Error(),
+		Violations: GetFieldViolations(err),
+	}
+
+	if settings.Format == ErrorFormatProblem {
+		problem := GetProblem(statusCode, err)
+		if problem.Instance == "" {
+			problem.Instance = ginCtx.Request.URL.Path
 		}
+
+		payload = problem
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return SseEvent{}, fmt.Errorf("can not encode error: %w", err)
 	}
+
+	return SseEvent{
+		Event: SseEventError,
+		Data:  string(data),
+	}, nil
+}
+
+// newBindError wraps an error of binding the request input with the status code 400. Bodies rejected for their size
+// are reported with 413.
+func newBindError(err error) ErrorWithStatus {
+	var maxBytesErr *http.MaxBytesError
+
+	if errors.Is(err, ErrDecompressedBodyTooLarge) || errors.Is(err, ErrUploadTooLarge) || errors.Is(err, ErrUploadTooManyFiles) || errors.As(err, &maxBytesErr) {
+		return NewErrorWithStatus(http.StatusRequestEntityTooLarge, err)
+	}
+
+	if errors.Is(err, ErrUploadContentType) {
+		return NewErrorWithStatus(http.StatusUnsupportedMediaType, err)
+	}
+
+	return NewErrorWithStatus(http.StatusBadRequest, err)
 }
 
 // BindHandleRequest binds request data into a new input value using explicit
@@ -163,7 +230,7 @@ func BindHandleRequest[I any](ginCtx *gin.Context, tags []string, binders []bind
 		if err := ginCtx.ShouldBindWith(in, binder); err != nil {
 			var validationErrors validator.ValidationErrors
 			if errors.As(err, &validationErrors) {
-				return nil, err
+				return nil, newBindValidationError(reflect.TypeFor[I](), err)
 			}
 
 			if strings.HasPrefix(err.Error(), binder.Name()+": ") {
@@ -176,11 +243,17 @@ func BindHandleRequest[I any](ginCtx *gin.Context, tags []string, binders []bind
 
 	if funk.Contains(tags, "uri") {
 		if err := ginCtx.ShouldBindUri(in); err != nil {
-			return nil, fmt.Errorf("uri: %w", err)
+			return nil, newBindValidationError(reflect.TypeFor[I](), fmt.Errorf("uri: %w", err))
 		}
 	}
 
-	if err := modifyInput(ginCtx, in); err != nil {
+	if scopedValidator := getScopedValidator(ginCtx); scopedValidator != nil {
+		if err := scopedValidator.ValidateStruct(in); err != nil {
+			return nil, newBindValidationError(reflect.TypeFor[I](), err)
+		}
+	}
+
+	if err := modifyInput(ginCtx, getModifier(ginCtx), in); err != nil {
 		return nil, err
 	}
 
@@ -214,7 +287,7 @@ func getContentTypeBinder(ginCtx *gin.Context) binding.Binding {
 	case binding.MIMETOML:
 		return binding.TOML
 	case binding.MIMEMultipartPOSTForm:
-		return binding.FormMultipart
+		return newUploadBinding(ginCtx)
 	case binding.MIMEPOSTForm:
 		return binding.Form
 	}
@@ -251,23 +324,62 @@ func getTagBinders(tags []string) (binders []binding.Binding) {
 
 // BindHandleResponse writes a Response to the Gin context, including status,
 // headers, and body handling for methods or status codes that must not include a body.
+// Negotiated responses are encoded according to the Accept header of the request first.
+// Successful GET and HEAD responses with an ETag or Last-Modified header are answered with
+// 304 Not Modified or 412 Precondition Failed if the conditional headers of the request ask for it.
 func BindHandleResponse(response Response, ginCtx *gin.Context) error {
 	var err error
 	var statusCode int
 	var header http.Header
 	var body []byte
 
+	if negotiable, ok := response.(negotiableResponse); ok {
+		addVary(ginCtx.Writer.Header(), HeaderAccept)
+
+		if response, err = negotiable.negotiate(ginCtx.Request, getNegotiationDefaultContentType(ginCtx)); err != nil {
+			return err
+		}
+	}
+
+	if streaming, ok := response.(streamingResponse); ok {
+		return streaming.writeTo(ginCtx)
+	}
+
 	statusCode = response.StatusCode()
 	header = response.Header()
 	bodyless := hasBodylessResponse(ginCtx.Request, statusCode)
+	etagMode := getBodyETag(response)
 
-	if !bodyless {
+	if !bodyless || etagMode != bodyETagNone {
 		if body, err = response.Body(); err != nil {
 			return fmt.Errorf("body read error: %w", err)
 		}
 	}
 
+	if etagMode != bodyETagNone {
+		header = header.Clone()
+		header.Set(HeaderETag, hashETag(body, etagMode == bodyETagWeak))
+	}
+
+	switch evaluateResponsePreconditions(ginCtx.Request, statusCode, header) {
+	case http.StatusPreconditionFailed:
+		return NewErrorWithStatus(http.StatusPreconditionFailed, ErrPreconditionFailed)
+	case http.StatusNotModified:
+		statusCode = http.StatusNotModified
+		bodyless = true
+
+		header = header.Clone()
+		deleteRepresentationHeaders(header)
+	}
+
 	for key, values := range header {
+		// the headers the response varies on add to the ones of the negotiation and the middlewares
+		if Normalize(key) == HeaderVary {
+			addVary(ginCtx.Writer.Header(), values...)
+
+			continue
+		}
+
 		for _, value := range values {
 			ginCtx.Header(key, value)
 		}
@@ -287,6 +399,50 @@ func BindHandleResponse(response Response, ginCtx *gin.Context) error {
 	return nil
 }
 
+// addVary adds the names to the Vary header of the response which it doesn't list yet.
+func addVary(header http.Header, names ...string) {
+	vary := parseVary(header)
+
+	for _, value := range names {
+		for name := range strings.SplitSeq(value, ",") {
+			if name = Normalize(strings.TrimSpace(name)); name != "" && !funk.Contains(vary, name) {
+				header.Add(HeaderVary, name)
+				vary = append(vary, name)
+			}
+		}
+	}
+}
+
+func getBodyETag(response Response) bodyETag {
+	if etagResponse, ok := response.(bodyETagResponse); ok {
+		return etagResponse.getBodyETag()
+	}
+
+	return bodyETagNone
+}
+
+// evaluateResponsePreconditions evaluates the conditional headers of GET and HEAD requests against the validators of
+// their successful response. Unsafe requests have to check their preconditions before changing the resource, see
+// CheckPreconditions.
+func evaluateResponsePreconditions(request *http.Request, statusCode int, header http.Header) int {
+	if request == nil || request.Method != http.MethodGet && request.Method != http.MethodHead {
+		return 0
+	}
+
+	if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+		return 0
+	}
+
+	etag := header.Get(HeaderETag)
+	lastModified, _ := parseHttpTime(header.Get(HeaderLastModified))
+
+	if etag == "" && lastModified.IsZero() {
+		return 0
+	}
+
+	return evaluatePreconditions(request, true, etag, lastModified)
+}
+
 func reportGinError(ginCtx *gin.Context, err error) {
 	reportGinErrorWithType(ginCtx, err, gin.ErrorTypePrivate)
 }
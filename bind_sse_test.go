@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gosoline-project/httpserver"
@@ -98,7 +99,59 @@ func (s *BindSseTestSuite) TestBindSse_HandlerError() {
 	body := rec.Body.String()
 	s.Contains(body, "data: before error\n\n")
 	s.Contains(body, "event: error\n")
-	s.Contains(body, "data: something went wrong\n")
+	s.Contains(body, `data: {"status":500,"err":"internal server error"}`+"\n", "internal errors must not leak")
+}
+
+func (s *BindSseTestSuite) TestBindSse_HandlerErrorSettings() {
+	testCases := map[string]struct {
+		settings  httpserver.ErrorsSettings
+		err       error
+		expectErr string
+	}{
+		"public": {
+			settings:  httpserver.ErrorsSettings{Privacy: httpserver.ErrorPrivacyPublic},
+			err:       errors.New("something went wrong"),
+			expectErr: `data: {"status":500,"err":"something went wrong"}`,
+		},
+		"client error": {
+			settings:  httpserver.ErrorsSettings{Privacy: httpserver.ErrorPrivacyPrivate},
+			err:       httpserver.NewErrorWithStatus(http.StatusConflict, errors.New("already subscribed")),
+			expectErr: `data: {"status":409,"err":"already subscribed"}`,
+		},
+		"problem": {
+			settings:  httpserver.ErrorsSettings{Privacy: httpserver.ErrorPrivacyPrivate, Format: httpserver.ErrorFormatProblem},
+			err:       errors.New("something went wrong"),
+			expectErr: `data: {"detail":"internal server error","instance":"/sse","status":500,"title":"Internal Server Error","type":"about:blank"}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		s.Run(name, func() {
+			router := gin.New()
+			router.Use(httpserver.ErrorMiddlewareWithSettings(tc.settings))
+			router.GET("/sse", httpserver.BindSseN(func(ctx context.Context, writer *httpserver.SseWriter) error {
+				return tc.err
+			}))
+
+			rec := s.serveRequest(router, http.MethodGet, "/sse", "", nil)
+
+			s.Equal("event: error\n"+tc.expectErr+"\n\n", rec.Body.String())
+		})
+	}
+}
+
+func (s *BindSseTestSuite) TestBindSse_HeartbeatSettings() {
+	router := gin.New()
+	router.Use(httpserver.SseMiddleware(httpserver.SseSettings{HeartbeatInterval: 10 * time.Millisecond}))
+	router.GET("/sse", httpserver.BindSseN(func(ctx context.Context, writer *httpserver.SseWriter) error {
+		time.Sleep(50 * time.Millisecond)
+
+		return nil
+	}))
+
+	rec := s.serveRequest(router, http.MethodGet, "/sse", "", nil)
+
+	s.Contains(rec.Body.String(), ": heartbeat\n\n")
 }
 
 func (s *BindSseTestSuite) TestBindSse_BindingError() {
@@ -253,3 +306,55 @@ func (s *BindSseTestSuite) TestBindSse_StructuredEvents() {
 	s.Contains(body, "id: 3\n")
 	s.Contains(body, "retry: 5000\n")
 }
+
+type sseResumeTestInput struct {
+	httpserver.SseResumeInput
+	Topic string `form:"topic"`
+}
+
+func (s *BindSseTestSuite) TestBindSse_Resume() {
+	store := httpserver.NewInMemorySseReplayStore(10, time.Minute)
+
+	router := gin.New()
+	router.GET("/events", httpserver.BindSse(func(ctx context.Context, input *sseResumeTestInput, writer *httpserver.SseWriter) error {
+		s.Equal(input.LastEventId, writer.LastEventId())
+
+		if err := writer.Replay(input.Topic, store); err != nil {
+			return err
+		}
+
+		if input.LastEventId != "" {
+			event, err := store.Append(ctx, input.Topic, httpserver.SseEvent{Data: "live"})
+			if err != nil {
+				return err
+			}
+
+			return writer.SendEvent(event)
+		}
+
+		for _, data := range []string{"a", "b", "c"} {
+			event, err := store.Append(ctx, input.Topic, httpserver.SseEvent{Id: data, Data: data})
+			if err != nil {
+				return err
+			}
+
+			if err = writer.SendEvent(event); err != nil {
+				return err
+			}
+		}
+
+		return errors.New("stream failed")
+	}))
+
+	rec := s.serveRequest(router, http.MethodGet, "/events?topic=news", "", nil)
+	s.Equal("id: a\ndata: a\n\nid: b\ndata: b\n\nid: c\ndata: c\n\nevent: error\ndata: {\"status\":500,\"err\":\"internal server error\"}\n\n", rec.Body.String())
+
+	rec = s.serveRequest(router, http.MethodGet, "/events?topic=news", "", map[string]string{
+		httpserver.HeaderLastEventId: "a",
+	})
+
+	body := rec.Body.String()
+	s.True(strings.HasPrefix(body, "id: b\ndata: b\n\nid: c\ndata: c\n\nid: "), "missed events must be sent first: %q", body)
+	s.True(strings.HasSuffix(body, "\ndata: live\n\n"), "live event must be sent last: %q", body)
+	s.NotContains(body, "event: error", "error events are not replayed")
+}
@@ -67,6 +67,7 @@ func newTestRouter(register func(r *gin.Engine)) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 
 	r := gin.New()
+	r.ContextWithFallback = true
 	r.Use(httpserver.ErrorMiddleware())
 
 	register(r)
@@ -74,6 +75,18 @@ func newTestRouter(register func(r *gin.Engine)) *gin.Engine {
 	return r
 }
 
+func serveTestRequest(router http.Handler, method string, path string, body io.Reader, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, body)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	return recorder
+}
+
 func TestBindCases(t *testing.T) {
 	cases := []struct {
 		name         string
@@ -284,9 +297,12 @@ func TestBindFailureCases(t *testing.T) {
 		},
 		{
 			// Validator errors come from the bind phase, but should not be prefixed with the binder name.
-			name:         "validation error returns bad request",
-			body:         `{"count":1}`,
-			expectedBody: `{"err":"Key: 'bindValidatedJsonInput.Name' Error:Field validation for 'Name' failed on the 'required' tag"}`,
+			name: "validation error returns bad request",
+			body: `{"count":1}`,
+			expectedBody: `{
+				"err":"Key: 'bindValidatedJsonInput.Name' Error:Field validation for 'Name' failed on the 'required' tag",
+				"violations":[{"field":"name","rule":"required","message":"is required"}]
+			}`,
 		},
 		{
 			// Decode errors should short-circuit validation and avoid duplicate prefixes like "json: json:".
@@ -0,0 +1,143 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ResponseMeta holds the optional status code and headers of a response produced by a typed handler.
+type ResponseMeta struct {
+	statusCode int
+	header     http.Header
+}
+
+type responseMetaKey struct{}
+
+// GetResponseMeta returns the response metadata of the typed handler serving the request of ctx. Outside of
+// the BindT family of functions a detached value is returned, so changes to it have no effect.
+func GetResponseMeta(ctx context.Context) *ResponseMeta {
+	if meta, ok := ctx.Value(responseMetaKey{}).(*ResponseMeta); ok {
+		return meta
+	}
+
+	return newResponseMeta()
+}
+
+func newResponseMeta() *ResponseMeta {
+	return &ResponseMeta{
+		header: make(http.Header),
+	}
+}
+
+// SetStatusCode overrides the status code of the response. By default, 200 is used if the handler returns
+// a value and 204 if it returns nil. Document the status code with WithRouteSuccessStatus.
+func (m *ResponseMeta) SetStatusCode(statusCode int) {
+	m.statusCode = statusCode
+}
+
+// Header returns the headers which are added to the response.
+func (m *ResponseMeta) Header() http.Header {
+	return m.header
+}
+
+// BindT adapts a typed handler returning its output value into a Gin handler. The output is encoded
+// by the server as JSON, XML, or protobuf depending on what the client accepts (see NewNegotiatedResponse).
+func BindT[I any, O any](handler func(ctx context.Context, input *I) (*O, error), binders ...binding.Binding) gin.HandlerFunc {
+	return BindTR[I, O](func(ctx context.Context, _ *http.Request, input *I) (*O, error) {
+		return handler(ctx, input)
+	}, binders...)
+}
+
+// BindTR adapts a typed handler like BindT, but also passes the raw HTTP request to the handler.
+func BindTR[I any, O any](handler func(ctx context.Context, req *http.Request, input *I) (*O, error), binders ...binding.Binding) gin.HandlerFunc {
+	ginHandler := BindR[I](func(ctx context.Context, req *http.Request, input *I) (Response, error) {
+		meta := newResponseMeta()
+
+		output, err := handler(context.WithValue(ctx, responseMetaKey{}, meta), req, input)
+		if err != nil {
+			return nil, err
+		}
+
+		return newTypedResponse(output, meta), nil
+	}, binders...)
+
+	addTypedRouteSpec[O](GetRouteSpec(ginHandler))
+
+	return ginHandler
+}
+
+// BindTN adapts a typed handler that does not need request input binding.
+func BindTN[O any](handler func(ctx context.Context) (*O, error)) gin.HandlerFunc {
+	return BindTNR[O](func(ctx context.Context, _ *http.Request) (*O, error) {
+		return handler(ctx)
+	})
+}
+
+// BindTNR adapts a typed handler that does not need request input binding, but still needs access to the
+// raw HTTP request.
+func BindTNR[O any](handler func(ctx context.Context, req *http.Request) (*O, error)) gin.HandlerFunc {
+	ginHandler := BindNR(func(ctx context.Context, req *http.Request) (Response, error) {
+		meta := newResponseMeta()
+
+		output, err := handler(context.WithValue(ctx, responseMetaKey{}, meta), req)
+		if err != nil {
+			return nil, err
+		}
+
+		return newTypedResponse(output, meta), nil
+	})
+
+	addTypedRouteSpec[O](GetRouteSpec(ginHandler))
+
+	return ginHandler
+}
+
+func newTypedResponse[O any](output *O, meta *ResponseMeta) Response {
+	statusCode := meta.statusCode
+
+	if output == nil {
+		if statusCode == 0 {
+			statusCode = http.StatusNoContent
+		}
+
+		return NewResponse(WithStatusCode(statusCode), WithHeaders(meta.header))
+	}
+
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	return NewNegotiatedResponse(output, WithHeaders(meta.header), WithStatusCode(statusCode))
+}
+
+func addTypedRouteSpec[O any](spec *RouteSpec) {
+	output := reflect.TypeFor[O]()
+	contentTypes := make([]string, 0, len(responseEncodings))
+
+	for _, encoding := range responseEncodings {
+		if encoding.supports(reflect.New(output).Interface()) {
+			contentTypes = append(contentTypes, encoding.mediaTypes[0])
+		}
+	}
+
+	spec.Output = output
+	spec.setResponse(RouteResponse{
+		StatusCode:   http.StatusOK,
+		Description:  http.StatusText(http.StatusOK),
+		Type:         output,
+		ContentTypes: contentTypes,
+	})
+	// handlers returning a nil output respond without a body
+	spec.setResponse(RouteResponse{
+		StatusCode:  http.StatusNoContent,
+		Description: http.StatusText(http.StatusNoContent),
+	})
+	spec.setResponse(RouteResponse{
+		StatusCode:  http.StatusInternalServerError,
+		Description: http.StatusText(http.StatusInternalServerError),
+	})
+}
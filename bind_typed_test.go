@@ -0,0 +1,173 @@
+package httpserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/justtrackio/gosoline/pkg/test/suite/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+type bindTypedOutput struct {
+	Greeting string `json:"greeting" xml:"greeting"`
+}
+
+func TestBindTCases(t *testing.T) {
+	cases := []struct {
+		name                string
+		handler             gin.HandlerFunc
+		headers             map[string]string
+		expectedCode        int
+		expectedContentType string
+		expectedHeader      http.Header
+		expectedBody        string
+	}{
+		{
+			// Without an Accept header the output should be encoded as JSON.
+			name: "json by default",
+			handler: httpserver.BindT(func(ctx context.Context, input *bindJsonInput) (*bindTypedOutput, error) {
+				return &bindTypedOutput{Greeting: "hello " + input.Name}, nil
+			}),
+			headers:             map[string]string{httpserver.HeaderContentType: httpserver.ContentTypeApplicationJson},
+			expectedCode:        http.StatusOK,
+			expectedContentType: httpserver.ContentTypeJson,
+			expectedBody:        `{"greeting":"hello alice"}`,
+		},
+		{
+			// Clients asking for XML should get the output encoded as XML.
+			name: "xml when accepted",
+			handler: httpserver.BindT(func(ctx context.Context, input *bindJsonInput) (*bindTypedOutput, error) {
+				return &bindTypedOutput{Greeting: "hello " + input.Name}, nil
+			}),
+			headers: map[string]string{
+				httpserver.HeaderContentType: httpserver.ContentTypeApplicationJson,
+				httpserver.HeaderAccept:      "text/xml",
+			},
+			expectedCode:        http.StatusOK,
+			expectedContentType: httpserver.ContentTypeXml,
+			expectedBody:        `<bindTypedOutput><greeting>hello alice</greeting></bindTypedOutput>`,
+		},
+		{
+			// Status and headers set through the response meta should be used for the response.
+			name: "response meta",
+			handler: httpserver.BindTN(func(ctx context.Context) (*bindTypedOutput, error) {
+				meta := httpserver.GetResponseMeta(ctx)
+				meta.SetStatusCode(http.StatusCreated)
+				meta.Header().Set(httpserver.HeaderLocation, "/greetings/1")
+
+				return &bindTypedOutput{Greeting: "created"}, nil
+			}),
+			expectedCode:        http.StatusCreated,
+			expectedContentType: httpserver.ContentTypeJson,
+			expectedHeader:      http.Header{httpserver.HeaderLocation: {"/greetings/1"}},
+			expectedBody:        `{"greeting":"created"}`,
+		},
+		{
+			// A nil output without explicit status should result in 204 No Content.
+			name: "nil output",
+			handler: httpserver.BindTN(func(ctx context.Context) (*bindTypedOutput, error) {
+				return nil, nil
+			}),
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			// Handler errors should be rendered by the error middleware.
+			name: "handler error",
+			handler: httpserver.BindTN(func(ctx context.Context) (*bindTypedOutput, error) {
+				return nil, httpserver.NewErrorWithStatus(http.StatusConflict, errors.New("already exists"))
+			}),
+			expectedCode:        http.StatusConflict,
+			expectedContentType: httpserver.ContentTypeJson,
+			expectedBody:        `{"err":"already exists"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRouter(func(r *gin.Engine) {
+				r.POST("/typed", tc.handler)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/typed", strings.NewReader(`{"name":"alice"}`))
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			recorder := httptest.NewRecorder()
+
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code)
+			assert.Equal(t, tc.expectedContentType, recorder.Header().Get(httpserver.HeaderContentType))
+			assert.Equal(t, tc.expectedBody, recorder.Body.String())
+
+			for key := range tc.expectedHeader {
+				assert.Equal(t, tc.expectedHeader.Get(key), recorder.Header().Get(key))
+			}
+		})
+	}
+}
+
+func TestBindTEncodesProtobufWhenAccepted(t *testing.T) {
+	handler := httpserver.BindTN(func(ctx context.Context) (*TestInput, error) {
+		return &TestInput{Text: "hello"}, nil
+	})
+
+	response := httpserver.HttpTest(http.MethodGet, "/", "/", "", handler, func(r *http.Request) {
+		r.Header.Set(httpserver.HeaderAccept, httpserver.ContentTypeProtobuf)
+	})
+
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, httpserver.ContentTypeProtobuf, response.Header().Get(httpserver.HeaderContentType))
+
+	message := &testdata.TestInput{}
+	require.NoError(t, proto.Unmarshal(response.Body.Bytes(), message))
+	assert.Equal(t, "hello", message.GetText())
+}
+
+func TestBindTRecordsOutputType(t *testing.T) {
+	handler := httpserver.BindT(func(ctx context.Context, input *bindJsonInput) (*TestInput, error) {
+		return nil, nil
+	})
+
+	router := &httpserver.Router{}
+	router.GET("/typed", handler)
+
+	spec := httpserver.GetRouteSpec(handler)
+	require.NotNil(t, spec)
+	assert.Equal(t, "bindJsonInput", spec.Input.Name())
+	assert.Equal(t, "TestInput", spec.Output.Name())
+	assert.Equal(t, []string{"application/json", "application/xml", "application/x-protobuf"}, spec.Response(http.StatusOK).ContentTypes)
+	assert.Equal(t, &httpserver.RouteResponse{StatusCode: http.StatusNoContent, Description: "No Content"}, spec.Response(http.StatusNoContent))
+	assert.NotNil(t, spec.Response(http.StatusBadRequest))
+	assert.NotNil(t, spec.Response(http.StatusInternalServerError))
+}
+
+func TestBindTDescribesSuccessStatus(t *testing.T) {
+	handler := httpserver.Describe(
+		httpserver.BindT(func(ctx context.Context, input *bindJsonInput) (*TestInput, error) {
+			httpserver.GetResponseMeta(ctx).SetStatusCode(http.StatusCreated)
+
+			return &TestInput{Text: input.Name}, nil
+		}),
+		httpserver.WithRouteSuccessStatus(http.StatusCreated, "the created input"),
+	)
+
+	spec := httpserver.GetRouteSpec(handler)
+	require.NotNil(t, spec)
+	assert.Nil(t, spec.Response(http.StatusOK))
+	assert.Equal(t, &httpserver.RouteResponse{
+		StatusCode:   http.StatusCreated,
+		Description:  "the created input",
+		Type:         spec.Output,
+		ContentTypes: []string{"application/json", "application/xml", "application/x-protobuf"},
+	}, spec.Response(http.StatusCreated))
+	assert.NotNil(t, spec.Response(http.StatusBadRequest))
+}
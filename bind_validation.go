@@ -0,0 +1,226 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldViolation describes a single field of the request input failing validation.
+type FieldViolation struct {
+	// Field is the path of the field as seen by the client, e.g. "items[0].name".
+	Field string `json:"field"`
+	// Rule is the validation rule which failed, e.g. "required" or "min".
+	Rule string `json:"rule"`
+	// Param is the parameter of the rule, e.g. "3" for "min=3".
+	Param string `json:"param,omitempty"`
+	// Message is a human-readable description of the violation.
+	Message string `json:"message"`
+}
+
+// BindValidationError is returned if the bound request input fails validation. It results in a 400 response
+// listing the field violations.
+type BindValidationError struct {
+	err        error
+	violations []FieldViolation
+}
+
+var _ ErrorWithStatus = &BindValidationError{}
+
+func (e *BindValidationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *BindValidationError) Unwrap() error {
+	return e.err
+}
+
+func (e *BindValidationError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// Violations returns the field violations of the input.
+func (e *BindValidationError) Violations() []FieldViolation {
+	return e.violations
+}
+
+// GetFieldViolations returns the field violations carried by err, or nil if err is no bind validation error.
+func GetFieldViolations(err error) []FieldViolation {
+	var bindErr *BindValidationError
+	if errors.As(err, &bindErr) {
+		return bindErr.Violations()
+	}
+
+	return nil
+}
+
+// newBindValidationError converts validator errors of an input of the given type into a BindValidationError.
+// Other errors are returned unchanged.
+func newBindValidationError(input reflect.Type, err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	violations := make([]FieldViolation, 0, len(validationErrors))
+	for _, vErr := range validationErrors {
+		violations = append(violations, FieldViolation{
+			Field:   getFieldViolationPath(input, vErr),
+			Rule:    vErr.Tag(),
+			Param:   vErr.Param(),
+			Message: getFieldViolationMessage(vErr),
+		})
+	}
+
+	return &BindValidationError{
+		err:        err,
+		violations: violations,
+	}
+}
+
+// getFieldViolationPath maps the struct namespace of a validation error to the names used by the client.
+// If a field can't be found in the input type, the namespace reported by the validator is used.
+func getFieldViolationPath(input reflect.Type, vErr validator.FieldError) string {
+	segments := strings.Split(vErr.StructNamespace(), ".")
+	if len(segments) < 2 {
+		return vErr.Field()
+	}
+
+	path := make([]string, 0, len(segments)-1)
+	typ := input
+
+	for _, segment := range segments[1:] {
+		name, index, _ := strings.Cut(segment, "[")
+
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+
+		if typ.Kind() != reflect.Struct {
+			return strings.Join(segments[1:], ".")
+		}
+
+		field, ok := typ.FieldByName(name)
+		if !ok {
+			return strings.Join(segments[1:], ".")
+		}
+
+		typ = field.Type
+
+		clientName := getFieldClientName(field)
+		if index != "" {
+			clientName += "[" + index
+			typ = getIndexedType(typ, strings.Count(index, "["))
+		}
+
+		if field.Anonymous && clientName == field.Name {
+			continue
+		}
+
+		path = append(path, clientName)
+	}
+
+	return strings.Join(path, ".")
+}
+
+// getFieldClientName returns the name of a field in the json, form, uri, or header tag and falls back to the field name.
+func getFieldClientName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri", "header"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// getIndexedType returns the element type reached by indexing typ the given number of additional times.
+func getIndexedType(typ reflect.Type, additional int) reflect.Type {
+	for range additional + 1 {
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+
+		switch typ.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			typ = typ.Elem()
+		default:
+			return typ
+		}
+	}
+
+	return typ
+}
+
+func getFieldViolationMessage(vErr validator.FieldError) string {
+	kind := vErr.Kind()
+	param := vErr.Param()
+
+	unit := ""
+	switch kind {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch vErr.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_with_all", "required_without", "required_without_all":
+		return "is required"
+	case "min", "gte":
+		if unit != "" {
+			return fmt.Sprintf("must contain at least %s%s", param, unit)
+		}
+
+		return fmt.Sprintf("must be greater than or equal to %s", param)
+	case "max", "lte":
+		if unit != "" {
+			return fmt.Sprintf("must contain at most %s%s", param, unit)
+		}
+
+		return fmt.Sprintf("must be less than or equal to %s", param)
+	case "gt":
+		if unit != "" {
+			return fmt.Sprintf("must contain more than %s%s", param, unit)
+		}
+
+		return fmt.Sprintf("must be greater than %s", param)
+	case "lt":
+		if unit != "" {
+			return fmt.Sprintf("must contain less than %s%s", param, unit)
+		}
+
+		return fmt.Sprintf("must be less than %s", param)
+	case "len":
+		if unit != "" {
+			return fmt.Sprintf("must contain exactly %s%s", param, unit)
+		}
+
+		return fmt.Sprintf("must be equal to %s", param)
+	case "eq":
+		return fmt.Sprintf("must be equal to %s", param)
+	case "ne":
+		return fmt.Sprintf("must not be equal to %s", param)
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", param)
+	case "email":
+		return "must be a valid email address"
+	case "url", "uri", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "datetime":
+		return fmt.Sprintf("must be a datetime in the format %s", param)
+	}
+
+	if param != "" {
+		return fmt.Sprintf("must satisfy the '%s=%s' rule", vErr.Tag(), param)
+	}
+
+	return fmt.Sprintf("must satisfy the '%s' rule", vErr.Tag())
+}
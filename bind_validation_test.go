@@ -0,0 +1,106 @@
+package httpserver_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindValidationBase struct {
+	Tenant string `json:"tenant" binding:"required"`
+}
+
+type bindValidationItem struct {
+	Sku      string `json:"sku"      binding:"required"`
+	Quantity int    `json:"quantity" binding:"gte=1"`
+}
+
+type bindValidationInput struct {
+	bindValidationBase
+	Name     string                `json:"name"                binding:"min=3"`
+	Email    string                `json:"email,omitempty"     binding:"omitempty,email"`
+	Role     string                `json:"role"                binding:"oneof=admin member"`
+	Items    []bindValidationItem  `json:"items"               binding:"max=2,dive"`
+	Labels   map[string]string     `json:"labels"              binding:"dive,keys,min=1,endkeys,max=3"`
+	Address  *bindValidationNested `json:"address"             binding:"required"`
+	Untagged string                `binding:"len=2"`
+}
+
+type bindValidationNested struct {
+	Zip string `json:"zip" binding:"numeric"`
+}
+
+type bindValidationQueryInput struct {
+	Page int `form:"page" binding:"min=1"`
+}
+
+func TestBindValidationErrorListsFieldViolations(t *testing.T) {
+	var violations []httpserver.FieldViolation
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.Use(func(c *gin.Context) {
+			c.Next()
+
+			violations = httpserver.GetFieldViolations(c.Errors.Last())
+		})
+		r.POST("/validated", httpserver.Bind(func(ctx context.Context, input *bindValidationInput) (httpserver.Response, error) {
+			return httpserver.NewStatusResponse(http.StatusNoContent), nil
+		}))
+	})
+
+	body := `{
+		"name": "ab",
+		"email": "no-mail",
+		"role": "guest",
+		"items": [{"sku": "a", "quantity": 1}, {"quantity": 0}],
+		"labels": {"color": "blue"},
+		"address": {"zip": "abc"},
+		"Untagged": "x"
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/validated", strings.NewReader(body))
+	req.Header.Set(httpserver.HeaderContentType, httpserver.ContentTypeApplicationJson)
+	recorder := httptest.NewRecorder()
+
+	r.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, []httpserver.FieldViolation{
+		{Field: "tenant", Rule: "required", Message: "is required"},
+		{Field: "name", Rule: "min", Param: "3", Message: "must contain at least 3 characters"},
+		{Field: "email", Rule: "email", Message: "must be a valid email address"},
+		{Field: "role", Rule: "oneof", Param: "admin member", Message: "must be one of [admin member]"},
+		{Field: "items[1].sku", Rule: "required", Message: "is required"},
+		{Field: "items[1].quantity", Rule: "gte", Param: "1", Message: "must be greater than or equal to 1"},
+		{Field: "labels[color]", Rule: "max", Param: "3", Message: "must contain at most 3 characters"},
+		{Field: "address.zip", Rule: "numeric", Message: "must satisfy the 'numeric' rule"},
+		{Field: "Untagged", Rule: "len", Param: "2", Message: "must contain exactly 2 characters"},
+	}, violations)
+}
+
+func TestBindValidationErrorUsesFormTagName(t *testing.T) {
+	handler := httpserver.Bind(func(ctx context.Context, input *bindValidationQueryInput) (httpserver.Response, error) {
+		return httpserver.NewStatusResponse(http.StatusNoContent), nil
+	})
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.GET("/list", handler)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/list?page=0", http.NoBody)
+	recorder := httptest.NewRecorder()
+
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{
+		"err":"Key: 'bindValidationQueryInput.Page' Error:Field validation for 'Page' failed on the 'min' tag",
+		"violations":[{"field":"page","rule":"min","param":"1","message":"must be greater than or equal to 1"}]
+	}`, recorder.Body.String())
+}
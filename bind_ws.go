@@ -0,0 +1,127 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+	"github.com/justtrackio/gosoline/pkg/refl"
+)
+
+// BindWs adapts a typed WebSocket handler into a Gin handler by binding the data of the upgrade request into the
+// input struct, upgrading the connection, and providing a stream of typed messages.
+//
+// The connection is closed once the handler returns: with a normal close frame if it returns nil, with the code of
+// a *WsCloseError, or with an internal error close frame otherwise. Internal errors are logged, their details are
+// only sent to the client if the errors privacy of the server is public.
+func BindWs[I any, R any, S any](handler func(ctx context.Context, input *I, stream *WsStream[R, S]) error, binders ...binding.Binding) gin.HandlerFunc {
+	return BindWsR[I](func(ctx context.Context, _ *http.Request, input *I, stream *WsStream[R, S]) error {
+		return handler(ctx, input, stream)
+	}, binders...)
+}
+
+// BindWsR adapts a typed WebSocket handler like BindWs, but also passes the raw
+// upgrade request to the handler.
+func BindWsR[I any, R any, S any](handler func(ctx context.Context, req *http.Request, input *I, stream *WsStream[R, S]) error, binders ...binding.Binding) gin.HandlerFunc {
+	tags := refl.GetTagNames(new(I))
+	spec := newBindRouteSpec[I](tags, binders)
+	spec.setResponse(wsRouteResponse())
+
+	return registerRouteSpec(func(ginCtx *gin.Context) {
+		var err error
+		var input *I
+
+		if input, err = BindHandleRequest[I](ginCtx, tags, binders); err != nil {
+			reportGinErrorWithType(ginCtx, newBindError(err), gin.ErrorTypeBind)
+
+			return
+		}
+
+		handleWs(ginCtx, func(ctx context.Context, stream *WsStream[R, S]) error {
+			return handler(ctx, ginCtx.Request, input, stream)
+		})
+	}, spec)
+}
+
+// BindWsN adapts a WebSocket handler that does not need request input binding.
+func BindWsN[R any, S any](handler func(ctx context.Context, stream *WsStream[R, S]) error) gin.HandlerFunc {
+	return BindWsNR(func(ctx context.Context, _ *http.Request, stream *WsStream[R, S]) error {
+		return handler(ctx, stream)
+	})
+}
+
+// BindWsNR adapts a WebSocket handler that does not need request input binding, but
+// still needs access to the raw upgrade request.
+func BindWsNR[R any, S any](handler func(ctx context.Context, req *http.Request, stream *WsStream[R, S]) error) gin.HandlerFunc {
+	return registerRouteSpec(func(ginCtx *gin.Context) {
+		handleWs(ginCtx, func(ctx context.Context, stream *WsStream[R, S]) error {
+			return handler(ctx, ginCtx.Request, stream)
+		})
+	}, &RouteSpec{
+		Responses: []RouteResponse{wsRouteResponse()},
+	})
+}
+
+func handleWs[R any, S any](ginCtx *gin.Context, handler func(ctx context.Context, stream *WsStream[R, S]) error) {
+	settings := defaultWebsocketSettings
+	tracker := getWsTracker(ginCtx.Request.Context())
+
+	if tracker != nil {
+		settings = tracker.settings
+	}
+
+	var err error
+	var handshakeStatus int
+	var handshakeErr error
+	var conn *websocket.Conn
+
+	upgrader := newWsUpgrader(settings)
+	upgrader.Error = func(_ http.ResponseWriter, _ *http.Request, status int, reason error) {
+		handshakeStatus = status
+		handshakeErr = reason
+	}
+
+	if conn, err = upgrader.Upgrade(ginCtx.Writer, ginCtx.Request, nil); err != nil {
+		if handshakeErr == nil {
+			reportGinError(ginCtx, fmt.Errorf("websocket upgrade: %w", err))
+
+			return
+		}
+
+		AbortWithError(ginCtx, NewErrorWithStatus(handshakeStatus, handshakeErr))
+
+		return
+	}
+
+	stream := newWsStream[R, S](ginCtx.Request.Context(), conn, settings)
+
+	if tracker != nil {
+		tracker.add(stream)
+		defer tracker.remove(stream)
+	}
+
+	// the response has been written by the upgrade, the error middleware must not write to the hijacked connection
+	ginCtx.Abort()
+
+	var closeErr *WsCloseError
+
+	err = handler(stream.Context(), stream)
+
+	switch {
+	case err == nil:
+		_ = stream.Close(WsCloseNormal, "")
+	case errors.As(err, &closeErr):
+		_ = stream.Close(closeErr.Code, closeErr.Reason)
+	case errors.Is(err, ErrWsClosed):
+		_ = stream.Close(WsCloseNormal, "")
+	default:
+		// the error is logged, the client only gets its details if the errors privacy is public
+		reportGinError(ginCtx, err)
+		err = getPublicError(getErrorsSettings(ginCtx), http.StatusInternalServerError, err)
+		_ = stream.Close(WsCloseInternalError, err.Error())
+	}
+}
@@ -0,0 +1,191 @@
+package httpserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/gosoline-project/httpserver"
+	"github.com/stretchr/testify/suite"
+)
+
+type wsTestInput struct {
+	Name string `form:"name" binding:"required"`
+}
+
+type wsTestMessage struct {
+	Text string `json:"text"`
+}
+
+type wsTestReply struct {
+	Greeting string `json:"greeting"`
+}
+
+func TestBindWsTestSuite(t *testing.T) {
+	suite.Run(t, new(BindWsTestSuite))
+}
+
+type BindWsTestSuite struct {
+	suite.Suite
+
+	handlerErr chan error
+}
+
+func (s *BindWsTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+
+	s.handlerErr = make(chan error, 1)
+}
+
+func (s *BindWsTestSuite) serve(handler gin.HandlerFunc) string {
+	router := gin.New()
+	router.Use(httpserver.ErrorMiddleware())
+	router.GET("/ws", handler)
+
+	server := httptest.NewServer(router)
+	s.T().Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+}
+
+func (s *BindWsTestSuite) dial(url string) *websocket.Conn {
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	s.Require().NoError(err)
+	s.Equal(http.StatusSwitchingProtocols, resp.StatusCode)
+	s.T().Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return conn
+}
+
+func (s *BindWsTestSuite) TestEcho() {
+	url := s.serve(httpserver.BindWs(func(ctx context.Context, input *wsTestInput, stream *httpserver.WsStream[wsTestMessage, wsTestReply]) error {
+		for {
+			msg, err := stream.Receive()
+			if err != nil {
+				s.handlerErr <- err
+
+				return err
+			}
+
+			if err = stream.Send(wsTestReply{Greeting: input.Name + ": " + msg.Text}); err != nil {
+				return err
+			}
+		}
+	}))
+
+	conn := s.dial(url + "?name=alice")
+
+	s.NoError(conn.WriteJSON(wsTestMessage{Text: "hello"}))
+
+	reply := wsTestReply{}
+	s.NoError(conn.ReadJSON(&reply))
+	s.Equal("alice: hello", reply.Greeting)
+
+	s.NoError(conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "done")))
+
+	err := <-s.handlerErr
+	s.ErrorIs(err, httpserver.ErrWsClosed)
+
+	var closeErr *httpserver.WsCloseError
+	s.Require().True(errors.As(err, &closeErr))
+	s.Equal(websocket.CloseNormalClosure, closeErr.Code)
+	s.Equal("done", closeErr.Reason)
+}
+
+func (s *BindWsTestSuite) TestInvalidMessageDoesNotClose() {
+	url := s.serve(httpserver.BindWsN(func(ctx context.Context, stream *httpserver.WsStream[wsTestMessage, string]) error {
+		_, err := stream.Receive()
+		s.Error(err)
+		s.NotErrorIs(err, httpserver.ErrWsClosed)
+
+		msg, err := stream.Receive()
+		if err != nil {
+			return err
+		}
+
+		return stream.Send(msg.Text)
+	}))
+
+	conn := s.dial(url)
+
+	s.NoError(conn.WriteMessage(websocket.TextMessage, []byte("{")))
+	s.NoError(conn.WriteJSON(wsTestMessage{Text: "valid"}))
+
+	messageType, data, err := conn.ReadMessage()
+	s.NoError(err)
+	s.Equal(websocket.TextMessage, messageType)
+	s.Equal("valid", string(data))
+}
+
+func (s *BindWsTestSuite) TestHandlerCloseCodes() {
+	testCases := map[string]struct {
+		err        error
+		expectCode int
+		expectText string
+	}{
+		"nil": {
+			expectCode: websocket.CloseNormalClosure,
+		},
+		"close error": {
+			err:        httpserver.NewWsCloseError(4001, "bye"),
+			expectCode: 4001,
+			expectText: "bye",
+		},
+		"long reason": {
+			err:        httpserver.NewWsCloseError(4002, strings.Repeat("ä", 100)),
+			expectCode: 4002,
+			expectText: strings.Repeat("ä", 61),
+		},
+		"other error": {
+			err:        errors.New("boom"),
+			expectCode: websocket.CloseInternalServerErr,
+			expectText: "internal server error",
+		},
+	}
+
+	for name, tc := range testCases {
+		s.Run(name, func() {
+			url := s.serve(httpserver.BindWsN(func(ctx context.Context, stream *httpserver.WsStream[[]byte, []byte]) error {
+				return tc.err
+			}))
+
+			conn := s.dial(url)
+
+			_, _, err := conn.ReadMessage()
+
+			var closeErr *websocket.CloseError
+			s.Require().True(errors.As(err, &closeErr))
+			s.Equal(tc.expectCode, closeErr.Code)
+			s.Equal(tc.expectText, closeErr.Text)
+		})
+	}
+}
+
+func (s *BindWsTestSuite) TestBindError() {
+	url := s.serve(httpserver.BindWs(func(ctx context.Context, input *wsTestInput, stream *httpserver.WsStream[wsTestMessage, wsTestReply]) error {
+		s.Fail("handler must not be called")
+
+		return nil
+	}))
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	s.ErrorIs(err, websocket.ErrBadHandshake)
+	s.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *BindWsTestSuite) TestOriginCheck() {
+	url := s.serve(httpserver.BindWsN(func(ctx context.Context, stream *httpserver.WsStream[[]byte, []byte]) error {
+		return nil
+	}))
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{httpserver.HeaderOrigin: []string{"https://evil.example.com"}})
+	s.ErrorIs(err, websocket.ErrBadHandshake)
+	s.Equal(http.StatusForbidden, resp.StatusCode)
+}
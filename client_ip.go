@@ -1,23 +1,84 @@
 package httpserver
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
+	"net/textproto"
 	"strings"
+
+	"github.com/gin-gonic/gin"
 )
 
-var defaultTrustedCIDRs, defaultTrustedCIDRsErr = parseCIDRs([]string{"0.0.0.0/0", "::/0"})
+// ClientIPResolver resolves the caller IP of requests. Client IP headers are only used if the request was sent by a
+// trusted proxy. Their values are read from right to left and the first address which is no trusted proxy is the
+// client IP.
+type ClientIPResolver struct {
+	trustedCIDRs []*net.IPNet
+	headers      []string
+}
+
+type clientIPResolverKey struct{}
+
+// defaultClientIPResolver is used for requests which weren't handled by the ClientIPMiddleware of a server. It trusts
+// all proxies.
+var defaultClientIPResolver, defaultClientIPResolverErr = NewClientIPResolver(ClientIPSettings{
+	TrustedProxies: []string{"0.0.0.0/0", "::/0"},
+	Headers:        []string{HeaderXForwardedFor, HeaderXRealIP},
+})
+
+// NewClientIPResolver creates a resolver trusting the proxies and reading the headers of the settings.
+func NewClientIPResolver(settings ClientIPSettings) (*ClientIPResolver, error) {
+	trustedCIDRs, err := parseCIDRs(settings.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make([]string, 0, len(settings.Headers))
+	for _, header := range settings.Headers {
+		headers = append(headers, textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(header)))
+	}
+
+	return &ClientIPResolver{
+		trustedCIDRs: trustedCIDRs,
+		headers:      headers,
+	}, nil
+}
+
+// ClientIPMiddleware makes ResolveClientIP use the given resolver for the requests of a server.
+func ClientIPMiddleware(resolver *ClientIPResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = WithClientIPResolver(c.Request, resolver)
+		c.Next()
+	}
+}
+
+// WithClientIPResolver returns a copy of the request resolving its client IP with the given resolver.
+func WithClientIPResolver(req *http.Request, resolver *ClientIPResolver) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), clientIPResolverKey{}, resolver))
+}
 
-// ResolveClientIP resolves the caller IP from forwarded headers and falls back to req.RemoteAddr.
+// ResolveClientIP resolves the caller IP from the client IP headers and falls back to req.RemoteAddr. It uses the
+// trusted proxies and headers configured for the server handling the request. Outside a server, all proxies are
+// trusted and only the X-Forwarded-For and X-Real-IP headers are read.
 func ResolveClientIP(req *http.Request) (string, error) {
-	var err error
-	var remoteAddr string
+	if resolver, ok := req.Context().Value(clientIPResolverKey{}).(*ClientIPResolver); ok {
+		return resolver.Resolve(req)
+	}
 
-	if defaultTrustedCIDRsErr != nil {
-		return "", defaultTrustedCIDRsErr
+	if defaultClientIPResolverErr != nil {
+		return "", defaultClientIPResolverErr
 	}
 
+	return defaultClientIPResolver.Resolve(req)
+}
+
+// Resolve resolves the caller IP from the client IP headers and falls back to req.RemoteAddr.
+func (r *ClientIPResolver) Resolve(req *http.Request) (string, error) {
+	var err error
+	var remoteAddr string
+
 	if remoteAddr, err = remoteIP(req); err != nil {
 		return "", err
 	}
@@ -27,8 +88,12 @@ func ResolveClientIP(req *http.Request) (string, error) {
 		return "", fmt.Errorf("invalid remote address IP: %q", remoteAddr)
 	}
 
-	for _, headerName := range []string{HeaderXForwardedFor, HeaderXRealIP} {
-		if ip, valid := validateClientIPHeader(req.Header.Get(headerName), defaultTrustedCIDRs); valid {
+	if !isTrustedProxy(remoteAddrIP, r.trustedCIDRs) {
+		return remoteAddrIP.String(), nil
+	}
+
+	for _, headerName := range r.headers {
+		if ip, valid := validateClientIPs(getClientIPHeaderValues(req.Header, headerName), r.trustedCIDRs); valid {
 			return ip, nil
 		}
 	}
@@ -36,6 +101,19 @@ func ResolveClientIP(req *http.Request) (string, error) {
 	return remoteAddrIP.String(), nil
 }
 
+// ginRemoteIPHeaders returns the headers Gin is able to read, Gin doesn't support the Forwarded header.
+func (r *ClientIPResolver) ginRemoteIPHeaders() []string {
+	headers := make([]string, 0, len(r.headers))
+
+	for _, header := range r.headers {
+		if header != HeaderForwarded {
+			headers = append(headers, header)
+		}
+	}
+
+	return headers
+}
+
 func remoteIP(req *http.Request) (string, error) {
 	var err error
 	var ip string
@@ -47,22 +125,54 @@ func remoteIP(req *http.Request) (string, error) {
 	return ip, nil
 }
 
-func validateClientIPHeader(header string, trustedCIDRs []*net.IPNet) (clientIP string, valid bool) {
-	if header == "" {
-		return "", false
+// getClientIPHeaderValues returns the addresses listed in a client IP header in the order they were added by proxies.
+func getClientIPHeaderValues(header http.Header, headerName string) []string {
+	var values []string
+
+	for _, value := range header.Values(headerName) {
+		for item := range strings.SplitSeq(value, ",") {
+			if headerName == HeaderForwarded {
+				item = parseForwardedFor(item)
+			}
+
+			values = append(values, strings.TrimSpace(item))
+		}
 	}
 
-	items := strings.Split(header, ",")
-	for i := len(items) - 1; i >= 0; i-- {
-		ipStr := strings.TrimSpace(items[i])
-		ip := net.ParseIP(ipStr)
+	return values
+}
+
+// parseForwardedFor returns the node of the for parameter of a forwarded element as defined by RFC 7239. The port
+// and the brackets of IPv6 addresses are removed. Obfuscated and unknown nodes are returned as they are.
+func parseForwardedFor(element string) string {
+	for pair := range strings.SplitSeq(element, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || !strings.EqualFold(strings.TrimSpace(key), "for") {
+			continue
+		}
+
+		node := strings.Trim(strings.TrimSpace(value), `"`)
+
+		if host, _, err := net.SplitHostPort(node); err == nil {
+			return host
+		}
+
+		return strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+	}
+
+	return ""
+}
+
+func validateClientIPs(values []string, trustedCIDRs []*net.IPNet) (clientIP string, valid bool) {
+	for i := len(values) - 1; i >= 0; i-- {
+		ip := net.ParseIP(values[i])
 
 		if ip == nil {
 			break
 		}
 
 		if i == 0 || !isTrustedProxy(ip, trustedCIDRs) {
-			return ipStr, true
+			return values[i], true
 		}
 	}
 
@@ -85,6 +195,16 @@ func parseCIDRs(values []string) ([]*net.IPNet, error) {
 	cidrs := make([]*net.IPNet, 0, len(values))
 
 	for _, value := range values {
+		value = strings.TrimSpace(value)
+
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+
 		if _, cidr, err = net.ParseCIDR(value); err != nil {
 			return nil, fmt.Errorf("parse trusted CIDR %q: %w", value, err)
 		}
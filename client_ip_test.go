@@ -100,3 +100,86 @@ func requestForClientIPTest(t *testing.T) *http.Request {
 
 	return req
 }
+
+func TestClientIPResolver(t *testing.T) {
+	settings := ClientIPSettings{
+		TrustedProxies: []string{"10.0.0.0/8", "2001:db8::1"},
+		Headers:        []string{"forwarded", HeaderXForwardedFor, HeaderXRealIP},
+	}
+
+	testCases := map[string]struct {
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		"untrusted remote addr ignores headers": {
+			remoteAddr: "40.40.40.40:1234",
+			headers:    map[string]string{HeaderXForwardedFor: "20.20.20.20"},
+			want:       "40.40.40.40",
+		},
+		"trusted remote addr uses headers": {
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{HeaderXForwardedFor: "20.20.20.20"},
+			want:       "20.20.20.20",
+		},
+		"trusted proxies are skipped": {
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{HeaderXForwardedFor: "30.30.30.30, 20.20.20.20, 10.0.0.2"},
+			want:       "20.20.20.20",
+		},
+		"single trusted ip": {
+			remoteAddr: "[2001:db8::1]:1234",
+			headers:    map[string]string{HeaderXRealIP: "20.20.20.20"},
+			want:       "20.20.20.20",
+		},
+		"forwarded takes precedence": {
+			remoteAddr: "10.0.0.1:1234",
+			headers: map[string]string{
+				HeaderForwarded:     `for=192.0.2.60;proto=http;by=203.0.113.43, For="[2001:db8:cafe::17]:4711"`,
+				HeaderXForwardedFor: "20.20.20.20",
+			},
+			want: "2001:db8:cafe::17",
+		},
+		"forwarded with port": {
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{HeaderForwarded: `for="192.0.2.60:8080"`},
+			want:       "192.0.2.60",
+		},
+		"obfuscated forwarded falls back to next header": {
+			remoteAddr: "10.0.0.1:1234",
+			headers: map[string]string{
+				HeaderForwarded:     "for=_hidden",
+				HeaderXForwardedFor: "20.20.20.20",
+			},
+			want: "20.20.20.20",
+		},
+		"no headers": {
+			remoteAddr: "10.0.0.1:1234",
+			want:       "10.0.0.1",
+		},
+	}
+
+	resolver, err := NewClientIPResolver(settings)
+	assert.NoError(t, err)
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/", http.NoBody)
+			assert.NoError(t, err)
+
+			req.RemoteAddr = tc.remoteAddr
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+
+			got, err := ResolveClientIP(WithClientIPResolver(req, resolver))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNewClientIPResolverInvalidProxy(t *testing.T) {
+	_, err := NewClientIPResolver(ClientIPSettings{TrustedProxies: []string{"not-an-ip"}})
+	assert.Error(t, err)
+}
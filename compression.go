@@ -4,52 +4,186 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/andybalholm/brotli"
 	ginGzip "github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressionWriterKey stores the compressResponseWriter of a request in the gin context.
+const compressionWriterKey = "goso.response.compressionWriter"
+
+type (
+	// compressionEncoder creates the writers of one content encoding with the configured level.
+	compressionEncoder struct {
+		name string
+		pool sync.Pool
+	}
+
+	encodingWriter interface {
+		io.WriteCloser
+		Flush() error
+		Reset(writer io.Writer)
+	}
+
+	compressionExclusions struct {
+		extensions ginGzip.ExcludedExtensions
+		paths      ginGzip.ExcludedPaths
+		pathRegexs ginGzip.ExcludedPathesRegexs
+	}
+
+	// compressResponseWriter buffers the response until MinSize bytes have been written and compresses it afterward.
+	// Smaller responses are written uncompressed once the handler is done. Event streams and routes which disabled
+	// compression are passed through unchanged, and every write is flushed once the handler flushed the response.
+	compressResponseWriter struct {
+		gin.ResponseWriter
+		encoder           *compressionEncoder
+		minSize           int
+		buffer            []byte
+		writer            encodingWriter
+		decided           bool
+		disabled          bool
+		flushing          bool
+		headerRequested   bool
+		uncompressedBytes int
+	}
 )
 
 func configureCompression(settings CompressionSettings) ([]gin.HandlerFunc, error) {
 	var err error
-	var level int
+	var encoders []*compressionEncoder
 
 	middlewares := make([]gin.HandlerFunc, 0)
 
 	// we always record the request size
 	middlewares = append(middlewares, recordRequestSize)
 
-	if level, err = parseLevel(settings.Level); err != nil {
+	if encoders, err = newCompressionEncoders(settings); err != nil {
 		return nil, err
 	}
 
-	if level == gzip.NoCompression && !settings.Decompression {
-		// there is no use in adding a handler if we should neither compress nor decompress
+	if len(encoders) == 0 {
+		// there is no use in adding a handler if we should not compress
 		return middlewares, nil
 	}
 
-	opts := make([]ginGzip.Option, 0, 4)
+	exclusions := compressionExclusions{
+		extensions: ginGzip.NewExcludedExtensions(settings.Exclude.Extension),
+		paths:      ginGzip.NewExcludedPaths(settings.Exclude.Path),
+		pathRegexs: ginGzip.NewExcludedPathesRegexs(settings.Exclude.PathRegex),
+	}
+
+	middlewares = append(middlewares, func(c *gin.Context) {
+		encoder := negotiateCompressionEncoder(c.Request, encoders, exclusions)
+		if encoder == nil {
+			c.Next()
+
+			return
+		}
+
+		writer := &compressResponseWriter{
+			ResponseWriter: c.Writer,
+			encoder:        encoder,
+			minSize:        settings.MinSize,
+		}
+		c.Writer = writer
+		c.Set(compressionWriterKey, writer)
+
+		defer func() {
+			if writer.finish() {
+				c.Set(responseSizeFields, encodedSizeData{
+					sizeData: sizeData{
+						size: &writer.uncompressedBytes,
+					},
+					contentEncoding: encoder.name,
+				})
+			}
+		}()
+
+		c.Next()
+	})
+
+	return middlewares, nil
+}
+
+// newCompressionEncoders returns the encoders which aren't disabled in the order of preference of the server.
+func newCompressionEncoders(settings CompressionSettings) ([]*compressionEncoder, error) {
+	var err error
+	var gzipLevel, brotliLevel int
+	var zstdLevel zstd.EncoderLevel
+
+	encoders := make([]*compressionEncoder, 0, 3)
+
+	if brotliLevel, err = parseBrotliLevel(settings.BrotliLevel); err != nil {
+		return nil, err
+	}
+
+	if brotliLevel >= 0 {
+		encoders = append(encoders, newCompressionEncoder(HeaderValueBrotli, func() encodingWriter {
+			return brotli.NewWriterLevel(io.Discard, brotliLevel)
+		}))
+	}
+
+	if zstdLevel, err = parseZstdLevel(settings.ZstdLevel); err != nil {
+		return nil, err
+	}
+
+	if zstdLevel > 0 {
+		encoders = append(encoders, newCompressionEncoder(HeaderValueZstd, func() encodingWriter {
+			writer, err := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(zstdLevel), zstd.WithEncoderConcurrency(1), zstd.WithLowerEncoderMem(true))
+			if err != nil {
+				panic(err)
+			}
 
-	if settings.Decompression {
-		opts = append(opts, ginGzip.WithDecompressFn(decompressionFn))
+			return writer
+		}))
 	}
 
-	if len(settings.Exclude.Extension) > 0 {
-		opts = append(opts, ginGzip.WithExcludedExtensions(settings.Exclude.Extension))
+	if gzipLevel, err = parseLevel(settings.Level); err != nil {
+		return nil, err
 	}
 
-	if len(settings.Exclude.Path) > 0 {
-		opts = append(opts, ginGzip.WithExcludedPaths(settings.Exclude.Path))
+	if gzipLevel != gzip.NoCompression {
+		encoders = append(encoders, newCompressionEncoder(HeaderValueGzip, func() encodingWriter {
+			writer, err := gzip.NewWriterLevel(io.Discard, gzipLevel)
+			if err != nil {
+				panic(err)
+			}
+
+			return writer
+		}))
 	}
 
-	if len(settings.Exclude.PathRegex) > 0 {
-		opts = append(opts, ginGzip.WithExcludedPathsRegexs(settings.Exclude.PathRegex))
+	return encoders, nil
+}
+
+func newCompressionEncoder(name string, newWriter func() encodingWriter) *compressionEncoder {
+	return &compressionEncoder{
+		name: name,
+		pool: sync.Pool{
+			New: func() any {
+				return newWriter()
+			},
+		},
 	}
+}
 
-	middlewares = append(middlewares, ginGzip.Gzip(level, opts...))
-	middlewares = append(middlewares, recordResponseSize)
+func (e *compressionEncoder) get(writer io.Writer) encodingWriter {
+	encoder := e.pool.Get().(encodingWriter)
+	encoder.Reset(writer)
 
-	return middlewares, nil
+	return encoder
+}
+
+func (e *compressionEncoder) put(encoder encodingWriter) {
+	encoder.Reset(io.Discard)
+	e.pool.Put(encoder)
 }
 
 func parseLevel(level string) (int, error) {
@@ -73,53 +207,312 @@ func parseLevel(level string) (int, error) {
 	}
 }
 
-func decompressionFn(c *gin.Context) {
-	var err error
-	var gzipReader io.ReadCloser
-	var readUncompressedBytes *int
+// parseBrotliLevel returns the brotli level or -1 if brotli is disabled.
+func parseBrotliLevel(level string) (int, error) {
+	switch level {
+	case "none":
+		return -1, nil
+	case "", "default":
+		return brotli.DefaultCompression, nil
+	case "best":
+		return brotli.BestCompression, nil
+	case "fast":
+		return brotli.BestSpeed, nil
+	default:
+		if parsedLevel, err := strconv.ParseInt(level, 10, 64); err != nil {
+			return 0, fmt.Errorf("failed to parse brotli level %s: %w", level, err)
+		} else if parsedLevel < brotli.BestSpeed || parsedLevel > brotli.BestCompression {
+			return 0, fmt.Errorf("invalid brotli compression level %d", parsedLevel)
+		} else {
+			return int(parsedLevel), nil
+		}
+	}
+}
+
+// parseZstdLevel returns the zstd level or 0 if zstd is disabled.
+func parseZstdLevel(level string) (zstd.EncoderLevel, error) {
+	switch level {
+	case "none":
+		return 0, nil
+	case "", "default":
+		return zstd.SpeedDefault, nil
+	case "best":
+		return zstd.SpeedBestCompression, nil
+	case "better":
+		return zstd.SpeedBetterCompression, nil
+	case "fast":
+		return zstd.SpeedFastest, nil
+	default:
+		return 0, fmt.Errorf("invalid zstd compression level %s", level)
+	}
+}
+
+// negotiateCompressionEncoder picks the encoder with the highest q-value in the Accept-Encoding header. Ties are
+// broken by the order of the encoders. Nil is returned if the client accepts none of them or the request is excluded.
+func negotiateCompressionEncoder(req *http.Request, encoders []*compressionEncoder, exclusions compressionExclusions) *compressionEncoder {
+	acceptEncoding := req.Header.Get(HeaderAcceptEncoding)
+
+	if len(encoders) == 0 || acceptEncoding == "" || strings.Contains(req.Header.Get(HeaderConnection), "Upgrade") {
+		return nil
+	}
+
+	if exclusions.extensions.Contains(filepath.Ext(req.URL.Path)) ||
+		exclusions.paths.Contains(req.URL.Path) ||
+		exclusions.pathRegexs.Contains(req.URL.Path) {
+		return nil
+	}
+
+	accepted := parseAccept(acceptEncoding)
+
+	var best *compressionEncoder
+	bestQuality := 0.0
+
+	for _, encoder := range encoders {
+		if quality, specificity := acceptQuality(accepted, encoder.name); specificity >= 0 && quality > bestQuality {
+			best, bestQuality = encoder, quality
+		}
+	}
+
+	return best
+}
+
+// DisableCompression disables the compression of the response of the current request. It can be used as a
+// middleware of routes streaming their response. Routes of the BindSse family of functions don't need it, as they
+// are never compressed. It has no effect if the response has already been sent.
+func DisableCompression(ginCtx *gin.Context) {
+	if writer, ok := ginCtx.Value(compressionWriterKey).(*compressResponseWriter); ok {
+		writer.disabled = true
+	}
+}
+
+// streamingRouteMiddleware disables compression for routes documented to produce an event stream.
+func streamingRouteMiddleware(definition Definition) gin.HandlerFunc {
+	if definition.Spec == nil || !definition.Spec.producesContentType(ContentTypeEventStream) {
+		return nil
+	}
+
+	return DisableCompression
+}
+
+func (w *compressResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressResponseWriter) Write(data []byte) (int, error) {
+	w.uncompressedBytes += len(data)
+
+	if w.decided {
+		return w.writeDecided(data)
+	}
+
+	if len(w.buffer)+len(data) < w.minSize && !w.isStream() {
+		w.buffer = append(w.buffer, data...)
+
+		return len(data), nil
+	}
+
+	pending := append(w.buffer, data...)
+	w.buffer = nil
+	w.decide(true)
+
+	if _, err := w.writePending(pending); err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+// WriteHeaderNow defers writing the headers until it is known whether the response is compressed.
+func (w *compressResponseWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
 
-	if gzipReader, readUncompressedBytes, err = NewGZipBodyReader(c.Request.Body); err != nil {
-		// the body is not a proper gzip encoded body, so don't do anything
-		// the client most likely set the wrong content encoding on the message
 		return
 	}
 
-	c.Request.Body = gzipReader
+	w.headerRequested = true
+}
 
-	c.Set(requestCompressedSizeFields, encodedSizeData{
-		sizeData: sizeData{
-			size: readUncompressedBytes,
-		},
-		contentEncoding: HeaderValueGzip,
-	})
+// Unwrap allows http.ResponseController to reach the connection of the response.
+func (w *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
 
-func recordRequestSize(c *gin.Context) {
-	body, readBytes := NewCountingBodyReader(c.Request.Body)
-	c.Request.Body = body
+func (w *compressResponseWriter) Written() bool {
+	return w.headerRequested || len(w.buffer) > 0 || w.ResponseWriter.Written()
+}
 
-	c.Set(requestSizeFields, sizeData{
-		size: readBytes,
-	})
+// Flush compresses streamed responses regardless of their size, as the size isn't known yet. Every following
+// write is flushed, too.
+func (w *compressResponseWriter) Flush() {
+	w.flushing = true
 
-	c.Next()
+	if !w.decided {
+		pending := w.buffer
+		w.buffer = nil
+		w.decide(true)
+
+		if _, err := w.writePending(pending); err != nil {
+			return
+		}
+	}
+
+	if w.writer != nil {
+		if err := w.writer.Flush(); err != nil {
+			return
+		}
+	}
+
+	w.ResponseWriter.Flush()
 }
 
-func recordResponseSize(c *gin.Context) {
-	if c.Writer.Header().Get(HeaderContentEncoding) != HeaderValueGzip {
-		c.Next()
+// decide sets the headers of the response. The response can't be compressed if the headers have already been
+// written, the handler encoded the body itself, the response is a stream, or the status doesn't allow a body.
+func (w *compressResponseWriter) decide(compress bool) {
+	w.decided = true
 
+	header := w.Header()
+
+	if w.ResponseWriter.Written() || header.Get(HeaderContentEncoding) != "" || w.isStream() {
 		return
 	}
 
-	writer, writtenBytes := NewCountingBodyWriter(c.Writer)
-	c.Writer = writer
+	header.Add(HeaderVary, HeaderAcceptEncoding)
 
-	c.Set(responseSizeFields, encodedSizeData{
-		sizeData: sizeData{
-			size: writtenBytes,
-		},
-		contentEncoding: HeaderValueGzip,
+	if status := w.Status(); !compress || status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return
+	}
+
+	header.Set(HeaderContentEncoding, w.encoder.name)
+	header.Del(HeaderContentLength)
+
+	w.writer = w.encoder.get(w.ResponseWriter)
+}
+
+// isStream reports whether the response has to be sent as written, as for event streams or routes which disabled
+// compression.
+func (w *compressResponseWriter) isStream() bool {
+	if w.disabled {
+		return true
+	}
+
+	contentType := strings.ToLower(w.Header().Get(HeaderContentType))
+
+	return strings.HasPrefix(contentType, ContentTypeEventStream)
+}
+
+func (w *compressResponseWriter) writeDecided(data []byte) (int, error) {
+	if w.writer == nil {
+		return w.ResponseWriter.Write(data)
+	}
+
+	written, err := w.writer.Write(data)
+	if err != nil || !w.flushing {
+		return written, err
+	}
+
+	if err = w.writer.Flush(); err != nil {
+		return written, err
+	}
+
+	w.ResponseWriter.Flush()
+
+	return written, nil
+}
+
+func (w *compressResponseWriter) writePending(pending []byte) (int, error) {
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	return w.writeDecided(pending)
+}
+
+// finish writes buffered responses uncompressed and completes compressed responses. It reports whether the response
+// was compressed.
+func (w *compressResponseWriter) finish() bool {
+	if !w.decided {
+		pending := w.buffer
+		w.buffer = nil
+		w.decide(false)
+
+		_, _ = w.writePending(pending)
+
+		if w.headerRequested {
+			w.ResponseWriter.WriteHeaderNow()
+		}
+	}
+
+	if w.writer == nil {
+		return false
+	}
+
+	_ = w.writer.Close()
+	w.encoder.put(w.writer)
+	w.writer = nil
+
+	return true
+}
+
+// DecompressionMiddleware decodes gzip, brotli and zstd encoded request bodies. Bodies with another content encoding
+// are rejected with 415 and bodies which can't be decoded with 400. Reading a body exceeding the maximum decoded
+// size or compression ratio fails with an error carrying the status code 413.
+func DecompressionMiddleware(settings CompressionSettings, metricRecorder ServerMetricRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		var reader io.ReadCloser
+		var readUncompressedBytes *int
+
+		contentEncoding := strings.ToLower(strings.TrimSpace(c.GetHeader(HeaderContentEncoding)))
+
+		if contentEncoding == "" || contentEncoding == HeaderValueIdentity {
+			c.Next()
+
+			return
+		}
+
+		body, compressedBytes := NewCountingBodyReader(c.Request.Body)
+
+		switch contentEncoding {
+		case HeaderValueGzip:
+			reader, readUncompressedBytes, err = NewGZipBodyReader(body)
+		case HeaderValueBrotli:
+			reader, readUncompressedBytes, err = NewBrotliBodyReader(body)
+		case HeaderValueZstd:
+			reader, readUncompressedBytes, err = NewZstdBodyReader(body)
+		default:
+			AbortWithError(c, NewErrorWithStatus(http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content encoding %s", contentEncoding)))
+
+			return
+		}
+
+		if err != nil {
+			AbortWithError(c, NewErrorWithStatus(http.StatusBadRequest, fmt.Errorf("invalid %s encoded body: %w", contentEncoding, err)))
+
+			return
+		}
+
+		c.Request.Body = newDecompressionLimitReader(reader, compressedBytes, settings.MaxDecompressedBytes, settings.MaxRatio, func() {
+			metricRecorder.TrackDecompressionLimitExceeded(c.Request.Context())
+		})
+
+		c.Set(requestCompressedSizeFields, encodedSizeData{
+			sizeData: sizeData{
+				size: readUncompressedBytes,
+			},
+			contentEncoding: contentEncoding,
+		})
+
+		c.Next()
+	}
+}
+
+func recordRequestSize(c *gin.Context) {
+	body, readBytes := NewCountingBodyReader(c.Request.Body)
+	c.Request.Body = body
+
+	c.Set(requestSizeFields, sizeData{
+		size: readBytes,
 	})
 
 	c.Next()
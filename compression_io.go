@@ -2,12 +2,25 @@ package httpserver
 
 import (
 	"compress/gzip"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
 
+	"github.com/andybalholm/brotli"
 	"github.com/gin-gonic/gin"
 	"github.com/hashicorp/go-multierror"
+	"github.com/klauspost/compress/zstd"
 )
 
+// decompressionRatioGraceBytes is the number of decoded bytes after which the compression ratio of a request body
+// is checked. Small bodies can have a high ratio without being a threat.
+const decompressionRatioGraceBytes = 1 << 20
+
+// ErrDecompressedBodyTooLarge is returned while reading an encoded request body exceeding the maximum decoded size or
+// compression ratio. It carries the status code 413.
+var ErrDecompressedBodyTooLarge = errors.New("decompressed request body too large")
+
 type countingBodyWriter struct {
 	gin.ResponseWriter
 	writtenBytes int
@@ -97,3 +110,111 @@ func (r *gzipBodyReader) Close() error {
 
 	return result.ErrorOrNil()
 }
+
+type decodingBodyReader struct {
+	body      io.Closer
+	reader    io.Reader
+	release   func()
+	readBytes int
+}
+
+// NewBrotliBodyReader wraps a brotli-compressed request body and counts uncompressed bytes.
+func NewBrotliBodyReader(body io.ReadCloser) (io.ReadCloser, *int, error) {
+	result := &decodingBodyReader{
+		body:   body,
+		reader: brotli.NewReader(body),
+	}
+
+	return result, &result.readBytes, nil
+}
+
+// NewZstdBodyReader wraps a zstd-compressed request body and counts uncompressed bytes.
+func NewZstdBodyReader(body io.ReadCloser) (io.ReadCloser, *int, error) {
+	decoder, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &decodingBodyReader{
+		body:    body,
+		reader:  decoder,
+		release: decoder.Close,
+	}
+
+	return result, &result.readBytes, nil
+}
+
+func (r *decodingBodyReader) Read(p []byte) (int, error) {
+	readBytes, err := r.reader.Read(p)
+	r.readBytes += readBytes
+
+	return readBytes, err
+}
+
+func (r *decodingBodyReader) Close() error {
+	if r.release != nil {
+		r.release()
+	}
+
+	return r.body.Close()
+}
+
+// decompressionLimitReader stops reading a decoded request body once it exceeds the maximum size or compression
+// ratio. The limits are disabled if they are 0.
+type decompressionLimitReader struct {
+	io.ReadCloser
+	compressedBytes   *int
+	decompressedBytes int64
+	maxBytes          int64
+	maxRatio          int
+	exceeded          func()
+	err               error
+}
+
+func newDecompressionLimitReader(body io.ReadCloser, compressedBytes *int, maxBytes int64, maxRatio int, exceeded func()) io.ReadCloser {
+	return &decompressionLimitReader{
+		ReadCloser:      body,
+		compressedBytes: compressedBytes,
+		maxBytes:        maxBytes,
+		maxRatio:        maxRatio,
+		exceeded:        exceeded,
+	}
+}
+
+func (r *decompressionLimitReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	// read at most one byte more than allowed to detect bodies exceeding the limit without decoding them further
+	if remaining := r.maxBytes - r.decompressedBytes + 1; r.maxBytes > 0 && int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	readBytes, err := r.ReadCloser.Read(p)
+	r.decompressedBytes += int64(readBytes)
+
+	if r.err = r.check(); r.err != nil {
+		r.exceeded()
+
+		return 0, r.err
+	}
+
+	return readBytes, err
+}
+
+func (r *decompressionLimitReader) check() error {
+	if r.maxBytes > 0 && r.decompressedBytes > r.maxBytes {
+		return NewErrorWithStatus(http.StatusRequestEntityTooLarge, fmt.Errorf("%w: more than %d bytes", ErrDecompressedBodyTooLarge, r.maxBytes))
+	}
+
+	if r.maxRatio <= 0 || r.decompressedBytes <= decompressionRatioGraceBytes {
+		return nil
+	}
+
+	if r.decompressedBytes > int64(r.maxRatio)*int64(max(*r.compressedBytes, 1)) {
+		return NewErrorWithStatus(http.StatusRequestEntityTooLarge, fmt.Errorf("%w: compression ratio above %d", ErrDecompressedBodyTooLarge, r.maxRatio))
+	}
+
+	return nil
+}
@@ -0,0 +1,525 @@
+package httpserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	"github.com/justtrackio/gosoline/pkg/log"
+	logMocks "github.com/justtrackio/gosoline/pkg/log/mocks"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var compressionTestBody = strings.Repeat("this is a long string. ", 100)
+
+type compressionTestMetricRecorder struct {
+	ServerMetricRecorder
+	limitsExceeded atomic.Int64
+}
+
+func (r *compressionTestMetricRecorder) TrackDecompressionLimitExceeded(_ context.Context) {
+	r.limitsExceeded.Add(1)
+}
+
+func newCompressionTestRouter(t *testing.T, settings CompressionSettings, handler gin.HandlerFunc, middlewares ...gin.HandlerFunc) *gin.Engine {
+	router, _ := newDecompressionTestRouter(t, settings, handler, middlewares...)
+
+	return router
+}
+
+func newDecompressionTestRouter(t *testing.T, settings CompressionSettings, handler gin.HandlerFunc, middlewares ...gin.HandlerFunc) (*gin.Engine, *compressionTestMetricRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	compression, err := configureCompression(settings)
+	require.NoError(t, err)
+
+	recorder := &compressionTestMetricRecorder{}
+
+	router := gin.New()
+	router.Use(middlewares...)
+	router.Use(compression...)
+	router.Use(ErrorMiddleware())
+	router.Use(DecompressionMiddleware(settings, recorder))
+	router.POST("/", handler)
+
+	return router, recorder
+}
+
+func newCompressionTestSettings() CompressionSettings {
+	return CompressionSettings{
+		Level:         "default",
+		BrotliLevel:   "default",
+		ZstdLevel:     "default",
+		Decompression: true,
+		MaxRatio:      100,
+	}
+}
+
+func decodeCompressionTestBody(t *testing.T, encoding string, body []byte) string {
+	var err error
+	var reader io.Reader
+
+	switch encoding {
+	case HeaderValueGzip:
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case HeaderValueBrotli:
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case HeaderValueZstd:
+		reader, err = zstd.NewReader(bytes.NewReader(body))
+	default:
+		reader = bytes.NewReader(body)
+	}
+	require.NoError(t, err)
+
+	decoded, err := io.ReadAll(reader)
+	require.NoError(t, err)
+
+	return string(decoded)
+}
+
+func encodeCompressionTestBody(t *testing.T, encoding string, body string) []byte {
+	var err error
+	var writer io.WriteCloser
+
+	buffer := &bytes.Buffer{}
+
+	switch encoding {
+	case HeaderValueGzip:
+		writer = gzip.NewWriter(buffer)
+	case HeaderValueBrotli:
+		writer = brotli.NewWriter(buffer)
+	case HeaderValueZstd:
+		writer, err = zstd.NewWriter(buffer)
+		require.NoError(t, err)
+	}
+
+	_, err = writer.Write([]byte(body))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buffer.Bytes()
+}
+
+func TestCompressionNegotiation(t *testing.T) {
+	testCases := map[string]struct {
+		acceptEncoding string
+		settings       func(settings *CompressionSettings)
+		expected       string
+	}{
+		"gzip only": {
+			acceptEncoding: "gzip",
+			expected:       HeaderValueGzip,
+		},
+		"server preference on ties": {
+			acceptEncoding: "gzip, deflate, br, zstd",
+			expected:       HeaderValueBrotli,
+		},
+		"q-values": {
+			acceptEncoding: "br;q=0.5, zstd;q=0.8, gzip;q=0.2",
+			expected:       HeaderValueZstd,
+		},
+		"rejected encoding": {
+			acceptEncoding: "br;q=0, gzip",
+			expected:       HeaderValueGzip,
+		},
+		"wildcard": {
+			acceptEncoding: "*;q=0.5, br;q=0",
+			expected:       HeaderValueZstd,
+		},
+		"identity": {
+			acceptEncoding: "identity",
+			expected:       "",
+		},
+		"disabled algorithm": {
+			acceptEncoding: "br, gzip;q=0.5",
+			settings: func(settings *CompressionSettings) {
+				settings.BrotliLevel = "none"
+			},
+			expected: HeaderValueGzip,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			settings := newCompressionTestSettings()
+			if tc.settings != nil {
+				tc.settings(&settings)
+			}
+
+			router := newCompressionTestRouter(t, settings, func(c *gin.Context) {
+				c.String(http.StatusOK, compressionTestBody)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+			req.Header.Set(HeaderAcceptEncoding, tc.acceptEncoding)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tc.expected, rec.Header().Get(HeaderContentEncoding))
+			assert.Equal(t, compressionTestBody, decodeCompressionTestBody(t, tc.expected, rec.Body.Bytes()))
+
+			if tc.expected != "" {
+				assert.Equal(t, HeaderAcceptEncoding, rec.Header().Get(HeaderVary))
+				assert.Less(t, rec.Body.Len(), len(compressionTestBody))
+			}
+		})
+	}
+}
+
+func TestCompressionMinSize(t *testing.T) {
+	settings := newCompressionTestSettings()
+	settings.MinSize = 100
+
+	router := newCompressionTestRouter(t, settings, func(c *gin.Context) {
+		body := c.Query("body")
+
+		// write in small chunks to cover buffering across writes
+		for i := 0; i < len(body); i += 10 {
+			_, err := c.Writer.WriteString(body[i:min(i+10, len(body))])
+			require.NoError(t, err)
+		}
+	})
+
+	for _, body := range []string{"short", compressionTestBody} {
+		req := httptest.NewRequest(http.MethodPost, "/?body="+strings.ReplaceAll(body, " ", "+"), http.NoBody)
+		req.Header.Set(HeaderAcceptEncoding, HeaderValueGzip)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		expectedEncoding := ""
+		if len(body) >= settings.MinSize {
+			expectedEncoding = HeaderValueGzip
+		}
+
+		assert.Equal(t, expectedEncoding, rec.Header().Get(HeaderContentEncoding))
+		assert.Equal(t, HeaderAcceptEncoding, rec.Header().Get(HeaderVary))
+		assert.Equal(t, body, decodeCompressionTestBody(t, expectedEncoding, rec.Body.Bytes()))
+	}
+}
+
+func TestCompressionBoundResponses(t *testing.T) {
+	router := newCompressionTestRouter(t, newCompressionTestSettings(), func(c *gin.Context) {
+		require.NoError(t, BindHandleResponse(NewJsonResponse(map[string]string{"content": compressionTestBody}), c))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+	req.Header.Set(HeaderAcceptEncoding, HeaderValueBrotli)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, HeaderValueBrotli, rec.Header().Get(HeaderContentEncoding))
+	assert.JSONEq(t, `{"content":"`+compressionTestBody+`"}`, decodeCompressionTestBody(t, HeaderValueBrotli, rec.Body.Bytes()))
+}
+
+func TestCompressionSkipsEventStreams(t *testing.T) {
+	router := newCompressionTestRouter(t, newCompressionTestSettings(), func(c *gin.Context) {
+		c.Header(HeaderContentType, ContentTypeEventStream)
+		c.String(http.StatusOK, compressionTestBody)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+	req.Header.Set(HeaderAcceptEncoding, HeaderValueGzip)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Empty(t, rec.Header().Get(HeaderContentEncoding))
+	assert.Empty(t, rec.Header().Get(HeaderVary))
+	assert.Equal(t, compressionTestBody, rec.Body.String())
+}
+
+func TestCompressionSkipsSeekableStreams(t *testing.T) {
+	for name, tc := range map[string]struct {
+		reader           func() io.Reader
+		rangeHeader      string
+		expectedEncoding string
+	}{
+		"seekable":           {reader: func() io.Reader { return strings.NewReader(compressionTestBody) }},
+		"seekable range":     {reader: func() io.Reader { return strings.NewReader(compressionTestBody) }, rangeHeader: "bytes=0-9"},
+		"not seekable":       {reader: func() io.Reader { return io.MultiReader(strings.NewReader(compressionTestBody)) }, expectedEncoding: HeaderValueGzip},
+		"not seekable range": {reader: func() io.Reader { return io.MultiReader(strings.NewReader(compressionTestBody)) }, rangeHeader: "bytes=0-9", expectedEncoding: HeaderValueGzip},
+	} {
+		router := newCompressionTestRouter(t, newCompressionTestSettings(), func(c *gin.Context) {
+			_ = BindHandleResponse(NewStreamResponse(tc.reader(), WithHeader(HeaderContentType, ContentTypeTextPlain)), c)
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+		req.Header.Set(HeaderAcceptEncoding, HeaderValueGzip)
+		req.Header.Set(HeaderRange, tc.rangeHeader)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, tc.expectedEncoding, rec.Header().Get(HeaderContentEncoding), name)
+	}
+}
+
+func TestCompressionStreamingRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	compression, err := configureCompression(newCompressionTestSettings())
+	require.NoError(t, err)
+
+	engine := gin.New()
+	engine.Use(compression...)
+
+	definitions := &Router{}
+	definitions.GET("/sse", BindSseN(func(ctx context.Context, writer *SseWriter) error {
+		return writer.Send(compressionTestBody)
+	}))
+	// the route is skipped because of its spec, not because of the content type of the response
+	definitions.GET("/described", Describe(func(c *gin.Context) {
+		c.String(http.StatusOK, compressionTestBody)
+	}, func(spec *RouteSpec) {
+		spec.setResponse(sseRouteResponse())
+	}))
+	definitions.GET("/stream", DisableCompression, func(c *gin.Context) {
+		c.String(http.StatusOK, compressionTestBody)
+	})
+	definitions.GET("/compressed", func(c *gin.Context) {
+		c.String(http.StatusOK, compressionTestBody)
+	})
+
+	logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+	_, err = buildRouter(t.Context(), cfg.New(), logger, &Settings{}, definitions, engine, streamingRouteMiddleware)
+	require.NoError(t, err)
+
+	for path, expectedEncoding := range map[string]string{"/sse": "", "/described": "", "/stream": "", "/compressed": HeaderValueGzip} {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		req.Header.Set(HeaderAcceptEncoding, HeaderValueGzip)
+
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+
+		assert.Equal(t, expectedEncoding, rec.Header().Get(HeaderContentEncoding), path)
+		assert.Contains(t, decodeCompressionTestBody(t, expectedEncoding, rec.Body.Bytes()), compressionTestBody, path)
+	}
+}
+
+func TestCompressionFlushesEveryWriteAfterFlush(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	router := newCompressionTestRouter(t, newCompressionTestSettings(), func(c *gin.Context) {
+		_, err := c.Writer.WriteString("first ")
+		require.NoError(t, err)
+
+		c.Writer.Flush()
+
+		_, err = c.Writer.WriteString("second")
+		require.NoError(t, err)
+
+		// the second write has to be readable by the client without another flush
+		reader, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+		require.NoError(t, err)
+
+		received := make([]byte, len("first second"))
+		_, err = io.ReadFull(reader, received)
+		require.NoError(t, err)
+		assert.Equal(t, "first second", string(received))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+	req.Header.Set(HeaderAcceptEncoding, HeaderValueGzip)
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, HeaderValueGzip, rec.Header().Get(HeaderContentEncoding))
+	assert.Equal(t, "first second", decodeCompressionTestBody(t, HeaderValueGzip, rec.Body.Bytes()))
+}
+
+func TestCompressionKeepsEncodedResponses(t *testing.T) {
+	encoded := encodeCompressionTestBody(t, HeaderValueZstd, compressionTestBody)
+
+	router := newCompressionTestRouter(t, newCompressionTestSettings(), func(c *gin.Context) {
+		c.Header(HeaderContentEncoding, HeaderValueZstd)
+		c.Data(http.StatusOK, ContentTypeApplicationJson, encoded)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+	req.Header.Set(HeaderAcceptEncoding, "br, zstd")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, HeaderValueZstd, rec.Header().Get(HeaderContentEncoding))
+	assert.Equal(t, encoded, rec.Body.Bytes())
+}
+
+func TestCompressionSizeFields(t *testing.T) {
+	for _, encoding := range []string{HeaderValueGzip, HeaderValueBrotli, HeaderValueZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			var fields log.Fields
+
+			router := newCompressionTestRouter(t, newCompressionTestSettings(), func(c *gin.Context) {
+				body, err := io.ReadAll(c.Request.Body)
+				require.NoError(t, err)
+
+				c.String(http.StatusOK, string(body))
+			}, func(c *gin.Context) {
+				c.Next()
+				fields = getRequestSizeFields(c)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(encodeCompressionTestBody(t, encoding, compressionTestBody)))
+			req.Header.Set(HeaderContentEncoding, encoding)
+			req.Header.Set(HeaderAcceptEncoding, encoding)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, encoding, rec.Header().Get(HeaderContentEncoding))
+			assert.Equal(t, compressionTestBody, decodeCompressionTestBody(t, encoding, rec.Body.Bytes()))
+
+			assert.Equal(t, encoding, fields["request_compression"])
+			assert.Equal(t, len(compressionTestBody), fields["request_uncompressed_bytes"])
+			assert.Equal(t, encoding, fields["response_compression"])
+			assert.Equal(t, len(compressionTestBody), fields["uncompressed_bytes"])
+		})
+	}
+}
+
+func TestCompressionInvalidLevel(t *testing.T) {
+	settings := newCompressionTestSettings()
+	settings.ZstdLevel = "9"
+
+	_, err := configureCompression(settings)
+	assert.EqualError(t, err, "invalid zstd compression level 9")
+}
+
+func TestDecompressionLimits(t *testing.T) {
+	type input struct {
+		Content string `json:"content"`
+	}
+
+	largeBody := `{"content":"` + strings.Repeat("a", 2*decompressionRatioGraceBytes) + `"}`
+
+	testCases := map[string]struct {
+		body           string
+		maxBytes       int64
+		maxRatio       int
+		expectedStatus int
+		expectedErr    string
+	}{
+		"within limits": {
+			body:           `{"content":"` + compressionTestBody + `"}`,
+			maxBytes:       10000,
+			maxRatio:       100,
+			expectedStatus: http.StatusOK,
+		},
+		"too large": {
+			body:           `{"content":"` + compressionTestBody + `"}`,
+			maxBytes:       1000,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedErr:    "json: decompressed request body too large: more than 1000 bytes",
+		},
+		"ratio too high": {
+			body:           largeBody,
+			maxRatio:       100,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedErr:    "json: decompressed request body too large: compression ratio above 100",
+		},
+		"ratio disabled": {
+			body:           largeBody,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			settings := newCompressionTestSettings()
+			settings.MaxDecompressedBytes = tc.maxBytes
+			settings.MaxRatio = tc.maxRatio
+
+			router, recorder := newDecompressionTestRouter(t, settings, Bind(func(ctx context.Context, input *input) (Response, error) {
+				return NewStatusResponse(http.StatusOK), nil
+			}, binding.JSON))
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(encodeCompressionTestBody(t, HeaderValueGzip, tc.body)))
+			req.Header.Set(HeaderContentType, ContentTypeApplicationJson)
+			req.Header.Set(HeaderContentEncoding, HeaderValueGzip)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			if tc.expectedErr == "" {
+				assert.Equal(t, int64(0), recorder.limitsExceeded.Load())
+
+				return
+			}
+
+			assert.JSONEq(t, `{"err":"`+tc.expectedErr+`"}`, rec.Body.String())
+			assert.Equal(t, int64(1), recorder.limitsExceeded.Load())
+		})
+	}
+}
+
+func TestDecompressionRejectsInvalidBodies(t *testing.T) {
+	testCases := map[string]struct {
+		contentEncoding string
+		body            []byte
+		expectedStatus  int
+		expectedErr     string
+	}{
+		"identity": {
+			contentEncoding: HeaderValueIdentity,
+			body:            []byte(compressionTestBody),
+			expectedStatus:  http.StatusOK,
+		},
+		"unsupported encoding": {
+			contentEncoding: "deflate",
+			body:            []byte(compressionTestBody),
+			expectedStatus:  http.StatusUnsupportedMediaType,
+			expectedErr:     "unsupported content encoding deflate",
+		},
+		"invalid body": {
+			contentEncoding: HeaderValueGzip,
+			body:            []byte(compressionTestBody),
+			expectedStatus:  http.StatusBadRequest,
+			expectedErr:     "invalid gzip encoded body: gzip: invalid header",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			router := newCompressionTestRouter(t, newCompressionTestSettings(), func(c *gin.Context) {
+				body, err := io.ReadAll(c.Request.Body)
+				require.NoError(t, err)
+
+				c.String(http.StatusOK, string(body))
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tc.body))
+			req.Header.Set(HeaderContentEncoding, tc.contentEncoding)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			if tc.expectedErr == "" {
+				assert.Equal(t, compressionTestBody, rec.Body.String())
+			} else {
+				assert.JSONEq(t, `{"err":"`+tc.expectedErr+`"}`, rec.Body.String())
+			}
+		})
+	}
+}
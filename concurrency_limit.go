@@ -0,0 +1,148 @@
+package httpserver
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// ConcurrencyAlgorithmAimd increases the limit by one while the latency is below a threshold and decreases it
+	// multiplicatively otherwise.
+	ConcurrencyAlgorithmAimd = "aimd"
+	// ConcurrencyAlgorithmGradient adjusts the limit to the ratio of the long-term and the current latency.
+	ConcurrencyAlgorithmGradient = "gradient"
+
+	// gradientLongRttWindow is the number of samples the long-term latency of the gradient algorithm averages.
+	gradientLongRttWindow = 600
+)
+
+// concurrencyLimitAlgorithm computes the concurrency limit from the latency of completed requests. Implementations
+// don't need to be safe for concurrent use.
+type concurrencyLimitAlgorithm interface {
+	// Limit returns the current limit.
+	Limit() int
+	// Update adjusts the limit to a completed request. inFlight is the number of requests handled when it started,
+	// dropped reports whether it exceeded its deadline.
+	Update(rtt time.Duration, inFlight int, dropped bool) int
+}
+
+func newConcurrencyLimitAlgorithm(settings ConcurrencySettings) concurrencyLimitAlgorithm {
+	switch settings.Adaptive.Algorithm {
+	case ConcurrencyAlgorithmAimd:
+		return newAimdConcurrencyLimit(settings.Adaptive)
+	case ConcurrencyAlgorithmGradient:
+		return newGradientConcurrencyLimit(settings.Adaptive)
+	default:
+		return fixedConcurrencyLimit(settings.MaxRequests)
+	}
+}
+
+type fixedConcurrencyLimit int
+
+func (l fixedConcurrencyLimit) Limit() int {
+	return int(l)
+}
+
+func (l fixedConcurrencyLimit) Update(time.Duration, int, bool) int {
+	return int(l)
+}
+
+type aimdConcurrencyLimit struct {
+	limit            int
+	minLimit         int
+	maxLimit         int
+	latencyThreshold time.Duration
+	backoffPercent   int
+}
+
+func newAimdConcurrencyLimit(settings AdaptiveConcurrencySettings) *aimdConcurrencyLimit {
+	return &aimdConcurrencyLimit{
+		limit:            clampConcurrencyLimit(settings.InitialLimit, settings),
+		minLimit:         settings.MinLimit,
+		maxLimit:         max(settings.MinLimit, settings.MaxLimit),
+		latencyThreshold: settings.LatencyThreshold,
+		backoffPercent:   settings.BackoffPercent,
+	}
+}
+
+func (l *aimdConcurrencyLimit) Limit() int {
+	return l.limit
+}
+
+func (l *aimdConcurrencyLimit) Update(rtt time.Duration, inFlight int, dropped bool) int {
+	switch {
+	case dropped || rtt > l.latencyThreshold:
+		l.limit = max(l.minLimit, l.limit*l.backoffPercent/100)
+	case inFlight*2 >= l.limit:
+		// only grow while the limit is used, otherwise it grows without bounds during low traffic
+		l.limit = min(l.maxLimit, l.limit+1)
+	}
+
+	return l.limit
+}
+
+// gradientConcurrencyLimit compares the latency of each request with the long-term average latency. The limit shrinks
+// proportionally while requests are slower than tolerated and grows by a queue of the square root of the limit
+// otherwise.
+type gradientConcurrencyLimit struct {
+	limit     float64
+	minLimit  float64
+	maxLimit  float64
+	tolerance float64
+	smoothing float64
+	longRtt   float64
+	samples   int
+}
+
+func newGradientConcurrencyLimit(settings AdaptiveConcurrencySettings) *gradientConcurrencyLimit {
+	return &gradientConcurrencyLimit{
+		limit:     float64(clampConcurrencyLimit(settings.InitialLimit, settings)),
+		minLimit:  float64(settings.MinLimit),
+		maxLimit:  float64(max(settings.MinLimit, settings.MaxLimit)),
+		tolerance: float64(settings.TolerancePercent) / 100,
+		smoothing: float64(settings.SmoothingPercent) / 100,
+	}
+}
+
+func (l *gradientConcurrencyLimit) Limit() int {
+	return int(l.limit)
+}
+
+func (l *gradientConcurrencyLimit) Update(rtt time.Duration, inFlight int, dropped bool) int {
+	shortRtt := float64(max(rtt, time.Microsecond))
+
+	if l.samples < gradientLongRttWindow {
+		l.samples++
+	}
+
+	if l.longRtt == 0 {
+		l.longRtt = shortRtt
+	}
+	l.longRtt += (shortRtt - l.longRtt) / float64(l.samples)
+
+	// return to the baseline faster once a long period of increased latency ended
+	if l.longRtt/shortRtt > 2 {
+		l.longRtt *= 0.95
+	}
+
+	// an underused limit has no influence on the latency, so don't grow it
+	if !dropped && float64(inFlight)*2 < l.limit {
+		return l.Limit()
+	}
+
+	gradient := math.Max(0.5, math.Min(1, l.tolerance*l.longRtt/shortRtt))
+	if dropped {
+		gradient = 0.5
+	}
+
+	limit := l.limit*gradient + math.Sqrt(l.limit)
+	limit = l.limit*(1-l.smoothing) + limit*l.smoothing
+
+	l.limit = math.Max(l.minLimit, math.Min(l.maxLimit, limit))
+
+	return l.Limit()
+}
+
+func clampConcurrencyLimit(limit int, settings AdaptiveConcurrencySettings) int {
+	return max(settings.MinLimit, min(max(settings.MinLimit, settings.MaxLimit), limit))
+}
@@ -0,0 +1,206 @@
+package httpserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type concurrencyLimitTestRecorder struct {
+	ServerMetricRecorder
+	limits chan int
+}
+
+func (r *concurrencyLimitTestRecorder) TrackConcurrencyLimit(_ context.Context, limit int) {
+	r.limits <- limit
+}
+
+func newConcurrencyLimitTestSettings(algorithm string) AdaptiveConcurrencySettings {
+	return AdaptiveConcurrencySettings{
+		Algorithm:        algorithm,
+		InitialLimit:     10,
+		MinLimit:         5,
+		MaxLimit:         20,
+		LatencyThreshold: 100 * time.Millisecond,
+		BackoffPercent:   50,
+		TolerancePercent: 150,
+		SmoothingPercent: 100,
+	}
+}
+
+func TestAimdConcurrencyLimit(t *testing.T) {
+	limit := newAimdConcurrencyLimit(newConcurrencyLimitTestSettings(ConcurrencyAlgorithmAimd))
+
+	assert.Equal(t, 10, limit.Limit())
+	assert.Equal(t, 10, limit.Update(time.Millisecond, 1, false), "an unused limit doesn't grow")
+	assert.Equal(t, 11, limit.Update(time.Millisecond, 5, false))
+	assert.Equal(t, 5, limit.Update(time.Second, 5, false))
+	assert.Equal(t, 5, limit.Update(time.Millisecond, 5, true), "the limit doesn't shrink below the minimum")
+
+	for range 100 {
+		limit.Update(time.Millisecond, 20, false)
+	}
+
+	assert.Equal(t, 20, limit.Limit(), "the limit doesn't grow above the maximum")
+}
+
+func TestGradientConcurrencyLimit(t *testing.T) {
+	limit := newGradientConcurrencyLimit(newConcurrencyLimitTestSettings(ConcurrencyAlgorithmGradient))
+
+	assert.Equal(t, 10, limit.Limit())
+	assert.Equal(t, 10, limit.Update(10*time.Millisecond, 1, false), "an unused limit doesn't grow")
+
+	for range 10 {
+		limit.Update(10*time.Millisecond, 20, false)
+	}
+
+	assert.Equal(t, 20, limit.Limit(), "the limit grows while the latency is stable")
+
+	limit.Update(100*time.Millisecond, 20, false)
+	assert.Less(t, limit.Limit(), 20, "the limit shrinks once the latency increases")
+
+	for range 10 {
+		limit.Update(time.Second, 20, true)
+	}
+
+	assert.Equal(t, 5, limit.Limit(), "the limit doesn't shrink below the minimum")
+}
+
+func TestConcurrencyLimiterQueuesByPriority(t *testing.T) {
+	recorder := &concurrencyLimitTestRecorder{limits: make(chan int, 10)}
+	limiter := &concurrencyLimiter{
+		algorithm:      fixedConcurrencyLimit(1),
+		metricRecorder: recorder,
+		queueSize:      2,
+	}
+
+	require.NoError(t, limiter.acquire(t.Context(), 1))
+
+	admitted := make(chan string, 3)
+	errs := make(chan error, 3)
+	wait := func(name string, priority int) {
+		if err := limiter.acquire(t.Context(), priority); err != nil {
+			errs <- err
+
+			return
+		}
+
+		admitted <- name
+		limiter.release(t.Context(), time.Millisecond, 1, false)
+	}
+
+	go wait("low", 0)
+	waitForConcurrencyQueue(t, limiter, 1)
+
+	go wait("normal", 1)
+	waitForConcurrencyQueue(t, limiter, 2)
+
+	// the full queue makes room for a higher priority by rejecting the lowest one
+	go wait("high", 2)
+	assert.ErrorIs(t, <-errs, ErrServerOverloaded)
+	waitForConcurrencyQueue(t, limiter, 2)
+
+	limiter.release(t.Context(), time.Millisecond, 1, false)
+
+	assert.Equal(t, "high", <-admitted)
+	assert.Equal(t, "normal", <-admitted)
+	assert.Empty(t, recorder.limits, "a fixed limit doesn't change")
+}
+
+func TestConcurrencyLimiterQueueTimeout(t *testing.T) {
+	limiter := &concurrencyLimiter{
+		algorithm:    fixedConcurrencyLimit(1),
+		queueSize:    1,
+		queueTimeout: time.Millisecond,
+	}
+
+	require.NoError(t, limiter.acquire(t.Context(), 1))
+
+	err := limiter.acquire(t.Context(), 1)
+	assert.ErrorIs(t, err, ErrServerOverloaded)
+	assert.EqualError(t, err, "server overloaded: waited 1ms in queue")
+	assert.Empty(t, limiter.queue)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	limiter.queueTimeout = 0
+	assert.EqualError(t, limiter.acquire(ctx, 1), "server overloaded: context canceled")
+}
+
+func TestConcurrencyLimiterAdmitsWaitersOnIncreasedLimit(t *testing.T) {
+	recorder := &concurrencyLimitTestRecorder{limits: make(chan int, 10)}
+	settings := newConcurrencyLimitTestSettings(ConcurrencyAlgorithmAimd)
+	settings.InitialLimit = 1
+	settings.MinLimit = 1
+	limiter := &concurrencyLimiter{
+		algorithm:      newAimdConcurrencyLimit(settings),
+		metricRecorder: recorder,
+		queueSize:      2,
+	}
+
+	require.NoError(t, limiter.acquire(t.Context(), 1))
+
+	admitted := make(chan error, 2)
+	for range 2 {
+		go func() {
+			admitted <- limiter.acquire(t.Context(), 1)
+		}()
+	}
+	waitForConcurrencyQueue(t, limiter, 2)
+
+	limiter.release(t.Context(), time.Millisecond, 1, false)
+
+	assert.NoError(t, <-admitted)
+	assert.NoError(t, <-admitted)
+	assert.Equal(t, 2, <-recorder.limits)
+	assert.Equal(t, 2, limiter.currentInFlight())
+}
+
+func waitForConcurrencyQueue(t *testing.T, limiter *concurrencyLimiter, length int) {
+	t.Helper()
+
+	assert.Eventually(t, func() bool {
+		limiter.lck.Lock()
+		defer limiter.lck.Unlock()
+
+		return len(limiter.queue) == length
+	}, time.Second, time.Millisecond)
+}
+
+func TestConcurrencyLimiterShedsByPriority(t *testing.T) {
+	limiter := &concurrencyLimiter{
+		algorithm: fixedConcurrencyLimit(4),
+		limitPercents: map[int]int{
+			requestPriorityRanks[RequestPriorityLow]:    50,
+			requestPriorityRanks[RequestPriorityNormal]: 75,
+		},
+	}
+
+	for _, step := range []struct {
+		priority string
+		admitted bool
+	}{
+		{priority: RequestPriorityLow, admitted: true},
+		{priority: RequestPriorityLow, admitted: true},
+		{priority: RequestPriorityLow, admitted: false},
+		{priority: RequestPriorityNormal, admitted: true},
+		{priority: RequestPriorityNormal, admitted: false},
+		{priority: RequestPriorityHigh, admitted: true},
+		{priority: RequestPriorityHigh, admitted: false},
+		{priority: RequestPriorityCritical, admitted: true},
+	} {
+		err := limiter.acquire(t.Context(), requestPriorityRanks[step.priority])
+
+		if step.admitted {
+			assert.NoError(t, err, step.priority)
+		} else {
+			assert.ErrorIs(t, err, ErrServerOverloaded, step.priority)
+		}
+	}
+
+	assert.Equal(t, 5, limiter.currentInFlight(), "critical requests exceed the limit")
+}
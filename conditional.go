@@ -0,0 +1,183 @@
+package httpserver
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrPreconditionFailed is reported with 412 if a conditional header of the request doesn't match the current state of
+// the target resource.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// CheckPreconditions evaluates the If-Match, If-None-Match and If-Unmodified-Since headers of a request against the
+// current ETag and modification time of the resource it targets. Handlers of PUT, PATCH or DELETE routes call it
+// before changing the resource to implement optimistic concurrency. An empty etag and a zero lastModified describe a
+// resource which doesn't exist yet, so "If-None-Match: *" allows to create it only once. Unquoted etags are handled like
+// the ones of WithETag. ErrPreconditionFailed with status 412 is returned if the request must not be handled.
+func CheckPreconditions(request *http.Request, etag string, lastModified time.Time) error {
+	exists := etag != "" || !lastModified.IsZero()
+
+	if etag != "" {
+		etag = formatETag(etag, false)
+	}
+
+	if statusCode := evaluatePreconditions(request, exists, etag, lastModified); statusCode != 0 {
+		return NewErrorWithStatus(http.StatusPreconditionFailed, ErrPreconditionFailed)
+	}
+
+	return nil
+}
+
+// evaluatePreconditions evaluates the conditional headers of a request in the order of RFC 9110, section 13.2.2. It
+// returns 304 or 412 if the request has to be answered with that status instead, 0 otherwise.
+func evaluatePreconditions(request *http.Request, exists bool, etag string, lastModified time.Time) int {
+	if ifMatch := request.Header.Get(HeaderIfMatch); ifMatch != "" {
+		if !matchETags(ifMatch, exists, etag, true) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, ok := parseHttpTime(request.Header.Get(HeaderIfUnmodifiedSince)); ok && !lastModified.IsZero() {
+		if lastModified.Truncate(time.Second).After(since) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	isSafe := request.Method == http.MethodGet || request.Method == http.MethodHead
+
+	if ifNoneMatch := request.Header.Get(HeaderIfNoneMatch); ifNoneMatch != "" {
+		if !matchETags(ifNoneMatch, exists, etag, false) {
+			return 0
+		}
+
+		if isSafe {
+			return http.StatusNotModified
+		}
+
+		return http.StatusPreconditionFailed
+	}
+
+	if since, ok := parseHttpTime(request.Header.Get(HeaderIfModifiedSince)); ok && isSafe && !lastModified.IsZero() {
+		if !lastModified.Truncate(time.Second).After(since) {
+			return http.StatusNotModified
+		}
+	}
+
+	return 0
+}
+
+// deleteRepresentationHeaders removes the headers describing the body of a response, as a 304 response carries the
+// headers of the 200 response without its body.
+func deleteRepresentationHeaders(header http.Header) {
+	header.Del(HeaderContentType)
+	header.Del(HeaderContentLength)
+	header.Del(HeaderContentEncoding)
+}
+
+// matchETags reports whether the list of entity tags of a conditional header matches etag. "*" matches any existing
+// resource. If-Match uses the strong comparison, If-None-Match the weak one.
+func matchETags(header string, exists bool, etag string, strong bool) bool {
+	header = strings.TrimSpace(header)
+
+	if header == "*" {
+		return exists
+	}
+
+	if etag == "" {
+		return false
+	}
+
+	for header != "" {
+		if header[0] == ',' {
+			header = strings.TrimSpace(header[1:])
+
+			continue
+		}
+
+		candidate, remain := scanETag(header)
+		if candidate == "" {
+			return false
+		}
+
+		if compareETags(candidate, etag, strong) {
+			return true
+		}
+
+		header = strings.TrimSpace(remain)
+	}
+
+	return false
+}
+
+// scanETag returns the entity tag at the start of s and the remainder of s. An empty etag is returned if s doesn't
+// start with a valid entity tag.
+func scanETag(s string) (etag string, remain string) {
+	start := 0
+	if strings.HasPrefix(s, "W/") {
+		start = 2
+	}
+
+	if len(s)-start < 2 || s[start] != '"' {
+		return "", ""
+	}
+
+	for i := start + 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return s[:i+1], s[i+1:]
+		case c == 0x21 || c >= 0x23 && c <= 0x7e || c >= 0x80:
+		default:
+			return "", ""
+		}
+	}
+
+	return "", ""
+}
+
+func compareETags(a string, b string, strong bool) bool {
+	if strong {
+		return a == b && !isWeakETag(a)
+	}
+
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+func isWeakETag(etag string) bool {
+	return strings.HasPrefix(etag, "W/")
+}
+
+// formatETag quotes the opaque tag unless it is quoted already and marks it as weak if requested.
+func formatETag(tag string, weak bool) string {
+	if isWeakETag(tag) {
+		return tag
+	}
+
+	if !strings.HasPrefix(tag, `"`) {
+		tag = `"` + tag + `"`
+	}
+
+	if weak {
+		return "W/" + tag
+	}
+
+	return tag
+}
+
+// hashETag computes the entity tag of a response body.
+func hashETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+
+	return formatETag(base64.RawURLEncoding.EncodeToString(sum[:]), weak)
+}
+
+func parseHttpTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	t, err := http.ParseTime(value)
+
+	return t, err == nil
+}
@@ -0,0 +1,283 @@
+package httpserver_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/stretchr/testify/assert"
+)
+
+var conditionalTestModified = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newConditionalTestRoutes(options ...httpserver.ResponseOption) func(r *gin.Engine) {
+	return func(r *gin.Engine) {
+		r.Match([]string{http.MethodGet, http.MethodHead}, "/report", httpserver.BindN(func(ctx context.Context) (httpserver.Response, error) {
+			return httpserver.NewTextResponse("report", options...), nil
+		}))
+		r.GET("/missing", httpserver.BindN(func(ctx context.Context) (httpserver.Response, error) {
+			return httpserver.NewStatusResponse(http.StatusNotFound, options...), nil
+		}))
+	}
+}
+
+func TestConditionalResponseETag(t *testing.T) {
+	cases := []struct {
+		name         string
+		method       string
+		options      []httpserver.ResponseOption
+		header       map[string]string
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			name:         "no conditional header",
+			method:       http.MethodGet,
+			options:      []httpserver.ResponseOption{httpserver.WithETag("v1")},
+			expectStatus: http.StatusOK,
+			expectBody:   "report",
+		},
+		{
+			name:         "if-none-match matches",
+			method:       http.MethodGet,
+			options:      []httpserver.ResponseOption{httpserver.WithETag("v1")},
+			header:       map[string]string{httpserver.HeaderIfNoneMatch: `"v0", "v1"`},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			name:         "if-none-match uses the weak comparison",
+			method:       http.MethodHead,
+			options:      []httpserver.ResponseOption{httpserver.WithWeakETag("v1")},
+			header:       map[string]string{httpserver.HeaderIfNoneMatch: `"v1"`},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			name:         "if-none-match doesn't match",
+			method:       http.MethodGet,
+			options:      []httpserver.ResponseOption{httpserver.WithETag("v2")},
+			header:       map[string]string{httpserver.HeaderIfNoneMatch: `"v1"`},
+			expectStatus: http.StatusOK,
+			expectBody:   "report",
+		},
+		{
+			name:         "if-none-match wildcard",
+			method:       http.MethodGet,
+			options:      []httpserver.ResponseOption{httpserver.WithETag("v1")},
+			header:       map[string]string{httpserver.HeaderIfNoneMatch: "*"},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			name:         "if-match uses the strong comparison",
+			method:       http.MethodGet,
+			options:      []httpserver.ResponseOption{httpserver.WithWeakETag("v1")},
+			header:       map[string]string{httpserver.HeaderIfMatch: `W/"v1"`},
+			expectStatus: http.StatusPreconditionFailed,
+			expectBody:   `{"err":"response error: precondition failed"}`,
+		},
+		{
+			name:         "if-match matches",
+			method:       http.MethodGet,
+			options:      []httpserver.ResponseOption{httpserver.WithETag(`"v1"`)},
+			header:       map[string]string{httpserver.HeaderIfMatch: `"v1"`},
+			expectStatus: http.StatusOK,
+			expectBody:   "report",
+		},
+		{
+			name:         "response without validators",
+			method:       http.MethodGet,
+			header:       map[string]string{httpserver.HeaderIfNoneMatch: "*"},
+			expectStatus: http.StatusOK,
+			expectBody:   "report",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newTestRouter(newConditionalTestRoutes(tc.options...))
+			rec := serveTestRequest(router, tc.method, "/report", nil, tc.header)
+
+			assert.Equal(t, tc.expectStatus, rec.Code)
+			assert.Equal(t, tc.expectBody, rec.Body.String())
+
+			if tc.expectStatus == http.StatusNotModified {
+				assert.NotEmpty(t, rec.Header().Get(httpserver.HeaderETag))
+				assert.Empty(t, rec.Header().Get(httpserver.HeaderContentType))
+			}
+		})
+	}
+}
+
+func TestConditionalResponseLastModified(t *testing.T) {
+	cases := []struct {
+		name         string
+		path         string
+		header       map[string]string
+		expectStatus int
+	}{
+		{
+			name:         "unconditional",
+			path:         "/report",
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "if-modified-since is current",
+			path:         "/report",
+			header:       map[string]string{httpserver.HeaderIfModifiedSince: conditionalTestModified.Format(http.TimeFormat)},
+			expectStatus: http.StatusNotModified,
+		},
+		{
+			name:         "if-modified-since is outdated",
+			path:         "/report",
+			header:       map[string]string{httpserver.HeaderIfModifiedSince: conditionalTestModified.Add(-time.Second).Format(http.TimeFormat)},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name: "if-modified-since is ignored if if-none-match is sent",
+			path: "/report",
+			header: map[string]string{
+				httpserver.HeaderIfNoneMatch:     `"v1"`,
+				httpserver.HeaderIfModifiedSince: conditionalTestModified.Format(http.TimeFormat),
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "if-unmodified-since is outdated",
+			path:         "/report",
+			header:       map[string]string{httpserver.HeaderIfUnmodifiedSince: conditionalTestModified.Add(-time.Second).Format(http.TimeFormat)},
+			expectStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:         "only successful responses are conditional",
+			path:         "/missing",
+			header:       map[string]string{httpserver.HeaderIfModifiedSince: conditionalTestModified.Format(http.TimeFormat)},
+			expectStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newTestRouter(newConditionalTestRoutes(httpserver.WithLastModified(conditionalTestModified.Add(500 * time.Millisecond))))
+			rec := serveTestRequest(router, http.MethodGet, tc.path, nil, tc.header)
+
+			assert.Equal(t, tc.expectStatus, rec.Code)
+
+			if tc.expectStatus == http.StatusOK || tc.expectStatus == http.StatusNotModified {
+				assert.Equal(t, "Sun, 01 Mar 2026 12:00:00 GMT", rec.Header().Get(httpserver.HeaderLastModified))
+			}
+		})
+	}
+}
+
+func TestConditionalResponseBodyETag(t *testing.T) {
+	router := newTestRouter(newConditionalTestRoutes(httpserver.WithBodyETag()))
+
+	rec := serveTestRequest(router, http.MethodGet, "/report", nil, nil)
+	etag := rec.Header().Get(httpserver.HeaderETag)
+	assert.Equal(t, `"hF6RgxMZ6JxNZWvbgMJ4rAmnIw1h5d_S4bH7tDasiRc"`, etag)
+
+	rec = serveTestRequest(router, http.MethodHead, "/report", nil, nil)
+	assert.Equal(t, etag, rec.Header().Get(httpserver.HeaderETag), "head requests get the etag of the body")
+
+	rec = serveTestRequest(router, http.MethodGet, "/report", nil, map[string]string{httpserver.HeaderIfNoneMatch: etag})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	router = newTestRouter(newConditionalTestRoutes(httpserver.WithWeakBodyETag()))
+	rec = serveTestRequest(router, http.MethodGet, "/report", nil, nil)
+	assert.Equal(t, "W/"+etag, rec.Header().Get(httpserver.HeaderETag))
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	cases := []struct {
+		name         string
+		method       string
+		etag         string
+		lastModified time.Time
+		header       map[string]string
+		expectFailed bool
+	}{
+		{
+			name:   "unconditional",
+			method: http.MethodPut,
+			etag:   `"v1"`,
+		},
+		{
+			name:   "if-match matches",
+			method: http.MethodPut,
+			etag:   `"v1"`,
+			header: map[string]string{httpserver.HeaderIfMatch: `"v1"`},
+		},
+		{
+			name:         "if-match is outdated",
+			method:       http.MethodPatch,
+			etag:         `"v2"`,
+			header:       map[string]string{httpserver.HeaderIfMatch: `"v1"`},
+			expectFailed: true,
+		},
+		{
+			name:         "if-match wildcard on a missing resource",
+			method:       http.MethodPut,
+			header:       map[string]string{httpserver.HeaderIfMatch: "*"},
+			expectFailed: true,
+		},
+		{
+			name:   "if-none-match wildcard on a missing resource",
+			method: http.MethodPut,
+			header: map[string]string{httpserver.HeaderIfNoneMatch: "*"},
+		},
+		{
+			name:         "if-none-match wildcard on an existing resource",
+			method:       http.MethodPut,
+			etag:         `"v1"`,
+			header:       map[string]string{httpserver.HeaderIfNoneMatch: "*"},
+			expectFailed: true,
+		},
+		{
+			name:         "if-unmodified-since is outdated",
+			method:       http.MethodDelete,
+			lastModified: conditionalTestModified,
+			header:       map[string]string{httpserver.HeaderIfUnmodifiedSince: conditionalTestModified.Add(-time.Minute).Format(http.TimeFormat)},
+			expectFailed: true,
+		},
+		{
+			name:         "if-unmodified-since is ignored if if-match is sent",
+			method:       http.MethodDelete,
+			etag:         `"v1"`,
+			lastModified: conditionalTestModified,
+			header: map[string]string{
+				httpserver.HeaderIfMatch:           `"v1"`,
+				httpserver.HeaderIfUnmodifiedSince: conditionalTestModified.Add(-time.Minute).Format(http.TimeFormat),
+			},
+		},
+		{
+			name:         "if-unmodified-since is current",
+			method:       http.MethodDelete,
+			lastModified: conditionalTestModified,
+			header:       map[string]string{httpserver.HeaderIfUnmodifiedSince: conditionalTestModified.Format(http.TimeFormat)},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/report", http.NoBody)
+			for key, value := range tc.header {
+				req.Header.Set(key, value)
+			}
+
+			err := httpserver.CheckPreconditions(req, tc.etag, tc.lastModified)
+
+			if !tc.expectFailed {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, httpserver.ErrPreconditionFailed)
+			assert.Equal(t, http.StatusPreconditionFailed, httpserver.GetErrorStatusCode(err))
+		})
+	}
+}
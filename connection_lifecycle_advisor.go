@@ -23,8 +23,8 @@ const (
 type (
 	// ConnectionLifeCycleAdvisor decides whether a client connection should be closed.
 	ConnectionLifeCycleAdvisor interface {
-		// ShouldCloseConnection checks whether the connection to the remote address should be closed.
-		ShouldCloseConnection(remoteAddr string, headers http.Header) bool
+		// ShouldCloseConnection checks whether the connection of the client with the given IP should be closed.
+		ShouldCloseConnection(clientIP string, headers http.Header) bool
 	}
 
 	noopConnectionLifeCycleAdvisor struct{}
@@ -85,14 +85,14 @@ func NewConnectionLifeCycleAdvisorWithInterfaces(
 	}
 }
 
-func (traffic connectionLifeCycleAdvisor) ShouldCloseConnection(remoteAddr string, _ http.Header) bool {
+func (traffic connectionLifeCycleAdvisor) ShouldCloseConnection(clientIP string, _ http.Header) bool {
 	shouldBeClosed := false
 
-	if remoteAddr == "" {
+	if clientIP == "" {
 		return false
 	}
 
-	traffic.tracker.Mutate(remoteAddr, func(entry *trafficEntry) trafficEntry {
+	traffic.tracker.Mutate(clientIP, func(entry *trafficEntry) trafficEntry {
 		if entry == nil {
 			entry = &trafficEntry{
 				activeSince: traffic.clock.Now(),
@@ -106,7 +106,7 @@ func (traffic connectionLifeCycleAdvisor) ShouldCloseConnection(remoteAddr strin
 	})
 
 	if shouldBeClosed {
-		traffic.tracker.Delete(remoteAddr)
+		traffic.tracker.Delete(clientIP)
 	}
 
 	return shouldBeClosed
@@ -140,11 +140,16 @@ func ProvideConnectionLifeCycleInterceptor(ctx context.Context, config cfg.Confi
 }
 
 // NewConnectionLifeCycleInterceptor creates a gin.HandlerFunc that uses the ConnectionLifeCycleAdvisor to
-// determine whether the connection to the remote address should be closed.
+// determine whether the connection of the client should be closed. The client IP is resolved like for the request
+// log and rate limiting, requests with an invalid remote address are tracked by it.
 func NewConnectionLifeCycleInterceptor(connectionLifeCycleAdvisor ConnectionLifeCycleAdvisor) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		remoteAddr := c.Request.RemoteAddr
-		if connectionLifeCycleAdvisor.ShouldCloseConnection(remoteAddr, c.Request.Header) {
+		clientIP, err := ResolveClientIP(c.Request)
+		if err != nil {
+			clientIP = c.Request.RemoteAddr
+		}
+
+		if connectionLifeCycleAdvisor.ShouldCloseConnection(clientIP, c.Request.Header) {
 			// This works for both HTTP/1.1 and HTTP/2 connections.
 			// see: https://github.com/golang/go/issues/20977
 			c.Header(HeaderConnection, HeaderValueClose)
@@ -2,11 +2,15 @@ package httpserver_test
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/gosoline-project/httpserver"
+	"github.com/gosoline-project/httpserver/mocks"
 	"github.com/justtrackio/gosoline/pkg/clock"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -133,3 +137,30 @@ func (s *ConnectionLifeCycleAdvisorTestSuite) TestShouldCloseConnection_Multiple
 	// Second host can connect again
 	s.False(s.advisor.ShouldCloseConnection(remoteAddrB, headers))
 }
+
+func (s *ConnectionLifeCycleAdvisorTestSuite) TestInterceptorUsesClientIP() {
+	advisor := mocks.NewConnectionLifeCycleAdvisor(s.T())
+	advisor.EXPECT().ShouldCloseConnection("203.0.113.7", mock.Anything).Return(true).Once()
+
+	resolver, err := httpserver.NewClientIPResolver(httpserver.ClientIPSettings{
+		TrustedProxies: []string{"10.0.0.0/8"},
+		Headers:        []string{httpserver.HeaderXForwardedFor},
+	})
+	s.Require().NoError(err)
+
+	router := gin.New()
+	router.Use(httpserver.ClientIPMiddleware(resolver))
+	router.Use(httpserver.NewConnectionLifeCycleInterceptor(advisor))
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	req.RemoteAddr = "10.0.0.1:12345"
+	req.Header.Set(httpserver.HeaderXForwardedFor, "203.0.113.7")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	s.Equal(httpserver.HeaderValueClose, rec.Header().Get(httpserver.HeaderConnection))
+}
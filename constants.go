@@ -9,6 +9,9 @@ const (
 	ContentTypeHtml            = "text/html; charset=utf-8"
 	ContentTypeEventStream     = "text/event-stream"
 	ContentTypeFormURLEncoded  = "application/x-www-form-urlencoded"
+	ContentTypeXml             = "application/xml; charset=utf-8"
+	ContentTypeProtobuf        = "application/x-protobuf"
+	ContentTypeProblemJson     = "application/problem+json"
 
 	HeaderAccept                        = "Accept"
 	HeaderAcceptCharset                 = "Accept-Charset"
@@ -25,6 +28,7 @@ const (
 	HeaderAccessControlMaxAge           = "Access-Control-Max-Age"
 	HeaderAccessControlRequestHeaders   = "Access-Control-Request-Headers"
 	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
+	HeaderAge                           = "Age"
 	HeaderAllow                         = "Allow"
 	HeaderAuthorization                 = "Authorization"
 	HeaderCacheControl                  = "Cache-Control"
@@ -42,11 +46,15 @@ const (
 	HeaderDoNotTrack                    = "DNT"
 	HeaderETag                          = "ETag"
 	HeaderExpires                       = "Expires"
+	HeaderForwarded                     = "Forwarded"
+	HeaderIdempotencyKey                = "Idempotency-Key"
+	HeaderIdempotentReplayed            = "Idempotent-Replayed"
 	HeaderIfMatch                       = "If-Match"
 	HeaderIfModifiedSince               = "If-Modified-Since"
 	HeaderIfNoneMatch                   = "If-None-Match"
 	HeaderIfRange                       = "If-Range"
 	HeaderIfUnmodifiedSince             = "If-Unmodified-Since"
+	HeaderLastEventId                   = "Last-Event-ID"
 	HeaderLastModified                  = "Last-Modified"
 	HeaderLink                          = "Link"
 	HeaderLocation                      = "Location"
@@ -73,6 +81,7 @@ const (
 	HeaderVia                           = "Via"
 	HeaderWarning                       = "Warning"
 	HeaderWWWAuthenticate               = "WWW-Authenticate"
+	HeaderXCache                        = "X-Cache"
 	HeaderXContentSecurityPolicy        = "X-Content-Security-Policy"
 	HeaderXContentTypeOptions           = "X-Content-Type-Options"
 	HeaderXCSRFToken                    = "X-CSRF-Token"
@@ -91,10 +100,13 @@ const (
 	HeaderXXSSProtection                = "X-XSS-Protection"
 
 	HeaderValueBasicRealmFormat = "Basic realm=%q"
+	HeaderValueBrotli           = "br"
 	HeaderValueClose            = "close"
 	HeaderValueGzip             = "gzip"
+	HeaderValueIdentity         = "identity"
 	HeaderValueKeepAlive        = "keep-alive"
 	HeaderValueNoCache          = "no-cache"
+	HeaderValueZstd             = "zstd"
 )
 
 // Normalize formats the input header to the formation of "Xxx-Xxx".
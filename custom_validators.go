@@ -34,7 +34,11 @@ type ValidateAlias struct {
 	Tags  string
 }
 
-// AddCustomValidators registers field-level validators with Gin's validator engine.
+// globalValidations records the package-level registrations, so validators of router scopes can apply them, too.
+var globalValidations validations
+
+// AddCustomValidators registers field-level validators with Gin's validator engine. Use Router.AddCustomValidators
+// to register them for the routes of one router only.
 func AddCustomValidators(customValidators []CustomValidator) error {
 	var err error
 	var v *validator.Validate
@@ -48,6 +52,8 @@ func AddCustomValidators(customValidators []CustomValidator) error {
 		if err != nil {
 			return err
 		}
+
+		globalValidations.customValidators = append(globalValidations.customValidators, customValidator)
 	}
 
 	return nil
@@ -66,6 +72,8 @@ func AddStructValidators(structValidators []StructValidator) error {
 		v.RegisterStructValidation(structValidator.Validator, structValidator.Struct)
 	}
 
+	globalValidations.structValidators = append(globalValidations.structValidators, structValidators...)
+
 	return nil
 }
 
@@ -82,6 +90,8 @@ func AddCustomTypeFuncs(customTypeFuncs []CustomTypeFunc) error {
 		v.RegisterCustomTypeFunc(customTypeFunc.Func, customTypeFunc.Types...)
 	}
 
+	globalValidations.customTypeFuncs = append(globalValidations.customTypeFuncs, customTypeFuncs...)
+
 	return nil
 }
 
@@ -98,6 +108,8 @@ func AddValidateAlias(aliases []ValidateAlias) error {
 		v.RegisterAlias(alias.Alias, alias.Tags)
 	}
 
+	globalValidations.aliases = append(globalValidations.aliases, aliases...)
+
 	return nil
 }
 
@@ -13,6 +13,11 @@ const (
 	ErrorPrivacyPublic = "public"
 	// ErrorPrivacyPrivate hides internal server error details from clients.
 	ErrorPrivacyPrivate = "private"
+
+	// ErrorFormatJson renders errors as {"err": "..."} using the package-level error handler.
+	ErrorFormatJson = "json"
+	// ErrorFormatProblem renders errors as RFC 9457 application/problem+json.
+	ErrorFormatProblem = "problem"
 )
 
 // ErrorHandler converts an error and status code into an HTTP response.
@@ -50,10 +55,17 @@ func (e errorWithStatus) Unwrap() error {
 }
 
 func errorHandlerJson(statusCode int, err error) Response {
-	return NewJsonResponse(gin.H{"err": err.Error()}, WithStatusCode(statusCode))
+	body := gin.H{"err": err.Error()}
+
+	if violations := GetFieldViolations(err); violations != nil {
+		body["violations"] = violations
+	}
+
+	return NewJsonResponse(body, WithStatusCode(statusCode))
 }
 
-// WithErrorHandler replaces the package-level default error response handler.
+// WithErrorHandler replaces the package-level default error response handler. Routers can override it
+// with Router.SetErrorHandler.
 func WithErrorHandler(handler ErrorHandler) {
 	defaultErrorHandler = handler
 }
@@ -63,6 +75,14 @@ func GetErrorHandler() ErrorHandler {
 	return defaultErrorHandler
 }
 
+// AbortWithError aborts the request with the status code carried by err and reports err to the error
+// middleware, which renders the error response in the format configured for the server.
+func AbortWithError(ginCtx *gin.Context, err error) {
+	reportGinError(ginCtx, err)
+	ginCtx.Status(GetErrorStatusCode(err))
+	ginCtx.Abort()
+}
+
 // GetErrorStatusCode returns the HTTP status code carried by err, or 500 otherwise.
 func GetErrorStatusCode(err error) int {
 	var errWithStatus ErrorWithStatus
@@ -0,0 +1,126 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/justtrackio/gosoline/pkg/cache"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	"github.com/justtrackio/gosoline/pkg/log"
+)
+
+const (
+	// IdempotencyStoreInMemory keeps the responses in the memory of the server.
+	IdempotencyStoreInMemory = "in_memory"
+
+	idempotencyStoreMaxSize   = 65535
+	idempotencyStorePruneSize = 1000
+)
+
+//go:generate go run github.com/vektra/mockery/v2 --name IdempotencyStore --with-expecter
+type (
+	// IdempotencyStore keeps the responses of requests with an idempotency key.
+	IdempotencyStore interface {
+		// Reserve stores an in-flight record with the fingerprint of the request for key, unless there is a record for
+		// key already. The existing record is returned in that case, nil otherwise.
+		Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error)
+		// Complete replaces the in-flight record of key with the response of the request.
+		Complete(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error
+		// Release removes the in-flight record of key, so the request can be retried.
+		Release(ctx context.Context, key string) error
+	}
+
+	// IdempotencyStoreFactory creates an IdempotencyStore for a server.
+	IdempotencyStoreFactory func(ctx context.Context, config cfg.Config, logger log.Logger, settings IdempotencySettings) (IdempotencyStore, error)
+
+	// IdempotencyRecord is the state of a request with an idempotency key.
+	IdempotencyRecord struct {
+		// Fingerprint identifies the request the key was used for first.
+		Fingerprint string `json:"fingerprint"`
+		// Completed reports whether the response is stored, the request is still in flight otherwise.
+		Completed bool `json:"completed"`
+		// StatusCode, Header and Body are the stored response.
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header"`
+		Body       []byte      `json:"body"`
+	}
+
+	inMemoryIdempotencyStore struct {
+		lck     sync.Mutex
+		records cache.Cache[IdempotencyRecord]
+	}
+)
+
+var (
+	idempotencyStoreFactoriesLck sync.RWMutex
+	idempotencyStoreFactories    = map[string]IdempotencyStoreFactory{
+		IdempotencyStoreInMemory: func(_ context.Context, _ cfg.Config, _ log.Logger, _ IdempotencySettings) (IdempotencyStore, error) {
+			return NewInMemoryIdempotencyStore(), nil
+		},
+	}
+)
+
+// AddIdempotencyStoreFactory makes a store available to the idempotency settings of all servers under the given name.
+func AddIdempotencyStoreFactory(name string, factory IdempotencyStoreFactory) {
+	idempotencyStoreFactoriesLck.Lock()
+	defer idempotencyStoreFactoriesLck.Unlock()
+
+	idempotencyStoreFactories[name] = factory
+}
+
+func newIdempotencyStore(ctx context.Context, config cfg.Config, logger log.Logger, settings IdempotencySettings) (IdempotencyStore, error) {
+	idempotencyStoreFactoriesLck.RLock()
+	factory, ok := idempotencyStoreFactories[settings.Store]
+	idempotencyStoreFactoriesLck.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("there is no idempotency store with the name %q", settings.Store)
+	}
+
+	return factory(ctx, config, logger, settings)
+}
+
+// NewInMemoryIdempotencyStore creates a store keeping the records in memory. Records of different instances of a
+// server aren't shared, so retries are only recognized if they reach the same instance.
+func NewInMemoryIdempotencyStore() IdempotencyStore {
+	return &inMemoryIdempotencyStore{
+		records: cache.New[IdempotencyRecord](idempotencyStoreMaxSize, idempotencyStorePruneSize, time.Minute),
+	}
+}
+
+func (s *inMemoryIdempotencyStore) Reserve(_ context.Context, key string, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+
+	if record, ok := s.records.Get(key); ok {
+		return &record, nil
+	}
+
+	s.records.SetX(key, IdempotencyRecord{
+		Fingerprint: fingerprint,
+	}, ttl)
+
+	return nil, nil
+}
+
+func (s *inMemoryIdempotencyStore) Complete(_ context.Context, key string, record IdempotencyRecord, ttl time.Duration) error {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+
+	record.Completed = true
+	s.records.SetX(key, record, ttl)
+
+	return nil
+}
+
+func (s *inMemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+
+	s.records.Delete(key)
+
+	return nil
+}
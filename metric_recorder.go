@@ -5,6 +5,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/justtrackio/gosoline/pkg/appctx"
 	"github.com/justtrackio/gosoline/pkg/clock"
 	"github.com/justtrackio/gosoline/pkg/metric"
 )
@@ -13,8 +14,18 @@ const (
 	concurrencyMetricSampleInterval = 10 * time.Second
 	// MetricHttpConcurrentRequests is the active request gauge metric name.
 	MetricHttpConcurrentRequests = "HttpConcurrentRequests"
+	// MetricHttpConcurrencyLimit is the gauge metric name of the limit of concurrently handled requests.
+	MetricHttpConcurrencyLimit = "HttpConcurrencyLimit"
 	// MetricHttpOpenConnections is the open connection gauge metric name.
 	MetricHttpOpenConnections = "HttpOpenConnections"
+	// MetricHttpSseSubscribers is the SSE hub subscriber gauge metric name.
+	MetricHttpSseSubscribers = "HttpSseSubscribers"
+	// MetricHttpDecompressionLimitExceeded is the count metric name of request bodies rejected by the decompression limits.
+	MetricHttpDecompressionLimitExceeded = "HttpDecompressionLimitExceeded"
+	// MetricHttpUploadBytes is the metric name of the bytes read per uploaded file.
+	MetricHttpUploadBytes = "HttpUploadBytes"
+	// MetricHttpUploadRejected is the count metric name of uploaded files rejected by the upload limits.
+	MetricHttpUploadRejected = "HttpUploadRejected"
 )
 
 // ServerMetricRecorder records active request and connection metrics for a server.
@@ -23,18 +34,35 @@ const (
 type ServerMetricRecorder interface {
 	TrackRequestStarted(ctx context.Context)
 	TrackRequestCompleted(ctx context.Context)
+	TrackConcurrencyLimit(ctx context.Context, limit int)
 	TrackConnectionOpened(ctx context.Context)
 	TrackConnectionClosed(ctx context.Context)
+	TrackSseSubscribed(ctx context.Context)
+	TrackSseUnsubscribed(ctx context.Context)
+	TrackDecompressionLimitExceeded(ctx context.Context)
+	TrackUploadedBytes(ctx context.Context, bytes int64)
+	TrackUploadRejected(ctx context.Context)
 	Run(ctx context.Context) error
 }
 
 type serverMetricRecorder struct {
-	name            string
-	clock           clock.Clock
-	writer          metric.Writer
-	activeRequests  atomic.Int64
-	openConnections atomic.Int64
-	sampleInterval  time.Duration
+	name             string
+	clock            clock.Clock
+	writer           metric.Writer
+	activeRequests   atomic.Int64
+	concurrencyLimit atomic.Int64
+	openConnections  atomic.Int64
+	sseSubscribers   atomic.Int64
+	sampleInterval   time.Duration
+}
+
+type serverMetricRecorderKey string
+
+// provideServerMetricRecorder returns the metric recorder shared by the server and the SSE hubs of the server.
+func provideServerMetricRecorder(ctx context.Context, name string) (ServerMetricRecorder, error) {
+	return appctx.Provide(ctx, serverMetricRecorderKey(name), func() (ServerMetricRecorder, error) {
+		return newServerMetricRecorder(name), nil
+	})
 }
 
 func newServerMetricRecorder(name string) ServerMetricRecorder {
@@ -60,6 +88,11 @@ func (r *serverMetricRecorder) TrackRequestCompleted(ctx context.Context) {
 	r.writeConcurrentRequests(ctx, r.activeRequests.Add(-1))
 }
 
+func (r *serverMetricRecorder) TrackConcurrencyLimit(ctx context.Context, limit int) {
+	r.concurrencyLimit.Store(int64(limit))
+	r.writer.WriteOne(ctx, r.buildGaugeDatum(MetricHttpConcurrencyLimit, int64(limit)))
+}
+
 func (r *serverMetricRecorder) TrackConnectionOpened(ctx context.Context) {
 	r.writeOpenConnections(ctx, r.openConnections.Add(1))
 }
@@ -68,6 +101,50 @@ func (r *serverMetricRecorder) TrackConnectionClosed(ctx context.Context) {
 	r.writeOpenConnections(ctx, r.openConnections.Add(-1))
 }
 
+func (r *serverMetricRecorder) TrackSseSubscribed(ctx context.Context) {
+	r.writeSseSubscribers(ctx, r.sseSubscribers.Add(1))
+}
+
+func (r *serverMetricRecorder) TrackSseUnsubscribed(ctx context.Context) {
+	r.writeSseSubscribers(ctx, r.sseSubscribers.Add(-1))
+}
+
+func (r *serverMetricRecorder) TrackDecompressionLimitExceeded(ctx context.Context) {
+	r.writer.WriteOne(ctx, &metric.Datum{
+		Priority:   metric.PriorityHigh,
+		MetricName: MetricHttpDecompressionLimitExceeded,
+		Dimensions: metric.Dimensions{
+			"ServerName": r.name,
+		},
+		Unit:  metric.UnitCount,
+		Value: 1,
+	})
+}
+
+func (r *serverMetricRecorder) TrackUploadedBytes(ctx context.Context, bytes int64) {
+	r.writer.WriteOne(ctx, &metric.Datum{
+		Priority:   metric.PriorityHigh,
+		MetricName: MetricHttpUploadBytes,
+		Dimensions: metric.Dimensions{
+			"ServerName": r.name,
+		},
+		Unit:  metric.UnitCount,
+		Value: float64(bytes),
+	})
+}
+
+func (r *serverMetricRecorder) TrackUploadRejected(ctx context.Context) {
+	r.writer.WriteOne(ctx, &metric.Datum{
+		Priority:   metric.PriorityHigh,
+		MetricName: MetricHttpUploadRejected,
+		Dimensions: metric.Dimensions{
+			"ServerName": r.name,
+		},
+		Unit:  metric.UnitCount,
+		Value: 1,
+	})
+}
+
 func (r *serverMetricRecorder) Run(ctx context.Context) error {
 	ticker := r.clock.NewTicker(r.sampleInterval)
 	defer ticker.Stop()
@@ -89,7 +166,9 @@ func (r *serverMetricRecorder) Run(ctx context.Context) error {
 func (r *serverMetricRecorder) writeCurrent(ctx context.Context) {
 	r.writer.Write(ctx, metric.Data{
 		r.buildGaugeDatum(MetricHttpConcurrentRequests, r.activeRequests.Load()),
+		r.buildGaugeDatum(MetricHttpConcurrencyLimit, r.concurrencyLimit.Load()),
 		r.buildGaugeDatum(MetricHttpOpenConnections, r.openConnections.Load()),
+		r.buildGaugeDatum(MetricHttpSseSubscribers, r.sseSubscribers.Load()),
 	})
 }
 
@@ -101,6 +180,10 @@ func (r *serverMetricRecorder) writeOpenConnections(ctx context.Context, value i
 	r.writer.WriteOne(ctx, r.buildGaugeDatum(MetricHttpOpenConnections, value))
 }
 
+func (r *serverMetricRecorder) writeSseSubscribers(ctx context.Context, value int64) {
+	r.writer.WriteOne(ctx, r.buildGaugeDatum(MetricHttpSseSubscribers, value))
+}
+
 func (r *serverMetricRecorder) buildGaugeDatum(metricName string, value int64) *metric.Datum {
 	return &metric.Datum{
 		Priority:   metric.PriorityHigh,
@@ -126,6 +209,16 @@ func getMetricRecorderDefaults(name string) metric.Data {
 			Kind:  metric.KindGauge.Build(),
 			Value: 0,
 		},
+		{
+			Priority:   metric.PriorityHigh,
+			MetricName: MetricHttpConcurrencyLimit,
+			Dimensions: metric.Dimensions{
+				"ServerName": name,
+			},
+			Unit:  metric.UnitCountMaximum,
+			Kind:  metric.KindGauge.Build(),
+			Value: 0,
+		},
 		{
 			Priority:   metric.PriorityHigh,
 			MetricName: MetricHttpOpenConnections,
@@ -136,5 +229,33 @@ func getMetricRecorderDefaults(name string) metric.Data {
 			Kind:  metric.KindGauge.Build(),
 			Value: 0,
 		},
+		{
+			Priority:   metric.PriorityHigh,
+			MetricName: MetricHttpSseSubscribers,
+			Dimensions: metric.Dimensions{
+				"ServerName": name,
+			},
+			Unit:  metric.UnitCountMaximum,
+			Kind:  metric.KindGauge.Build(),
+			Value: 0,
+		},
+		{
+			Priority:   metric.PriorityHigh,
+			MetricName: MetricHttpDecompressionLimitExceeded,
+			Dimensions: metric.Dimensions{
+				"ServerName": name,
+			},
+			Unit:  metric.UnitCount,
+			Value: 0,
+		},
+		{
+			Priority:   metric.PriorityHigh,
+			MetricName: MetricHttpUploadRejected,
+			Dimensions: metric.Dimensions{
+				"ServerName": name,
+			},
+			Unit:  metric.UnitCount,
+			Value: 0,
+		},
 	}
 }
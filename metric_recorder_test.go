@@ -36,18 +36,78 @@ func TestServerMetricRecorder_OpenConnections(t *testing.T) {
 	recorder.TrackConnectionClosed(t.Context())
 }
 
+func TestServerMetricRecorder_SseSubscribers(t *testing.T) {
+	writer := metricMocks.NewWriter(t)
+	expectWriteOne(writer, MetricHttpSseSubscribers, []float64{1, 2, 1, 0})
+	recorder := newServerMetricRecorderWithInterfaces("api", clock.NewFakeClock(), writer, time.Hour)
+
+	recorder.TrackSseSubscribed(t.Context())
+	recorder.TrackSseSubscribed(t.Context())
+	recorder.TrackSseUnsubscribed(t.Context())
+	recorder.TrackSseUnsubscribed(t.Context())
+}
+
+func TestServerMetricRecorder_ConcurrencyLimit(t *testing.T) {
+	writer := metricMocks.NewWriter(t)
+	expectWriteOne(writer, MetricHttpConcurrencyLimit, []float64{20, 18})
+	recorder := newServerMetricRecorderWithInterfaces("api", clock.NewFakeClock(), writer, time.Hour)
+
+	recorder.TrackConcurrencyLimit(t.Context(), 20)
+	recorder.TrackConcurrencyLimit(t.Context(), 18)
+}
+
+func TestServerMetricRecorder_DecompressionLimitExceeded(t *testing.T) {
+	writer := metricMocks.NewWriter(t)
+	writer.EXPECT().WriteOne(matcher.Context, &metric.Datum{
+		Priority:   metric.PriorityHigh,
+		MetricName: MetricHttpDecompressionLimitExceeded,
+		Dimensions: metric.Dimensions{"ServerName": "api"},
+		Unit:       metric.UnitCount,
+		Value:      1,
+	}).Return().Once()
+
+	recorder := newServerMetricRecorderWithInterfaces("api", clock.NewFakeClock(), writer, time.Hour)
+	recorder.TrackDecompressionLimitExceeded(t.Context())
+}
+
+func TestServerMetricRecorder_Upload(t *testing.T) {
+	writer := metricMocks.NewWriter(t)
+	writer.EXPECT().WriteOne(matcher.Context, &metric.Datum{
+		Priority:   metric.PriorityHigh,
+		MetricName: MetricHttpUploadBytes,
+		Dimensions: metric.Dimensions{"ServerName": "api"},
+		Unit:       metric.UnitCount,
+		Value:      2048,
+	}).Return().Once()
+	writer.EXPECT().WriteOne(matcher.Context, &metric.Datum{
+		Priority:   metric.PriorityHigh,
+		MetricName: MetricHttpUploadRejected,
+		Dimensions: metric.Dimensions{"ServerName": "api"},
+		Unit:       metric.UnitCount,
+		Value:      1,
+	}).Return().Once()
+
+	recorder := newServerMetricRecorderWithInterfaces("api", clock.NewFakeClock(), writer, time.Hour)
+	recorder.TrackUploadedBytes(t.Context(), 2048)
+	recorder.TrackUploadRejected(t.Context())
+}
+
 func TestServerMetricRecorder_RunSamplesCurrentValues(t *testing.T) {
 	writer := metricMocks.NewWriter(t)
 	writer.EXPECT().WriteOne(matcher.Context, matchMetricDatum(MetricHttpConcurrentRequests, 1)).Return()
 	writer.EXPECT().WriteOne(matcher.Context, matchMetricDatum(MetricHttpOpenConnections, 1)).Return()
+	writer.EXPECT().WriteOne(matcher.Context, matchMetricDatum(MetricHttpConcurrencyLimit, 10)).Return()
 	writer.EXPECT().Write(matcher.Context, matchMetricData(t, map[string]float64{
 		MetricHttpConcurrentRequests: 1,
+		MetricHttpConcurrencyLimit:   10,
 		MetricHttpOpenConnections:    1,
+		MetricHttpSseSubscribers:     0,
 	})).Return().Twice()
 
 	recorder := newServerMetricRecorderWithInterfaces("api", clock.NewFakeClock(), writer, time.Hour)
 	recorder.TrackRequestStarted(t.Context())
 	recorder.TrackConnectionOpened(t.Context())
+	recorder.TrackConcurrencyLimit(t.Context(), 10)
 
 	ctx, cancel := context.WithCancel(t.Context())
 	cancel()
@@ -88,10 +148,16 @@ func matchMetricData(t *testing.T, valuesByMetricName map[string]float64) any {
 func TestGetMetricRecorderDefaults(t *testing.T) {
 	defaults := getMetricRecorderDefaults("api")
 
-	assert.Len(t, defaults, 2)
-	for _, datum := range defaults {
+	assert.Len(t, defaults, 6)
+	for _, datum := range defaults[:4] {
 		assertMetricDatum(t, datum, datum.MetricName, 0)
 	}
+
+	for i, metricName := range []string{MetricHttpDecompressionLimitExceeded, MetricHttpUploadRejected} {
+		assert.Equal(t, metricName, defaults[4+i].MetricName)
+		assert.Equal(t, metric.UnitCount, defaults[4+i].Unit)
+		assert.Equal(t, 0.0, defaults[4+i].Value)
+	}
 }
 
 func isMetricDatum(datum *metric.Datum, metricName string, value float64) bool {
@@ -1,41 +1,309 @@
 package httpserver
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// ConcurrentRequestLimitMiddleware limits the number of concurrently handled requests.
-// When the limit is reached, it rejects new requests immediately instead of queueing them.
+// ErrServerOverloaded is reported if a request is rejected because the server is at its concurrency limit.
+var ErrServerOverloaded = errors.New("server overloaded")
+
+const (
+	// RequestPriorityLow requests, like batch jobs, are shed first. They can only use a share of the concurrency limit
+	// and wait behind all other requests.
+	RequestPriorityLow = "low"
+	// RequestPriorityNormal is the priority of routes without priority.
+	RequestPriorityNormal = "normal"
+	// RequestPriorityHigh requests can use the whole concurrency limit and wait in front of all other requests.
+	RequestPriorityHigh = "high"
+	// RequestPriorityCritical requests are never rejected by the concurrency limit, but count towards it.
+	RequestPriorityCritical = "critical"
+
+	requestPriorityKey = "goso.request.priority"
+)
+
+var requestPriorityRanks = map[string]int{
+	RequestPriorityLow:      0,
+	RequestPriorityNormal:   1,
+	RequestPriorityHigh:     2,
+	RequestPriorityCritical: 3,
+}
+
+type (
+	// concurrencyLimiter admits requests up to the limit of its algorithm. Requests above the limit wait in a queue
+	// ordered by priority until a slot is released or they time out.
+	concurrencyLimiter struct {
+		lck            sync.Mutex
+		algorithm      concurrencyLimitAlgorithm
+		metricRecorder ServerMetricRecorder
+		inFlight       int
+		queue          []*concurrencyWaiter
+		queueSize      int
+		queueTimeout   time.Duration
+		sequence       uint64
+		// limitPercents are the shares of the limit requests of a priority rank can use
+		limitPercents map[int]int
+	}
+
+	concurrencyWaiter struct {
+		priority int
+		sequence uint64
+		ready    chan struct{}
+		admitted bool
+	}
+)
+
+// ConcurrentRequestLimitMiddleware limits the number of concurrently handled requests to MaxRequests or to the limit
+// of the adaptive algorithm. When the limit is reached, new requests wait in a queue of QueueSize requests, ordered by
+// the priority of their route, optionally lowered by the PriorityHeader, or are rejected immediately without a queue. Requests with low
+// and normal priority are limited to a share of the limit, so they are shed before requests with higher priority.
 func ConcurrentRequestLimitMiddleware(settings ConcurrencySettings) gin.HandlerFunc {
-	if settings.MaxRequests <= 0 {
+	return ConcurrentRequestLimitMiddlewareWithInterfaces(settings, nil)
+}
+
+// ConcurrentRequestLimitMiddlewareWithInterfaces creates a ConcurrentRequestLimitMiddleware tracking the current limit
+// as a gauge with the metric recorder. A nil recorder doesn't track it.
+func ConcurrentRequestLimitMiddlewareWithInterfaces(settings ConcurrencySettings, metricRecorder ServerMetricRecorder) gin.HandlerFunc {
+	if settings.MaxRequests <= 0 && settings.Adaptive.Algorithm == "" {
 		return func(c *gin.Context) {
 			c.Next()
 		}
 	}
 
-	semaphore := make(chan struct{}, settings.MaxRequests)
+	limiter := &concurrencyLimiter{
+		algorithm:      newConcurrencyLimitAlgorithm(settings),
+		metricRecorder: metricRecorder,
+		queueSize:      settings.QueueSize,
+		queueTimeout:   settings.QueueTimeout,
+		limitPercents: map[int]int{
+			requestPriorityRanks[RequestPriorityLow]:    settings.LowPriorityPercent,
+			requestPriorityRanks[RequestPriorityNormal]: settings.NormalPriorityPercent,
+		},
+	}
+	limiter.trackLimit(context.Background(), limiter.algorithm.Limit())
 
 	return func(c *gin.Context) {
-		select {
-		case semaphore <- struct{}{}:
-			defer func() {
-				<-semaphore
-			}()
+		if priority := c.GetHeader(settings.PriorityHeader); settings.PriorityHeader != "" && priority != "" {
+			setRequestPriorityFromHeader(c, priority)
+		}
 
-			c.Next()
-		default:
+		if err := limiter.acquire(c.Request.Context(), requestPriorityRanks[GetRequestPriority(c)]); err != nil {
 			c.Request = MarkRequestRejected(c.Request)
 			writeRetryAfterHeader(c, settings.RetryAfter)
+
+			if IsErrorFormatProblem(c) {
+				AbortWithError(c, NewErrorWithStatus(settings.OverloadStatusCode, err))
+
+				return
+			}
+
 			c.AbortWithStatusJSON(settings.OverloadStatusCode, gin.H{
-				"error": "server overloaded",
+				"error": err.Error(),
 			})
+
+			return
+		}
+
+		start := time.Now()
+		inFlight := limiter.currentInFlight()
+
+		defer func() {
+			dropped := errors.Is(context.Cause(c.Request.Context()), ErrHandlerDeadlineExceeded)
+			limiter.release(c.Request.Context(), time.Since(start), inFlight, dropped)
+		}()
+
+		c.Next()
+	}
+}
+
+// SetRequestPriority sets the priority of a request under pressure to one of the RequestPriority constants. Use it in
+// middleware running before ConcurrentRequestLimitMiddleware, the server sets the priority of the route.
+func SetRequestPriority(ginCtx *gin.Context, priority string) {
+	ginCtx.Set(requestPriorityKey, priority)
+}
+
+// GetRequestPriority returns the priority of a request, RequestPriorityNormal if none or an unknown one was set.
+func GetRequestPriority(ginCtx *gin.Context) string {
+	priority, _ := ginCtx.Value(requestPriorityKey).(string)
+
+	if _, ok := requestPriorityRanks[priority]; !ok {
+		return RequestPriorityNormal
+	}
+
+	return priority
+}
+
+// setRequestPriorityFromHeader sets the priority a client requested if it is lower than the priority of the route.
+// The header is sent by any client, so it can't raise the priority of a request, or low priority routes wouldn't be
+// shed anymore.
+func setRequestPriorityFromHeader(ginCtx *gin.Context, priority string) {
+	priority = strings.ToLower(strings.TrimSpace(priority))
+
+	if rank, ok := requestPriorityRanks[priority]; ok && rank < requestPriorityRanks[GetRequestPriority(ginCtx)] {
+		SetRequestPriority(ginCtx, priority)
+	}
+}
+
+func (l *concurrencyLimiter) acquire(ctx context.Context, priority int) error {
+	l.lck.Lock()
+
+	if l.inFlight < l.priorityLimit(l.algorithm.Limit(), priority) && (len(l.queue) == 0 || l.queue[0].priority < priority) {
+		l.inFlight++
+		l.lck.Unlock()
+
+		return nil
+	}
+
+	waiter, err := l.enqueue(priority)
+	l.lck.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	var timeout <-chan time.Time
+	if l.queueTimeout > 0 {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	select {
+	case <-waiter.ready:
+	case <-timeout:
+	case <-ctx.Done():
+	}
+
+	l.lck.Lock()
+	defer l.lck.Unlock()
+
+	if waiter.admitted {
+		return nil
+	}
+
+	select {
+	case <-waiter.ready:
+		// the waiter was displaced by a request with a higher priority
+		return ErrServerOverloaded
+	default:
+		l.remove(waiter)
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", ErrServerOverloaded, context.Cause(ctx))
+	}
+
+	return fmt.Errorf("%w: waited %s in queue", ErrServerOverloaded, l.queueTimeout)
+}
+
+// enqueue adds a waiter ordered by priority and arrival. A full queue displaces the last waiter if it has a lower
+// priority.
+func (l *concurrencyLimiter) enqueue(priority int) (*concurrencyWaiter, error) {
+	if l.queueSize <= 0 {
+		return nil, ErrServerOverloaded
+	}
+
+	if len(l.queue) >= l.queueSize {
+		last := l.queue[len(l.queue)-1]
+		if last.priority >= priority {
+			return nil, ErrServerOverloaded
 		}
+
+		l.queue = l.queue[:len(l.queue)-1]
+		close(last.ready)
 	}
+
+	l.sequence++
+	waiter := &concurrencyWaiter{
+		priority: priority,
+		sequence: l.sequence,
+		ready:    make(chan struct{}),
+	}
+
+	index, _ := slices.BinarySearchFunc(l.queue, waiter, compareConcurrencyWaiters)
+	l.queue = slices.Insert(l.queue, index, waiter)
+
+	return waiter, nil
+}
+
+func (l *concurrencyLimiter) remove(waiter *concurrencyWaiter) {
+	l.queue = slices.DeleteFunc(l.queue, func(queued *concurrencyWaiter) bool {
+		return queued == waiter
+	})
+}
+
+func (l *concurrencyLimiter) currentInFlight() int {
+	l.lck.Lock()
+	defer l.lck.Unlock()
+
+	return l.inFlight
+}
+
+func (l *concurrencyLimiter) release(ctx context.Context, rtt time.Duration, inFlight int, dropped bool) {
+	l.lck.Lock()
+
+	previousLimit := l.algorithm.Limit()
+	limit := l.algorithm.Update(rtt, inFlight, dropped)
+
+	l.inFlight--
+
+	// admit waiters in order of the queue while the limit allows it. The share of the limit of the first waiter is
+	// at least the share of all following waiters.
+	for len(l.queue) > 0 && l.inFlight < l.priorityLimit(limit, l.queue[0].priority) {
+		waiter := l.queue[0]
+		l.queue = l.queue[1:]
+		l.inFlight++
+
+		waiter.admitted = true
+		close(waiter.ready)
+	}
+
+	l.lck.Unlock()
+
+	if limit != previousLimit {
+		l.trackLimit(ctx, limit)
+	}
+}
+
+func (l *concurrencyLimiter) trackLimit(ctx context.Context, limit int) {
+	if l.metricRecorder != nil {
+		l.metricRecorder.TrackConcurrencyLimit(ctx, limit)
+	}
+}
+
+// priorityLimit returns the share of the limit requests of the priority rank can use, rounded up so small limits
+// aren't reduced to 0.
+func (l *concurrencyLimiter) priorityLimit(limit int, priority int) int {
+	if priority == requestPriorityRanks[RequestPriorityCritical] {
+		return math.MaxInt
+	}
+
+	percent, ok := l.limitPercents[priority]
+	if !ok || percent <= 0 {
+		return limit
+	}
+
+	return (limit*percent + 99) / 100
+}
+
+func compareConcurrencyWaiters(a *concurrencyWaiter, b *concurrencyWaiter) int {
+	if a.priority != b.priority {
+		return cmp.Compare(b.priority, a.priority)
+	}
+
+	return cmp.Compare(a.sequence, b.sequence)
 }
 
 func writeRetryAfterHeader(c *gin.Context, retryAfter time.Duration) {
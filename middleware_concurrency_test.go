@@ -9,6 +9,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gosoline-project/httpserver"
+	httpserverMocks "github.com/gosoline-project/httpserver/mocks"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -29,6 +31,7 @@ func (s *MiddlewareConcurrencyTestSuite) newConcurrentRequestLimitRouter(setting
 	router := gin.New()
 	var enteredOnce sync.Once
 
+	router.Use(httpserver.ErrorMiddleware())
 	router.Use(httpserver.ConcurrentRequestLimitMiddleware(settings))
 	router.GET("/", func(c *gin.Context) {
 		if entered != nil {
@@ -121,3 +124,183 @@ func (s *MiddlewareConcurrencyTestSuite) TestReleasesSlotAfterRequest() {
 		s.Equal(http.StatusNoContent, recorder.Code)
 	}
 }
+
+func (s *MiddlewareConcurrencyTestSuite) TestQueuesWhenLimitReached() {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	router := s.newConcurrentRequestLimitRouter(httpserver.ConcurrencySettings{
+		MaxRequests:        1,
+		OverloadStatusCode: http.StatusServiceUnavailable,
+		QueueSize:          1,
+	}, entered, release)
+
+	firstResult := make(chan int)
+	go func() {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/", http.NoBody)
+		require.NoError(s.T(), err)
+
+		router.ServeHTTP(recorder, req)
+		firstResult <- recorder.Code
+	}()
+	<-entered
+
+	queuedResult := make(chan int, 1)
+	go func() {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/", http.NoBody)
+		require.NoError(s.T(), err)
+
+		router.ServeHTTP(recorder, req)
+		queuedResult <- recorder.Code
+	}()
+
+	s.Never(func() bool {
+		return len(queuedResult) > 0
+	}, 20*time.Millisecond, time.Millisecond, "the second request waits for the first one")
+
+	close(release)
+	s.Equal(http.StatusNoContent, <-firstResult)
+	s.Equal(http.StatusNoContent, <-queuedResult)
+}
+
+func (s *MiddlewareConcurrencyTestSuite) TestRejectsAfterQueueTimeout() {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	router := s.newConcurrentRequestLimitRouter(httpserver.ConcurrencySettings{
+		MaxRequests:        1,
+		OverloadStatusCode: http.StatusTooManyRequests,
+		QueueSize:          1,
+		QueueTimeout:       10 * time.Millisecond,
+	}, entered, release)
+
+	firstResult := make(chan int)
+	go func() {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/", http.NoBody)
+		require.NoError(s.T(), err)
+
+		router.ServeHTTP(recorder, req)
+		firstResult <- recorder.Code
+	}()
+	<-entered
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, s.newRequest())
+
+	s.Equal(http.StatusTooManyRequests, recorder.Code)
+	s.JSONEq(`{"error":"server overloaded: waited 10ms in queue"}`, recorder.Body.String())
+
+	close(release)
+	s.Equal(http.StatusNoContent, <-firstResult)
+}
+
+func (s *MiddlewareConcurrencyTestSuite) TestTracksAdaptiveLimit() {
+	recorder := httpserverMocks.NewServerMetricRecorder(s.T())
+	recorder.EXPECT().TrackConcurrencyLimit(mock.Anything, 10).Return().Once()
+	recorder.EXPECT().TrackConcurrencyLimit(mock.Anything, 9).Return().Once()
+
+	router := gin.New()
+	router.Use(httpserver.ConcurrentRequestLimitMiddlewareWithInterfaces(httpserver.ConcurrencySettings{
+		Adaptive: httpserver.AdaptiveConcurrencySettings{
+			Algorithm:        httpserver.ConcurrencyAlgorithmAimd,
+			InitialLimit:     10,
+			MinLimit:         1,
+			MaxLimit:         100,
+			LatencyThreshold: time.Millisecond,
+			BackoffPercent:   90,
+		},
+	}, recorder))
+	router.GET("/", func(c *gin.Context) {
+		time.Sleep(5 * time.Millisecond)
+		c.Status(http.StatusNoContent)
+	})
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, s.newRequest())
+
+	s.Equal(http.StatusNoContent, response.Code)
+}
+
+func (s *MiddlewareConcurrencyTestSuite) TestPriorityHeaderOnlyLowersPriority() {
+	testCases := map[string]struct {
+		routePriority  string
+		headerPriority string
+		expected       string
+	}{
+		"lowers normal route":   {headerPriority: "low", expected: httpserver.RequestPriorityLow},
+		"lowers high route":     {routePriority: httpserver.RequestPriorityHigh, headerPriority: "normal", expected: httpserver.RequestPriorityNormal},
+		"can't raise normal":    {headerPriority: "high", expected: httpserver.RequestPriorityNormal},
+		"can't raise low route": {routePriority: httpserver.RequestPriorityLow, headerPriority: "high", expected: httpserver.RequestPriorityLow},
+		"can't claim critical":  {routePriority: httpserver.RequestPriorityHigh, headerPriority: "critical", expected: httpserver.RequestPriorityHigh},
+		"ignores unknown":       {routePriority: httpserver.RequestPriorityHigh, headerPriority: "urgent", expected: httpserver.RequestPriorityHigh},
+	}
+
+	for name, tc := range testCases {
+		s.Run(name, func() {
+			var priority string
+
+			recorder := httpserverMocks.NewServerMetricRecorder(s.T())
+			recorder.EXPECT().TrackConcurrencyLimit(mock.Anything, mock.Anything).Return().Maybe()
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tc.routePriority != "" {
+					httpserver.SetRequestPriority(c, tc.routePriority)
+				}
+			})
+			router.Use(httpserver.ConcurrentRequestLimitMiddlewareWithInterfaces(httpserver.ConcurrencySettings{
+				MaxRequests:    1,
+				PriorityHeader: "X-Request-Priority",
+			}, recorder))
+			router.GET("/", func(c *gin.Context) {
+				priority = httpserver.GetRequestPriority(c)
+			})
+
+			req := s.newRequest()
+			req.Header.Set("X-Request-Priority", tc.headerPriority)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			s.Equal(tc.expected, priority)
+		})
+	}
+}
+
+func (s *MiddlewareConcurrencyTestSuite) TestPriorityHeader() {
+	for maxRequests, priority := range map[int]string{
+		// low priority requests can only use half of the limit
+		2: "LOW",
+		// clients can't raise their priority, so the request is rejected as a normal one
+		1: httpserver.RequestPriorityCritical,
+	} {
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		router := s.newConcurrentRequestLimitRouter(httpserver.ConcurrencySettings{
+			MaxRequests:        maxRequests,
+			OverloadStatusCode: http.StatusServiceUnavailable,
+			PriorityHeader:     "X-Request-Priority",
+			LowPriorityPercent: 50,
+		}, entered, release)
+
+		firstResult := make(chan int)
+		go func() {
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/", http.NoBody)
+			require.NoError(s.T(), err)
+
+			router.ServeHTTP(recorder, req)
+			firstResult <- recorder.Code
+		}()
+		<-entered
+
+		recorder := httptest.NewRecorder()
+		req := s.newRequest()
+		req.Header.Set("X-Request-Priority", priority)
+
+		router.ServeHTTP(recorder, req)
+		s.Equal(http.StatusServiceUnavailable, recorder.Code, priority)
+
+		close(release)
+		s.Equal(http.StatusNoContent, <-firstResult)
+	}
+}
@@ -6,32 +6,75 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const errorsSettingsKey = "goso.errors.settings"
+
 // ErrorMiddleware creates error middleware with default settings.
 func ErrorMiddleware() gin.HandlerFunc {
 	return ErrorMiddlewareWithSettings(ErrorsSettings{})
 }
 
-// ErrorMiddlewareWithSettings converts Gin context errors into HTTP error responses.
+// ErrorMiddlewareWithSettings converts Gin context errors into HTTP error responses. The error handler of the
+// router group serving the request is used if set. Otherwise, depending on the configured format, the
+// package-level error handler or an RFC 9457 problem is used to render the response.
 func ErrorMiddlewareWithSettings(settings ErrorsSettings) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// handlers rendering errors on their own, like the BindSse family, use the same settings
+		c.Set(errorsSettingsKey, settings)
 		c.Next()
 
-		if len(c.Errors) == 0 {
+		// a written response, like the upgrade of a WebSocket connection, can't be replaced, its errors are only logged
+		if len(c.Errors) == 0 || c.Writer.Written() {
 			return
 		}
 
 		err := c.Errors.Last().Err
 		statusCode := GetErrorStatusCode(err)
+		err = getPublicError(settings, statusCode, err)
 
-		if statusCode >= 500 && (settings.Privacy == ErrorPrivacyPrivate || settings.Privacy == "") {
-			err = fmt.Errorf("internal server error")
-		}
-
-		errorHandler := GetErrorHandler()
-		response := errorHandler(statusCode, err)
+		response := getErrorResponse(c, settings, statusCode, err)
 
 		if err = BindHandleResponse(response, c); err != nil {
 			c.Errors = append(c.Errors, &gin.Error{Err: fmt.Errorf("error response error: %w", err), Type: gin.ErrorTypePrivate})
 		}
 	}
 }
+
+// IsErrorFormatProblem returns true if errors of the request are rendered as RFC 9457 problems. Handlers writing their
+// own error bodies use it to keep their json format otherwise.
+func IsErrorFormatProblem(ginCtx *gin.Context) bool {
+	return getErrorsSettings(ginCtx).Format == ErrorFormatProblem
+}
+
+func getErrorsSettings(ginCtx *gin.Context) ErrorsSettings {
+	if settings, ok := ginCtx.Value(errorsSettingsKey).(ErrorsSettings); ok {
+		return settings
+	}
+
+	return ErrorsSettings{}
+}
+
+// getPublicError hides the details of internal server errors unless the privacy is set to public.
+func getPublicError(settings ErrorsSettings, statusCode int, err error) error {
+	if statusCode >= 500 && (settings.Privacy == ErrorPrivacyPrivate || settings.Privacy == "") {
+		return fmt.Errorf("internal server error")
+	}
+
+	return err
+}
+
+func getErrorResponse(c *gin.Context, settings ErrorsSettings, statusCode int, err error) Response {
+	if errorHandler := getScopedErrorHandler(c); errorHandler != nil {
+		return errorHandler(statusCode, err)
+	}
+
+	if settings.Format != ErrorFormatProblem {
+		return GetErrorHandler()(statusCode, err)
+	}
+
+	problem := GetProblem(statusCode, err)
+	if problem.Instance == "" {
+		problem.Instance = c.Request.URL.Path
+	}
+
+	return NewProblemResponse(problem)
+}
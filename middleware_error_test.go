@@ -79,6 +79,51 @@ func (s *errorMiddlewareTestSuite) TestStatusErrorTakesPrecedenceOverValidationE
 	s.JSONEq(`{"err":"validation: invalid input"}`, recorder.Body.String())
 }
 
+func (s *errorMiddlewareTestSuite) TestProblemFormatReturnsProblemDetails() {
+	err := httpserver.NewErrorWithStatus(http.StatusNotFound, errors.New("user 1 not found"))
+	recorder := s.serveErrorMiddlewareRequest(err, httpserver.ErrorMiddlewareWithSettings(httpserver.ErrorsSettings{
+		Format: httpserver.ErrorFormatProblem,
+	}))
+
+	s.Equal(http.StatusNotFound, recorder.Code)
+	s.Equal(httpserver.ContentTypeProblemJson, recorder.Header().Get(httpserver.HeaderContentType))
+	s.JSONEq(`{"type":"about:blank","title":"Not Found","status":404,"detail":"user 1 not found","instance":"/error"}`, recorder.Body.String())
+}
+
+func (s *errorMiddlewareTestSuite) TestProblemFormatKeepsProblemMembers() {
+	problem := &httpserver.Problem{
+		Type:   "https://example.com/problems/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+		Detail: "Your current balance is 30, but that costs 50.",
+	}
+	err := fmt.Errorf("purchase failed: %w", problem.WithExtension("balance", 30).WithExtension("status", "ignored"))
+
+	recorder := s.serveErrorMiddlewareRequest(err, httpserver.ErrorMiddlewareWithSettings(httpserver.ErrorsSettings{
+		Format: httpserver.ErrorFormatProblem,
+	}))
+
+	s.Equal(http.StatusForbidden, recorder.Code)
+	s.JSONEq(`{
+		"type":"https://example.com/problems/out-of-credit",
+		"title":"You do not have enough credit.",
+		"status":403,
+		"detail":"Your current balance is 30, but that costs 50.",
+		"instance":"/error",
+		"balance":30
+	}`, recorder.Body.String())
+}
+
+func (s *errorMiddlewareTestSuite) TestProblemFormatHidesInternalServerErrorDetails() {
+	recorder := s.serveErrorMiddlewareRequest(errors.New("super secret internal detail"), httpserver.ErrorMiddlewareWithSettings(httpserver.ErrorsSettings{
+		Privacy: httpserver.ErrorPrivacyPrivate,
+		Format:  httpserver.ErrorFormatProblem,
+	}))
+
+	s.Equal(http.StatusInternalServerError, recorder.Code)
+	s.JSONEq(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/error"}`, recorder.Body.String())
+}
+
 func (s *errorMiddlewareTestSuite) serveErrorMiddlewareRequest(err error, middleware gin.HandlerFunc) *httptest.ResponseRecorder {
 	s.T().Helper()
 
@@ -0,0 +1,205 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	"github.com/justtrackio/gosoline/pkg/log"
+)
+
+var (
+	// ErrIdempotencyKeyInFlight is reported with 409 if a request with the same idempotency key is still in flight.
+	ErrIdempotencyKeyInFlight = errors.New("a request with the idempotency key is in flight")
+	// ErrIdempotencyKeyReused is reported with 422 if an idempotency key is reused for a different request.
+	ErrIdempotencyKeyReused = errors.New("the idempotency key was used for a different request")
+)
+
+// IdempotencyGuard creates the idempotency middlewares of the routes of a server. The first response to a
+// request with an idempotency key is stored and replayed for retries of the request with the same key.
+type IdempotencyGuard struct {
+	logger   log.Logger
+	store    IdempotencyStore
+	settings IdempotencySettings
+}
+
+// NewIdempotencyGuard creates an idempotency guard with the store selected in the settings.
+func NewIdempotencyGuard(ctx context.Context, config cfg.Config, logger log.Logger, settings IdempotencySettings) (*IdempotencyGuard, error) {
+	var err error
+	var store IdempotencyStore
+
+	if store, err = newIdempotencyStore(ctx, config, logger, settings); err != nil {
+		return nil, fmt.Errorf("can not create idempotency store: %w", err)
+	}
+
+	return NewIdempotencyGuardWithInterfaces(logger, store, settings), nil
+}
+
+// NewIdempotencyGuardWithInterfaces creates an idempotency guard from already constructed dependencies.
+func NewIdempotencyGuardWithInterfaces(logger log.Logger, store IdempotencyStore, settings IdempotencySettings) *IdempotencyGuard {
+	return &IdempotencyGuard{
+		logger:   logger.WithChannel("idempotency"),
+		store:    store,
+		settings: settings,
+	}
+}
+
+// RouteMiddleware returns the idempotency middleware of the route with the given method and path. Keys are scoped to
+// the route. It returns nil for methods other than POST and PATCH.
+func (g *IdempotencyGuard) RouteMiddleware(method string, path string) gin.HandlerFunc {
+	if method != http.MethodPost && method != http.MethodPatch {
+		return nil
+	}
+
+	return g.middleware(fmt.Sprintf("%s %s", method, path))
+}
+
+func (g *IdempotencyGuard) middleware(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		var body []byte
+		var record *IdempotencyRecord
+
+		idempotencyKey := c.GetHeader(g.settings.Header)
+		if idempotencyKey == "" {
+			c.Next()
+
+			return
+		}
+
+		// uploads are streamed to the handler, only other bodies are read into memory, as their binders do it anyway
+		if !isMultipartRequest(c.Request) {
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				AbortWithError(c, newBindError(fmt.Errorf("can not read request body: %w", err)))
+
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		ctx := c.Request.Context()
+		key := route + "|" + idempotencyKey
+		fingerprint := getIdempotencyFingerprint(c.Request, body)
+
+		if record, err = g.store.Reserve(ctx, key, fingerprint, g.settings.InFlightTtl); err != nil {
+			g.logger.Warn(ctx, "can not reserve idempotency key, handling the request without it: %s", err)
+			c.Next()
+
+			return
+		}
+
+		switch {
+		case record == nil:
+		case record.Fingerprint != fingerprint:
+			AbortWithError(c, NewErrorWithStatus(http.StatusUnprocessableEntity, ErrIdempotencyKeyReused))
+
+			return
+		case !record.Completed:
+			AbortWithError(c, NewErrorWithStatus(http.StatusConflict, ErrIdempotencyKeyInFlight))
+
+			return
+		default:
+			replayIdempotentResponse(c, record)
+
+			return
+		}
+
+		g.handle(c, key, fingerprint)
+	}
+}
+
+// handle runs the handler of the request and stores its response for the key. The key is released if the response
+// can't be replayed, also if the handler panics, so the request can be retried.
+func (g *IdempotencyGuard) handle(c *gin.Context, key string, fingerprint string) {
+	var err error
+
+	handled := false
+	header := c.Writer.Header().Clone()
+	writer := newResponseRecorder(c.Writer, g.settings.MaxResponseBytes)
+
+	// the context of the request might be canceled already
+	ctx := context.WithoutCancel(c.Request.Context())
+
+	defer func() {
+		c.Writer = writer.ResponseWriter
+
+		if !handled || !isIdempotentResponseStorable(c, writer) {
+			if err = g.store.Release(ctx, key); err != nil {
+				g.logger.Warn(ctx, "can not release idempotency key: %s", err)
+			}
+
+			return
+		}
+
+		err = g.store.Complete(ctx, key, IdempotencyRecord{
+			Fingerprint: fingerprint,
+			StatusCode:  writer.Status(),
+			Header:      writer.getHandlerHeader(header),
+			Body:        writer.body.Bytes(),
+		}, g.settings.Ttl)
+		if err != nil {
+			g.logger.Warn(ctx, "can not store response of idempotency key: %s", err)
+		}
+	}()
+
+	c.Writer = writer
+	c.Next()
+	handled = true
+}
+
+// getIdempotencyFingerprint identifies a request by its method, URL, content type and body. Multipart requests are
+// identified without their body and boundary, which differs between retries.
+func getIdempotencyFingerprint(request *http.Request, body []byte) string {
+	hash := sha256.New()
+	contentType := request.Header.Get(HeaderContentType)
+
+	if isMultipartRequest(request) {
+		contentType = binding.MIMEMultipartPOSTForm
+	}
+
+	for _, part := range []string{request.Method, request.URL.RequestURI(), contentType} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func isMultipartRequest(request *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get(HeaderContentType))
+
+	return mediaType == binding.MIMEMultipartPOSTForm
+}
+
+func replayIdempotentResponse(c *gin.Context, record *IdempotencyRecord) {
+	for name, values := range record.Header {
+		c.Writer.Header()[name] = slices.Clone(values)
+	}
+	c.Header(HeaderIdempotentReplayed, "true")
+	c.Status(record.StatusCode)
+
+	if len(record.Body) == 0 {
+		c.Writer.WriteHeaderNow()
+	} else if _, err := c.Writer.Write(record.Body); err != nil {
+		_ = c.Error(fmt.Errorf("can not replay idempotent response: %w", err))
+	}
+
+	c.Abort()
+}
+
+// isIdempotentResponseStorable reports whether the response is complete and can be replayed. Failed requests and
+// responses of server errors are not stored, so they can be retried.
+func isIdempotentResponseStorable(c *gin.Context, recorder *responseRecorder) bool {
+	return recorder.isComplete(c) && recorder.Status() < http.StatusInternalServerError
+}
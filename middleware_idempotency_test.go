@@ -0,0 +1,259 @@
+package httpserver_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gosoline-project/httpserver"
+	httpserverMocks "github.com/gosoline-project/httpserver/mocks"
+	logMocks "github.com/justtrackio/gosoline/pkg/log/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type idempotencyTestInput struct {
+	Amount int `json:"amount"`
+}
+
+func newIdempotencyTestSettings() httpserver.IdempotencySettings {
+	return httpserver.IdempotencySettings{
+		Enabled:          true,
+		Header:           httpserver.HeaderIdempotencyKey,
+		Store:            httpserver.IdempotencyStoreInMemory,
+		Ttl:              time.Hour,
+		InFlightTtl:      time.Minute,
+		MaxResponseBytes: 1024,
+	}
+}
+
+func newIdempotencyTestRoute(t *testing.T, store httpserver.IdempotencyStore, handler gin.HandlerFunc) func(r *gin.Engine) {
+	logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+	guard := httpserver.NewIdempotencyGuardWithInterfaces(logger, store, newIdempotencyTestSettings())
+
+	return func(r *gin.Engine) {
+		r.POST("/payments", guard.RouteMiddleware(http.MethodPost, "/payments"), handler)
+	}
+}
+
+func newIdempotencyTestHandler(calls *atomic.Int32) gin.HandlerFunc {
+	return httpserver.Bind(func(ctx context.Context, input *idempotencyTestInput) (httpserver.Response, error) {
+		call := calls.Add(1)
+
+		if input.Amount < 0 {
+			return nil, fmt.Errorf("can not pay %d", input.Amount)
+		}
+
+		return httpserver.NewJsonResponse(map[string]int{"amount": input.Amount, "call": int(call)},
+			httpserver.WithStatusCode(http.StatusCreated),
+			httpserver.WithHeader("X-Payment-Id", fmt.Sprintf("payment-%d", call)),
+		), nil
+	}, binding.JSON)
+}
+
+func postIdempotencyTestPayment(router http.Handler, key string, body string) *httptest.ResponseRecorder {
+	header := map[string]string{httpserver.HeaderContentType: httpserver.ContentTypeApplicationJson}
+	if key != "" {
+		header[httpserver.HeaderIdempotencyKey] = key
+	}
+
+	return serveTestRequest(router, http.MethodPost, "/payments", strings.NewReader(body), header)
+}
+
+func TestIdempotencyCases(t *testing.T) {
+	type request struct {
+		key             string
+		body            string
+		expectStatus    int
+		expectBody      string
+		expectPaymentId string
+		expectReplayed  bool
+	}
+
+	cases := []struct {
+		name        string
+		handler     func(calls *atomic.Int32) gin.HandlerFunc
+		requests    []request
+		expectCalls int32
+	}{
+		{
+			name: "replays the first response",
+			requests: []request{
+				{key: "key-1", body: `{"amount":10}`, expectStatus: http.StatusCreated, expectBody: `{"amount":10,"call":1}`, expectPaymentId: "payment-1"},
+				{key: "key-1", body: `{"amount":10}`, expectStatus: http.StatusCreated, expectBody: `{"amount":10,"call":1}`, expectPaymentId: "payment-1", expectReplayed: true},
+				{key: "key-2", body: `{"amount":10}`, expectStatus: http.StatusCreated, expectBody: `{"amount":10,"call":2}`, expectPaymentId: "payment-2"},
+			},
+			expectCalls: 2,
+		},
+		{
+			name: "rejects reused keys",
+			requests: []request{
+				{key: "key-1", body: `{"amount":10}`, expectStatus: http.StatusCreated, expectBody: `{"amount":10,"call":1}`, expectPaymentId: "payment-1"},
+				{key: "key-1", body: `{"amount":20}`, expectStatus: http.StatusUnprocessableEntity, expectBody: `{"err":"the idempotency key was used for a different request"}`},
+			},
+			expectCalls: 1,
+		},
+		{
+			name: "does not store failures",
+			requests: []request{
+				{key: "key-1", body: `{"amount":-1}`, expectStatus: http.StatusInternalServerError, expectBody: `{"err":"internal server error"}`},
+				{key: "key-1", body: `{"amount":-1}`, expectStatus: http.StatusInternalServerError, expectBody: `{"err":"internal server error"}`},
+			},
+			expectCalls: 2,
+		},
+		{
+			name: "does not store large responses",
+			handler: func(calls *atomic.Int32) gin.HandlerFunc {
+				return func(c *gin.Context) {
+					calls.Add(1)
+					c.JSON(http.StatusOK, strings.Repeat("a", 2048))
+				}
+			},
+			requests: []request{
+				{key: "key-1", body: `{}`, expectStatus: http.StatusOK, expectBody: fmt.Sprintf("%q", strings.Repeat("a", 2048))},
+				{key: "key-1", body: `{}`, expectStatus: http.StatusOK, expectBody: fmt.Sprintf("%q", strings.Repeat("a", 2048))},
+			},
+			expectCalls: 2,
+		},
+		{
+			name: "without key",
+			requests: []request{
+				{body: `{"amount":10}`, expectStatus: http.StatusCreated, expectBody: `{"amount":10,"call":1}`, expectPaymentId: "payment-1"},
+				{body: `{"amount":10}`, expectStatus: http.StatusCreated, expectBody: `{"amount":10,"call":2}`, expectPaymentId: "payment-2"},
+			},
+			expectCalls: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := &atomic.Int32{}
+			handler := newIdempotencyTestHandler
+			if tc.handler != nil {
+				handler = tc.handler
+			}
+
+			router := newTestRouter(newIdempotencyTestRoute(t, httpserver.NewInMemoryIdempotencyStore(), handler(calls)))
+
+			for _, req := range tc.requests {
+				rec := postIdempotencyTestPayment(router, req.key, req.body)
+
+				assert.Equal(t, req.expectStatus, rec.Code)
+				assert.JSONEq(t, req.expectBody, rec.Body.String())
+				assert.Equal(t, req.expectPaymentId, rec.Header().Get("X-Payment-Id"))
+
+				if req.expectReplayed {
+					assert.Equal(t, "true", rec.Header().Get(httpserver.HeaderIdempotentReplayed))
+					assert.Equal(t, httpserver.ContentTypeApplicationJson+"; charset=utf-8", rec.Header().Get(httpserver.HeaderContentType))
+				} else {
+					assert.Empty(t, rec.Header().Get(httpserver.HeaderIdempotentReplayed))
+				}
+			}
+
+			assert.Equal(t, tc.expectCalls, calls.Load())
+		})
+	}
+}
+
+func TestIdempotencyRejectsRequestsInFlight(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	router := newTestRouter(newIdempotencyTestRoute(t, httpserver.NewInMemoryIdempotencyStore(), func(c *gin.Context) {
+		close(entered)
+		<-release
+
+		c.Status(http.StatusAccepted)
+	}))
+
+	first := make(chan int)
+	go func() {
+		first <- postIdempotencyTestPayment(router, "key-1", `{}`).Code
+	}()
+	<-entered
+
+	rec := postIdempotencyTestPayment(router, "key-1", `{}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.JSONEq(t, `{"err":"a request with the idempotency key is in flight"}`, rec.Body.String())
+
+	close(release)
+	assert.Equal(t, http.StatusAccepted, <-first)
+
+	rec = postIdempotencyTestPayment(router, "key-1", `{}`)
+	assert.Equal(t, http.StatusAccepted, rec.Code, "the bodyless response is replayed")
+	assert.Equal(t, "true", rec.Header().Get(httpserver.HeaderIdempotentReplayed))
+}
+
+func TestIdempotencyStoreErrors(t *testing.T) {
+	calls := &atomic.Int32{}
+	store := httpserverMocks.NewIdempotencyStore(t)
+	store.EXPECT().Reserve(mock.Anything, "POST /payments|key-1", mock.Anything, time.Minute).Return(nil, fmt.Errorf("store down")).Once()
+	router := newTestRouter(newIdempotencyTestRoute(t, store, newIdempotencyTestHandler(calls)))
+
+	rec := postIdempotencyTestPayment(router, "key-1", `{"amount":10}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code, "requests are handled without idempotency")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyRouteMiddleware(t *testing.T) {
+	logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+	guard := httpserver.NewIdempotencyGuardWithInterfaces(logger, httpserver.NewInMemoryIdempotencyStore(), newIdempotencyTestSettings())
+
+	require.NotNil(t, guard.RouteMiddleware(http.MethodPatch, "/payments/:id"))
+	assert.Nil(t, guard.RouteMiddleware(http.MethodGet, "/payments/:id"))
+	assert.Nil(t, guard.RouteMiddleware(http.MethodPut, "/payments/:id"))
+}
+
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	calls := &atomic.Int32{}
+	router := newTestRouter(newIdempotencyTestRoute(t, httpserver.NewInMemoryIdempotencyStore(), func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			panic("handler failed")
+		}
+
+		c.Status(http.StatusAccepted)
+	}))
+
+	assert.Panics(t, func() {
+		postIdempotencyTestPayment(router, "key-1", `{}`)
+	})
+
+	rec := postIdempotencyTestPayment(router, "key-1", `{}`)
+	assert.Equal(t, http.StatusAccepted, rec.Code, "the key is released after the panic")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyMultipartRequests(t *testing.T) {
+	calls := &atomic.Int32{}
+	router := newTestRouter(newIdempotencyTestRoute(t, httpserver.NewInMemoryIdempotencyStore(), func(c *gin.Context) {
+		file, err := c.FormFile("file")
+		require.NoError(t, err)
+
+		c.String(http.StatusCreated, "%s-%d", file.Filename, calls.Add(1))
+	}))
+
+	serve := func(boundary string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf("--%s\r\nContent-Disposition: form-data; name=\"file\"; filename=\"report.csv\"\r\n\r\na,b\r\n--%s--\r\n", boundary, boundary)
+
+		return serveTestRequest(router, http.MethodPost, "/payments", strings.NewReader(body), map[string]string{
+			httpserver.HeaderContentType:    "multipart/form-data; boundary=" + boundary,
+			httpserver.HeaderIdempotencyKey: "key-1",
+		})
+	}
+
+	first := serve("first")
+	retry := serve("retry")
+
+	assert.Equal(t, "report.csv-1", first.Body.String())
+	assert.Equal(t, "report.csv-1", retry.Body.String(), "retries with another boundary are replayed")
+	assert.Equal(t, "true", retry.Header().Get(httpserver.HeaderIdempotentReplayed))
+	assert.Equal(t, int32(1), calls.Load())
+}
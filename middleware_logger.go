@@ -85,7 +85,7 @@ func (lc *logCall) prepare(ginCtx *gin.Context) {
 	req := ginCtx.Request
 
 	lc.fields["bytes"] = ginCtx.Writer.Size()
-	lc.fields["client_ip"] = ginCtx.ClientIP()
+	lc.fields["client_ip"] = getLogClientIP(ginCtx)
 	lc.fields["host"] = req.Host
 	lc.fields["protocol"] = req.Proto
 	lc.fields["request_method"] = req.Method
@@ -210,3 +210,13 @@ func getPathRaw(ginCtx *gin.Context) string {
 
 	return path
 }
+
+// getLogClientIP returns the client IP resolved by ResolveClientIP. If the remote address of the request can't be
+// parsed, the client IP of Gin is used.
+func getLogClientIP(ginCtx *gin.Context) string {
+	if clientIP, err := ResolveClientIP(ginCtx.Request); err == nil {
+		return clientIP
+	}
+
+	return ginCtx.ClientIP()
+}
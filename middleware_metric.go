@@ -2,6 +2,7 @@ package httpserver
 
 import (
 	"fmt"
+	"net/http"
 	"slices"
 	"time"
 
@@ -20,6 +21,13 @@ const (
 	MetricHttpRequestResponseTime = "HttpRequestResponseTime"
 	// MetricHttpRequestsRejected is the rejected request count metric name.
 	MetricHttpRequestsRejected = "HttpRequestsRejected"
+	// MetricHttpRequestsTimedOut is the count metric name of requests which exceeded the handler timeout.
+	MetricHttpRequestsTimedOut = "HttpRequestsTimedOut"
+	// MetricHttpResponseCacheHit is the count metric name of requests answered from the response cache, including
+	// stale responses.
+	MetricHttpResponseCacheHit = "HttpResponseCacheHit"
+	// MetricHttpResponseCacheMiss is the count metric name of requests of cached routes handled by their handler.
+	MetricHttpResponseCacheMiss = "HttpResponseCacheMiss"
 	// MetricHttpStatus is the prefix for HTTP status class metric names.
 	MetricHttpStatus = "HttpStatus"
 )
@@ -97,29 +105,58 @@ func MetricMiddleware(name string, ginCtx *gin.Context, writer metric.Writer, me
 	}))
 
 	if WasRequestRejected(ginCtx.Request) {
-		writer.Write(ginCtx.Request.Context(), metric.Data{
-			{
+		writer.Write(ginCtx.Request.Context(), append(
+			createRequestCountMetrics(MetricHttpRequestsRejected, name, method, path),
+			// shows which priorities are shed under pressure
+			&metric.Datum{
 				Priority:   metric.PriorityHigh,
 				MetricName: MetricHttpRequestsRejected,
 				Unit:       metric.UnitCount,
 				Dimensions: map[string]string{
-					"Method":     method,
-					"Path":       path,
-					"ServerName": name,
+					"RequestPriority": GetRequestPriority(ginCtx),
+					"ServerName":      name,
 				},
 				Value: 1.0,
 			},
-			{
-				Priority:   metric.PriorityHigh,
-				MetricName: MetricHttpRequestsRejected,
-				Unit:       metric.UnitCount,
-				Dimensions: map[string]string{
-					"ServerName": name,
-				},
-				Value: 1.0,
-				Kind:  metric.KindTotal,
+		))
+	}
+
+	if WasRequestTimedOut(ginCtx.Request) {
+		writer.Write(ginCtx.Request.Context(), createRequestCountMetrics(MetricHttpRequestsTimedOut, name, method, path))
+	}
+
+	switch GetResponseCacheStatus(ginCtx.Request) {
+	case ResponseCacheHit, ResponseCacheStale:
+		writer.Write(ginCtx.Request.Context(), createRequestCountMetrics(MetricHttpResponseCacheHit, name, method, path))
+	case ResponseCacheMiss:
+		writer.Write(ginCtx.Request.Context(), createRequestCountMetrics(MetricHttpResponseCacheMiss, name, method, path))
+	}
+}
+
+// createRequestCountMetrics counts a request per route and in total for the given metric name.
+func createRequestCountMetrics(metricName string, name string, method string, path string) metric.Data {
+	return metric.Data{
+		{
+			Priority:   metric.PriorityHigh,
+			MetricName: metricName,
+			Unit:       metric.UnitCount,
+			Dimensions: map[string]string{
+				"Method":     method,
+				"Path":       path,
+				"ServerName": name,
+			},
+			Value: 1.0,
+		},
+		{
+			Priority:   metric.PriorityHigh,
+			MetricName: metricName,
+			Unit:       metric.UnitCount,
+			Dimensions: map[string]string{
+				"ServerName": name,
 			},
-		})
+			Value: 1.0,
+			Kind:  metric.KindTotal,
+		},
 	}
 }
 
@@ -143,6 +180,10 @@ func createMetricsWithDimensions(metrics metric.Data, dimensionsByMetricSuffix m
 
 // GetMetricMiddlewareDefaults returns the default metric data for registered HTTP routes.
 func GetMetricMiddlewareDefaults(name string, definitions ...Definition) metric.Data {
+	getDefinitions := funk.Filter(definitions, func(definition Definition) bool {
+		return definition.HttpMethod == http.MethodGet
+	})
+
 	return slices.Concat(
 		funk.Map(definitions, func(definition Definition) *metric.Datum {
 			return &metric.Datum{
@@ -157,39 +198,60 @@ func GetMetricMiddlewareDefaults(name string, definitions ...Definition) metric.
 				Value: 0.0,
 			}
 		}),
-		funk.Map(definitions, func(definition Definition) *metric.Datum {
+		getRequestCountMetricDefaults(MetricHttpRequestsRejected, name, definitions),
+		funk.Map([]string{RequestPriorityLow, RequestPriorityNormal, RequestPriorityHigh, RequestPriorityCritical}, func(priority string) *metric.Datum {
 			return &metric.Datum{
 				Priority:   metric.PriorityHigh,
 				MetricName: MetricHttpRequestsRejected,
 				Dimensions: metric.Dimensions{
-					"Method":     definition.HttpMethod,
-					"Path":       definition.getAbsolutePath(),
-					"ServerName": name,
+					"RequestPriority": priority,
+					"ServerName":      name,
 				},
 				Unit:  metric.UnitCount,
 				Value: 0.0,
 			}
 		}),
+		getRequestCountMetricDefaults(MetricHttpRequestsTimedOut, name, definitions),
+		getRequestCountMetricDefaults(MetricHttpResponseCacheHit, name, getDefinitions),
+		getRequestCountMetricDefaults(MetricHttpResponseCacheMiss, name, getDefinitions),
 		metric.Data{
 			{
 				Priority:   metric.PriorityHigh,
-				MetricName: MetricHttpRequestsRejected,
+				MetricName: MetricHttpRequestCount,
 				Dimensions: metric.Dimensions{
 					"ServerName": name,
 				},
 				Unit:  metric.UnitCount,
 				Value: 0.0,
-				Kind:  metric.KindTotal,
 			},
-			{
+		},
+	)
+}
+
+func getRequestCountMetricDefaults(metricName string, name string, definitions []Definition) metric.Data {
+	return append(
+		funk.Map(definitions, func(definition Definition) *metric.Datum {
+			return &metric.Datum{
 				Priority:   metric.PriorityHigh,
-				MetricName: MetricHttpRequestCount,
+				MetricName: metricName,
 				Dimensions: metric.Dimensions{
+					"Method":     definition.HttpMethod,
+					"Path":       definition.getAbsolutePath(),
 					"ServerName": name,
 				},
 				Unit:  metric.UnitCount,
 				Value: 0.0,
+			}
+		}),
+		&metric.Datum{
+			Priority:   metric.PriorityHigh,
+			MetricName: metricName,
+			Dimensions: metric.Dimensions{
+				"ServerName": name,
 			},
+			Unit:  metric.UnitCount,
+			Value: 0.0,
+			Kind:  metric.KindTotal,
 		},
 	)
 }
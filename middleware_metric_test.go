@@ -5,10 +5,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gosoline-project/httpserver"
 	httpserverMocks "github.com/gosoline-project/httpserver/mocks"
+	"github.com/justtrackio/gosoline/pkg/clock"
+	logMocks "github.com/justtrackio/gosoline/pkg/log/mocks"
 	"github.com/justtrackio/gosoline/pkg/metric"
 	metricMocks "github.com/justtrackio/gosoline/pkg/metric/mocks"
 	"github.com/justtrackio/gosoline/pkg/test/matcher"
@@ -32,6 +35,7 @@ func TestMetricMiddleware_WritesRejectedRequestMetrics(t *testing.T) {
 		httpserver.MetricMiddleware("api", c, writer, recorder)
 	})
 	router.Use(func(c *gin.Context) {
+		httpserver.SetRequestPriority(c, httpserver.RequestPriorityLow)
 		c.Request = httpserver.MarkRequestRejected(c.Request)
 		c.AbortWithStatus(http.StatusTooManyRequests)
 	})
@@ -46,15 +50,102 @@ func TestMetricMiddleware_WritesRejectedRequestMetrics(t *testing.T) {
 	require.Len(t, writes, 2)
 
 	rejectedMetrics := writes[1]
-	require.Len(t, rejectedMetrics, 2)
-	assertRejectedMetric(t, rejectedMetrics[0], metric.Dimensions{
+	require.Len(t, rejectedMetrics, 3)
+	assertRequestCountMetric(t, rejectedMetrics[0], httpserver.MetricHttpRequestsRejected, metric.Dimensions{
 		"Method":     http.MethodGet,
 		"Path":       "/widgets/:id",
 		"ServerName": "api",
 	}, metric.KindDefault)
-	assertRejectedMetric(t, rejectedMetrics[1], metric.Dimensions{
+	assertRequestCountMetric(t, rejectedMetrics[1], httpserver.MetricHttpRequestsRejected, metric.Dimensions{
 		"ServerName": "api",
 	}, metric.KindTotal)
+	assertRequestCountMetric(t, rejectedMetrics[2], httpserver.MetricHttpRequestsRejected, metric.Dimensions{
+		"RequestPriority": httpserver.RequestPriorityLow,
+		"ServerName":      "api",
+	}, metric.KindDefault)
+}
+
+func TestMetricMiddleware_WritesTimedOutRequestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	writes := make([]metric.Data, 0)
+	writer := metricMocks.NewWriter(t)
+	writer.EXPECT().Write(matcher.Context, mock.Anything).Run(func(_ context.Context, batch metric.Data) {
+		writes = append(writes, batch)
+	}).Return().Twice()
+	recorder := httpserverMocks.NewServerMetricRecorder(t)
+	recorder.EXPECT().TrackRequestStarted(matcher.Context).Return().Once()
+	recorder.EXPECT().TrackRequestCompleted(matcher.Context).Return().Once()
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(func(c *gin.Context) {
+		httpserver.MetricMiddleware("api", c, writer, recorder)
+	})
+	router.Use(httpserver.TimeoutMiddleware(time.Millisecond, http.StatusServiceUnavailable))
+	router.GET("/widgets/:id", func(c *gin.Context) {
+		<-c.Done()
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/widgets/42", http.NoBody)
+	response := httptest.NewRecorder()
+
+	router.ServeHTTP(response, request)
+
+	require.Len(t, writes, 2)
+
+	timedOutMetrics := writes[1]
+	require.Len(t, timedOutMetrics, 2)
+	assertRequestCountMetric(t, timedOutMetrics[0], httpserver.MetricHttpRequestsTimedOut, metric.Dimensions{
+		"Method":     http.MethodGet,
+		"Path":       "/widgets/:id",
+		"ServerName": "api",
+	}, metric.KindDefault)
+	assertRequestCountMetric(t, timedOutMetrics[1], httpserver.MetricHttpRequestsTimedOut, metric.Dimensions{
+		"ServerName": "api",
+	}, metric.KindTotal)
+}
+
+func TestMetricMiddleware_WritesResponseCacheMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	writes := make([]metric.Data, 0)
+	writer := metricMocks.NewWriter(t)
+	writer.EXPECT().Write(matcher.Context, mock.Anything).Run(func(_ context.Context, batch metric.Data) {
+		writes = append(writes, batch)
+	}).Return().Times(4)
+	recorder := httpserverMocks.NewServerMetricRecorder(t)
+	recorder.EXPECT().TrackRequestStarted(matcher.Context).Return().Twice()
+	recorder.EXPECT().TrackRequestCompleted(matcher.Context).Return().Twice()
+	logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+	responseCache := httpserver.NewResponseCacheWithInterfaces(logger, clock.NewFakeClock(), httpserver.NewInMemoryResponseCacheStore(10), nil, httpserver.ResponseCacheSettings{
+		MaxResponseBytes: 1024,
+		Routes:           []httpserver.ResponseCacheRouteSettings{{Path: "/widgets/:id"}},
+	})
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		httpserver.MetricMiddleware("api", c, writer, recorder)
+	})
+	router.GET("/widgets/:id", responseCache.RouteMiddleware(http.MethodGet, "/widgets/:id"), func(c *gin.Context) {
+		c.Header(httpserver.HeaderCacheControl, "max-age=60")
+		c.String(http.StatusOK, "widget")
+	})
+
+	for range 2 {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/widgets/42", http.NoBody))
+	}
+
+	require.Len(t, writes, 4)
+
+	for i, metricName := range []string{httpserver.MetricHttpResponseCacheMiss, httpserver.MetricHttpResponseCacheHit} {
+		cacheMetrics := writes[1+2*i]
+		require.Len(t, cacheMetrics, 2)
+		assertRequestCountMetric(t, cacheMetrics[0], metricName, metric.Dimensions{
+			"Method":     http.MethodGet,
+			"Path":       "/widgets/:id",
+			"ServerName": "api",
+		}, metric.KindDefault)
+		assertRequestCountMetric(t, cacheMetrics[1], metricName, metric.Dimensions{
+			"ServerName": "api",
+		}, metric.KindTotal)
+	}
 }
 
 func TestGetMetricMiddlewareDefaults_IncludesRejectedRequestMetrics(t *testing.T) {
@@ -66,7 +157,7 @@ func TestGetMetricMiddlewareDefaults_IncludesRejectedRequestMetrics(t *testing.T
 
 	defaults := httpserver.GetMetricMiddlewareDefaults("api", definition)
 
-	require.Len(t, defaults, 4)
+	require.Len(t, defaults, 14)
 	assertDefaultMetric(t, defaults[0], httpserver.MetricHttpRequestCountPerRoute, metric.Dimensions{
 		"Method":     http.MethodGet,
 		"Path":       "/widgets/:id",
@@ -80,16 +171,44 @@ func TestGetMetricMiddlewareDefaults_IncludesRejectedRequestMetrics(t *testing.T
 	assertDefaultMetric(t, defaults[2], httpserver.MetricHttpRequestsRejected, metric.Dimensions{
 		"ServerName": "api",
 	}, metric.KindTotal)
-	assertDefaultMetric(t, defaults[3], httpserver.MetricHttpRequestCount, metric.Dimensions{
+	for i, priority := range []string{"low", "normal", "high", "critical"} {
+		assertDefaultMetric(t, defaults[3+i], httpserver.MetricHttpRequestsRejected, metric.Dimensions{
+			"RequestPriority": priority,
+			"ServerName":      "api",
+		}, metric.KindDefault)
+	}
+	assertDefaultMetric(t, defaults[7], httpserver.MetricHttpRequestsTimedOut, metric.Dimensions{
+		"Method":     http.MethodGet,
+		"Path":       "/widgets/:id",
 		"ServerName": "api",
 	}, metric.KindDefault)
+	assertDefaultMetric(t, defaults[8], httpserver.MetricHttpRequestsTimedOut, metric.Dimensions{
+		"ServerName": "api",
+	}, metric.KindTotal)
+	for i, metricName := range []string{httpserver.MetricHttpResponseCacheHit, httpserver.MetricHttpResponseCacheMiss} {
+		assertDefaultMetric(t, defaults[9+2*i], metricName, metric.Dimensions{
+			"Method":     http.MethodGet,
+			"Path":       "/widgets/:id",
+			"ServerName": "api",
+		}, metric.KindDefault)
+		assertDefaultMetric(t, defaults[10+2*i], metricName, metric.Dimensions{
+			"ServerName": "api",
+		}, metric.KindTotal)
+	}
+	assertDefaultMetric(t, defaults[13], httpserver.MetricHttpRequestCount, metric.Dimensions{
+		"ServerName": "api",
+	}, metric.KindDefault)
+
+	definition.HttpMethod = http.MethodPost
+	defaults = httpserver.GetMetricMiddlewareDefaults("api", definition)
+	assert.Len(t, defaults, 12, "only GET routes have response cache metrics")
 }
 
-func assertRejectedMetric(t *testing.T, datum *metric.Datum, dimensions metric.Dimensions, kind metric.Kind) {
+func assertRequestCountMetric(t *testing.T, datum *metric.Datum, metricName string, dimensions metric.Dimensions, kind metric.Kind) {
 	t.Helper()
 
 	assert.Equal(t, metric.PriorityHigh, datum.Priority)
-	assert.Equal(t, httpserver.MetricHttpRequestsRejected, datum.MetricName)
+	assert.Equal(t, metricName, datum.MetricName)
 	assert.Equal(t, metric.UnitCount, datum.Unit)
 	assert.Equal(t, dimensions, datum.Dimensions)
 	assert.Equal(t, 1.0, datum.Value)
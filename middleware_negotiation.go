@@ -0,0 +1,26 @@
+package httpserver
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const negotiationDefaultContentType = "goso.negotiation.defaultContentType"
+
+// NegotiationMiddleware makes the configured default content type available to negotiated responses.
+func NegotiationMiddleware(settings NegotiationSettings) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if settings.DefaultContentType != "" {
+			c.Set(negotiationDefaultContentType, settings.DefaultContentType)
+		}
+
+		c.Next()
+	}
+}
+
+func getNegotiationDefaultContentType(ginCtx *gin.Context) string {
+	if contentType := ginCtx.GetString(negotiationDefaultContentType); contentType != "" {
+		return contentType
+	}
+
+	return ContentTypeApplicationJson
+}
@@ -0,0 +1,95 @@
+package httpserver_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/stretchr/testify/assert"
+)
+
+type negotiationOutput struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func TestNegotiatedResponseCases(t *testing.T) {
+	cases := []struct {
+		name                string
+		defaultContentType  string
+		accept              string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "configured default",
+			defaultContentType:  "application/xml",
+			expectedCode:        http.StatusCreated,
+			expectedContentType: httpserver.ContentTypeXml,
+			expectedBody:        `<negotiationOutput><name>alice</name></negotiationOutput>`,
+		},
+		{
+			name:                "accepted json",
+			defaultContentType:  "application/xml",
+			accept:              "application/xml;q=0.1, application/json",
+			expectedCode:        http.StatusCreated,
+			expectedContentType: httpserver.ContentTypeJson,
+			expectedBody:        `{"name":"alice"}`,
+		},
+		{
+			name:                "not acceptable",
+			accept:              "text/html",
+			expectedCode:        http.StatusNotAcceptable,
+			expectedContentType: httpserver.ContentTypeJson,
+			expectedBody:        `{"err":"response error: none of the accepted media types can be produced"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			r := gin.New()
+			r.Use(httpserver.ErrorMiddleware())
+			r.Use(httpserver.NegotiationMiddleware(httpserver.NegotiationSettings{DefaultContentType: tc.defaultContentType}))
+			r.GET("/", httpserver.BindN(func(ctx context.Context) (httpserver.Response, error) {
+				return httpserver.NewNegotiatedResponse(negotiationOutput{Name: "alice"}, httpserver.WithStatusCode(http.StatusCreated)), nil
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				req.Header.Set(httpserver.HeaderAccept, tc.accept)
+			}
+			recorder := httptest.NewRecorder()
+
+			r.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code)
+			assert.Equal(t, tc.expectedContentType, recorder.Header().Get(httpserver.HeaderContentType))
+			assert.Equal(t, httpserver.HeaderAccept, recorder.Header().Get(httpserver.HeaderVary))
+			assert.Equal(t, tc.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestNegotiatedResponseMergesVary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(httpserver.ErrorMiddleware())
+	r.Use(httpserver.NegotiationMiddleware(httpserver.NegotiationSettings{}))
+	r.GET("/", httpserver.BindN(func(ctx context.Context) (httpserver.Response, error) {
+		return httpserver.NewNegotiatedResponse(negotiationOutput{Name: "alice"}, httpserver.WithHeaders(http.Header{
+			httpserver.HeaderVary: {"authorization, accept", "X-Tenant"},
+		})), nil
+	}))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []string{httpserver.HeaderAccept, httpserver.HeaderAuthorization, "X-Tenant"}, recorder.Header().Values(httpserver.HeaderVary))
+}
@@ -0,0 +1,193 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	"github.com/justtrackio/gosoline/pkg/log"
+)
+
+const (
+	// RateLimitKeyIp assigns requests to buckets by the client IP.
+	RateLimitKeyIp = "ip"
+	// RateLimitKeyApiKey assigns requests to buckets by the api key header.
+	RateLimitKeyApiKey = "api_key"
+)
+
+// ErrRateLimitExceeded is reported if a request is rejected because its bucket is empty.
+var ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+type (
+	// RateLimitKeyFunc returns the key of the bucket a request is assigned to. Requests with an empty key are not limited.
+	RateLimitKeyFunc func(ginCtx *gin.Context, settings RateLimitSettings) (string, error)
+
+	// RateLimiter creates the rate limit middlewares of the routes of a server.
+	RateLimiter struct {
+		logger   log.Logger
+		store    RateLimitStore
+		keyFunc  RateLimitKeyFunc
+		settings RateLimitSettings
+	}
+)
+
+var (
+	rateLimitKeyFuncsLck sync.RWMutex
+	rateLimitKeyFuncs    = map[string]RateLimitKeyFunc{
+		RateLimitKeyIp:     ClientIpRateLimitKey,
+		RateLimitKeyApiKey: ApiKeyRateLimitKey,
+	}
+)
+
+// AddRateLimitKeyFunc makes a key function available to the rate limit settings of all servers under the given name.
+func AddRateLimitKeyFunc(name string, keyFunc RateLimitKeyFunc) {
+	rateLimitKeyFuncsLck.Lock()
+	defer rateLimitKeyFuncsLck.Unlock()
+
+	rateLimitKeyFuncs[name] = keyFunc
+}
+
+func getRateLimitKeyFunc(name string) (RateLimitKeyFunc, error) {
+	rateLimitKeyFuncsLck.RLock()
+	defer rateLimitKeyFuncsLck.RUnlock()
+
+	keyFunc, ok := rateLimitKeyFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("there is no rate limit key function with the name %q", name)
+	}
+
+	return keyFunc, nil
+}
+
+// ClientIpRateLimitKey returns the client IP resolved by ResolveClientIP as rate limit key.
+func ClientIpRateLimitKey(ginCtx *gin.Context, _ RateLimitSettings) (string, error) {
+	return ResolveClientIP(ginCtx.Request)
+}
+
+// ApiKeyRateLimitKey returns the api key header configured in the settings as rate limit key.
+func ApiKeyRateLimitKey(ginCtx *gin.Context, settings RateLimitSettings) (string, error) {
+	return ginCtx.GetHeader(settings.ApiKeyHeader), nil
+}
+
+// NewRateLimiter creates a rate limiter with the store and key function selected in the settings.
+func NewRateLimiter(ctx context.Context, config cfg.Config, logger log.Logger, settings RateLimitSettings) (*RateLimiter, error) {
+	var err error
+	var store RateLimitStore
+	var keyFunc RateLimitKeyFunc
+
+	if store, err = newRateLimitStore(ctx, config, logger, settings); err != nil {
+		return nil, fmt.Errorf("can not create rate limit store: %w", err)
+	}
+
+	if keyFunc, err = getRateLimitKeyFunc(settings.Key); err != nil {
+		return nil, err
+	}
+
+	return NewRateLimiterWithInterfaces(logger, store, keyFunc, settings), nil
+}
+
+// NewRateLimiterWithInterfaces creates a rate limiter from already constructed dependencies.
+func NewRateLimiterWithInterfaces(logger log.Logger, store RateLimitStore, keyFunc RateLimitKeyFunc, settings RateLimitSettings) *RateLimiter {
+	return &RateLimiter{
+		logger:   logger.WithChannel("rate_limit"),
+		store:    store,
+		keyFunc:  keyFunc,
+		settings: settings,
+	}
+}
+
+// RouteMiddleware returns the middleware limiting the route with the given method and path. Routes without an
+// override share the buckets of the server-wide limit. It returns nil if the route is not limited.
+func (l *RateLimiter) RouteMiddleware(method string, path string) gin.HandlerFunc {
+	limit := RateLimit{
+		Rate:   l.settings.Rate,
+		Period: l.settings.Period,
+		Burst:  l.settings.Burst,
+	}
+	bucket := ""
+
+	for _, route := range l.settings.Routes {
+		if route.Path != path || (route.Method != "" && !strings.EqualFold(route.Method, method)) {
+			continue
+		}
+
+		limit = RateLimit{
+			Rate:   route.Rate,
+			Period: route.Period,
+			Burst:  route.Burst,
+		}
+		bucket = fmt.Sprintf("%s %s", strings.ToUpper(route.Method), path)
+
+		if limit.Period <= 0 {
+			limit.Period = l.settings.Period
+		}
+
+		break
+	}
+
+	if limit.Rate <= 0 || limit.Period <= 0 {
+		return nil
+	}
+
+	return l.middleware(bucket, limit)
+}
+
+func (l *RateLimiter) middleware(bucket string, limit RateLimit) gin.HandlerFunc {
+	capacity := strconv.Itoa(limit.Capacity())
+
+	return func(c *gin.Context) {
+		var err error
+		var key string
+		var result RateLimitResult
+
+		if key, err = l.keyFunc(c, l.settings); err != nil {
+			l.logger.Warn(c.Request.Context(), "can not get rate limit key, not limiting the request: %s", err)
+			c.Next()
+
+			return
+		}
+
+		if key == "" {
+			c.Next()
+
+			return
+		}
+
+		if result, err = l.store.Take(c.Request.Context(), bucket+"|"+key, limit); err != nil {
+			l.logger.Warn(c.Request.Context(), "can not take rate limit token, not limiting the request: %s", err)
+			c.Next()
+
+			return
+		}
+
+		c.Header(HeaderXRatelimitLimit, capacity)
+		c.Header(HeaderXRatelimitRemaining, strconv.Itoa(result.Remaining))
+		c.Header(HeaderXRatelimitReset, strconv.FormatInt(ceilSeconds(result.Reset), 10))
+
+		if result.Allowed {
+			c.Next()
+
+			return
+		}
+
+		c.Request = MarkRequestRejected(c.Request)
+		writeRetryAfterHeader(c, max(result.RetryAfter, time.Nanosecond))
+		AbortWithError(c, NewErrorWithStatus(http.StatusTooManyRequests, ErrRateLimitExceeded))
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	seconds := int64(d / time.Second)
+	if d%time.Second != 0 {
+		seconds++
+	}
+
+	return seconds
+}
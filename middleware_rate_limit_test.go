@@ -0,0 +1,159 @@
+package httpserver_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/gosoline-project/httpserver/mocks"
+	"github.com/justtrackio/gosoline/pkg/clock"
+	logMocks "github.com/justtrackio/gosoline/pkg/log/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MiddlewareRateLimitTestSuite struct {
+	suite.Suite
+
+	clock    clock.FakeClock
+	settings httpserver.RateLimitSettings
+	store    httpserver.RateLimitStore
+	rejected bool
+}
+
+func TestMiddlewareRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(MiddlewareRateLimitTestSuite))
+}
+
+func (s *MiddlewareRateLimitTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+
+	s.clock = clock.NewFakeClock()
+	s.store = httpserver.NewInMemoryRateLimitStoreWithInterfaces(s.clock)
+	s.settings = httpserver.RateLimitSettings{
+		Enabled:      true,
+		Key:          httpserver.RateLimitKeyIp,
+		ApiKeyHeader: "X-API-KEY",
+		Rate:         2,
+		Period:       time.Minute,
+	}
+	s.rejected = false
+}
+
+func (s *MiddlewareRateLimitTestSuite) newRouter(keyFunc httpserver.RateLimitKeyFunc) *gin.Engine {
+	logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(s.T()))
+	limiter := httpserver.NewRateLimiterWithInterfaces(logger, s.store, keyFunc, s.settings)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		s.rejected = httpserver.WasRequestRejected(c.Request)
+	})
+	router.Use(httpserver.ErrorMiddleware())
+
+	for _, path := range []string{"/a", "/b", "/c"} {
+		handlers := []gin.HandlerFunc{func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		}}
+
+		if middleware := limiter.RouteMiddleware(http.MethodGet, path); middleware != nil {
+			handlers = append([]gin.HandlerFunc{middleware}, handlers...)
+		}
+
+		router.GET(path, handlers...)
+	}
+
+	return router
+}
+
+func (s *MiddlewareRateLimitTestSuite) request(router *gin.Engine, path string, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+	req.RemoteAddr = remoteAddr
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+func (s *MiddlewareRateLimitTestSuite) TestLimitsByClientIp() {
+	router := s.newRouter(httpserver.ClientIpRateLimitKey)
+
+	w := s.request(router, "/a", "192.0.2.1:1234")
+	s.Equal(http.StatusNoContent, w.Code)
+	s.Equal("2", w.Header().Get(httpserver.HeaderXRatelimitLimit))
+	s.Equal("1", w.Header().Get(httpserver.HeaderXRatelimitRemaining))
+	s.Equal("30", w.Header().Get(httpserver.HeaderXRatelimitReset))
+
+	w = s.request(router, "/b", "192.0.2.1:1234")
+	s.Equal(http.StatusNoContent, w.Code, "routes without override share the bucket")
+	s.Equal("0", w.Header().Get(httpserver.HeaderXRatelimitRemaining))
+
+	w = s.request(router, "/a", "192.0.2.1:1234")
+	s.Equal(http.StatusTooManyRequests, w.Code)
+	s.Equal("30", w.Header().Get(httpserver.HeaderRetryAfter))
+	s.Equal("60", w.Header().Get(httpserver.HeaderXRatelimitReset))
+	s.JSONEq(`{"err":"rate limit exceeded"}`, w.Body.String())
+	s.True(s.rejected)
+
+	w = s.request(router, "/a", "192.0.2.2:1234")
+	s.Equal(http.StatusNoContent, w.Code)
+	s.False(s.rejected)
+
+	s.clock.Advance(30 * time.Second)
+
+	w = s.request(router, "/a", "192.0.2.1:1234")
+	s.Equal(http.StatusNoContent, w.Code)
+}
+
+func (s *MiddlewareRateLimitTestSuite) TestRouteOverrides() {
+	s.settings.Routes = []httpserver.RateLimitRouteSettings{
+		{Method: http.MethodGet, Path: "/b", Rate: 1, Burst: 1},
+		{Path: "/c", Rate: 0},
+	}
+	router := s.newRouter(httpserver.ClientIpRateLimitKey)
+
+	w := s.request(router, "/b", "192.0.2.1:1234")
+	s.Equal(http.StatusNoContent, w.Code)
+	s.Equal("1", w.Header().Get(httpserver.HeaderXRatelimitLimit))
+
+	w = s.request(router, "/b", "192.0.2.1:1234")
+	s.Equal(http.StatusTooManyRequests, w.Code)
+	s.Equal("60", w.Header().Get(httpserver.HeaderRetryAfter))
+
+	w = s.request(router, "/a", "192.0.2.1:1234")
+	s.Equal(http.StatusNoContent, w.Code, "overridden routes use their own buckets")
+	s.Equal("1", w.Header().Get(httpserver.HeaderXRatelimitRemaining))
+
+	for range 5 {
+		w = s.request(router, "/c", "192.0.2.1:1234")
+		s.Equal(http.StatusNoContent, w.Code)
+		s.Empty(w.Header().Get(httpserver.HeaderXRatelimitLimit))
+	}
+}
+
+func (s *MiddlewareRateLimitTestSuite) TestEmptyKeyIsNotLimited() {
+	s.settings.Rate = 1
+	router := s.newRouter(httpserver.ApiKeyRateLimitKey)
+
+	for range 3 {
+		w := s.request(router, "/a", "192.0.2.1:1234")
+		s.Equal(http.StatusNoContent, w.Code)
+	}
+}
+
+func (s *MiddlewareRateLimitTestSuite) TestStoreErrorFailsOpen() {
+	store := mocks.NewRateLimitStore(s.T())
+	store.EXPECT().Take(mock.Anything, "|192.0.2.1", mock.Anything).Return(httpserver.RateLimitResult{}, errors.New("unavailable"))
+	s.store = store
+
+	router := s.newRouter(httpserver.ClientIpRateLimitKey)
+
+	w := s.request(router, "/a", "192.0.2.1:1234")
+	s.Equal(http.StatusNoContent, w.Code)
+	s.Empty(w.Header().Get(httpserver.HeaderXRatelimitLimit))
+}
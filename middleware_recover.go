@@ -2,6 +2,7 @@ package httpserver
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -9,7 +10,7 @@ import (
 	"github.com/justtrackio/gosoline/pkg/log"
 )
 
-// RecoveryWithSentry recovers panics, logs them, and returns a 500 JSON response.
+// RecoveryWithSentry recovers panics, logs them, and reports a 500 error to the error middleware.
 func RecoveryWithSentry(logger log.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
@@ -32,11 +33,11 @@ func RecoveryWithSentry(logger log.Logger) gin.HandlerFunc {
 			case string:
 				rerr = errors.New(rval)
 			default:
-				c.AbortWithStatus(http.StatusInternalServerError)
+				rerr = fmt.Errorf("%v", rval)
 			}
 
 			logger.Error(ctx, "%w", rerr)
-			c.JSON(http.StatusInternalServerError, gin.H{"err": rerr.Error()})
+			AbortWithError(c, NewErrorWithStatus(http.StatusInternalServerError, rerr))
 		}()
 
 		c.Next()
@@ -137,3 +137,31 @@ func TestRecoveryWithSentryCaseString(t *testing.T) {
 	loggerMock.AssertNumberOfCalls(t, "Warn", 0)
 	loggerMock.AssertNumberOfCalls(t, "Error", 1)
 }
+
+func TestRecoveryWithSentryReportsErrorToErrorMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	loggerMock := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+
+	r := gin.New()
+	r.Use(httpserver.ErrorMiddlewareWithSettings(httpserver.ErrorsSettings{
+		Privacy: httpserver.ErrorPrivacyPublic,
+		Format:  httpserver.ErrorFormatProblem,
+	}))
+	r.Use(httpserver.RecoveryWithSentry(loggerMock))
+	r.Use(func(_ *gin.Context) {
+		panic(42)
+	})
+
+	httpRecorder := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/some/route", http.NoBody)
+	assert.NoError(t, err)
+
+	assert.NotPanics(t, func() {
+		r.ServeHTTP(httpRecorder, req)
+	})
+	assert.Equal(t, http.StatusInternalServerError, httpRecorder.Code)
+	assert.Equal(t, httpserver.ContentTypeProblemJson, httpRecorder.Header().Get(httpserver.HeaderContentType))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"42","instance":"/some/route"}`, httpRecorder.Body.String())
+	loggerMock.AssertNumberOfCalls(t, "Error", 1)
+}
@@ -0,0 +1,582 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	"github.com/justtrackio/gosoline/pkg/clock"
+	"github.com/justtrackio/gosoline/pkg/log"
+)
+
+const (
+	// ResponseCacheKeyQuery derives the cache key from the query parameters of a request, independent of their order.
+	ResponseCacheKeyQuery = "query"
+
+	// ResponseCacheHit is the cache status of a request answered with a fresh cached response.
+	ResponseCacheHit = "hit"
+	// ResponseCacheStale is the cache status of a request answered with a stale cached response while it is
+	// revalidated in the background.
+	ResponseCacheStale = "stale"
+	// ResponseCacheMiss is the cache status of a request handled by its handler, as there was no usable response.
+	ResponseCacheMiss = "miss"
+)
+
+// cacheableStatusCodes are the status codes of responses which can be stored by a shared cache.
+var cacheableStatusCodes = []int{
+	http.StatusOK,
+	http.StatusNonAuthoritativeInfo,
+	http.StatusNoContent,
+	http.StatusMultipleChoices,
+	http.StatusMovedPermanently,
+	http.StatusPermanentRedirect,
+	http.StatusNotFound,
+	http.StatusMethodNotAllowed,
+	http.StatusGone,
+	http.StatusRequestURITooLong,
+}
+
+type (
+	// ResponseCacheKeyFunc returns a part of the cache key of a request.
+	ResponseCacheKeyFunc func(ginCtx *gin.Context) (string, error)
+
+	// ResponseCache creates the response cache middlewares of the routes of a server. Concurrent requests missing
+	// the cache for the same key wait for the first one, so the handler runs only once.
+	ResponseCache struct {
+		logger   log.Logger
+		clock    clock.Clock
+		store    ResponseCacheStore
+		keyFuncs map[string]ResponseCacheKeyFunc
+		settings ResponseCacheSettings
+		lck      sync.Mutex
+		fills    map[string]chan struct{}
+	}
+
+	// cacheControl holds the directives of a Cache-Control header by their lower case name.
+	cacheControl map[string]string
+
+	responseCacheStatusKey struct{}
+)
+
+var (
+	responseCacheKeyFuncsLck sync.RWMutex
+	responseCacheKeyFuncs    = map[string]ResponseCacheKeyFunc{
+		ResponseCacheKeyQuery: QueryResponseCacheKey,
+	}
+	// privateResponseCacheKeys are the names of the key functions identifying the caller of a request.
+	privateResponseCacheKeys = map[string]bool{}
+)
+
+// credentialHeaders are the request headers which always carry credentials, besides the ones of the settings.
+var credentialHeaders = []string{HeaderAuthorization, HeaderCookie}
+
+// AddResponseCacheKeyFunc makes a key function available to the response cache settings of all servers under the given name.
+func AddResponseCacheKeyFunc(name string, keyFunc ResponseCacheKeyFunc) {
+	responseCacheKeyFuncsLck.Lock()
+	defer responseCacheKeyFuncsLck.Unlock()
+
+	responseCacheKeyFuncs[name] = keyFunc
+}
+
+// AddPrivateResponseCacheKeyFunc makes a key function identifying the caller of a request, like the subject provided
+// by the auth package, available under the given name. Routes whose key includes it cache the responses per caller, so
+// private responses and responses to requests with credentials are cached, too.
+func AddPrivateResponseCacheKeyFunc(name string, keyFunc ResponseCacheKeyFunc) {
+	responseCacheKeyFuncsLck.Lock()
+	defer responseCacheKeyFuncsLck.Unlock()
+
+	responseCacheKeyFuncs[name] = keyFunc
+	privateResponseCacheKeys[name] = true
+}
+
+func isPrivateResponseCacheKey(name string) bool {
+	responseCacheKeyFuncsLck.RLock()
+	defer responseCacheKeyFuncsLck.RUnlock()
+
+	return privateResponseCacheKeys[name]
+}
+
+func getResponseCacheKeyFunc(name string) (ResponseCacheKeyFunc, error) {
+	responseCacheKeyFuncsLck.RLock()
+	defer responseCacheKeyFuncsLck.RUnlock()
+
+	keyFunc, ok := responseCacheKeyFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("there is no response cache key function with the name %q", name)
+	}
+
+	return keyFunc, nil
+}
+
+// QueryResponseCacheKey returns the query parameters of a request sorted by name as cache key.
+func QueryResponseCacheKey(ginCtx *gin.Context) (string, error) {
+	return ginCtx.Request.URL.Query().Encode(), nil
+}
+
+// GetResponseCacheStatus returns whether a request was answered from the response cache, see ResponseCacheHit,
+// ResponseCacheStale and ResponseCacheMiss. It returns an empty string for requests of routes without cache.
+func GetResponseCacheStatus(request *http.Request) string {
+	status, _ := request.Context().Value(responseCacheStatusKey{}).(string)
+
+	return status
+}
+
+func markResponseCacheStatus(request *http.Request, status string) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), responseCacheStatusKey{}, status))
+}
+
+// NewResponseCache creates a response cache with the store and key functions selected in the settings.
+func NewResponseCache(ctx context.Context, config cfg.Config, logger log.Logger, settings ResponseCacheSettings) (*ResponseCache, error) {
+	var err error
+	var store ResponseCacheStore
+	var keyFunc ResponseCacheKeyFunc
+
+	if store, err = newResponseCacheStore(ctx, config, logger, settings); err != nil {
+		return nil, fmt.Errorf("can not create response cache store: %w", err)
+	}
+
+	keyFuncs := make(map[string]ResponseCacheKeyFunc)
+	names := slices.Clone(settings.Key)
+
+	for _, route := range settings.Routes {
+		names = append(names, route.Key...)
+	}
+
+	for _, name := range names {
+		if keyFunc, err = getResponseCacheKeyFunc(name); err != nil {
+			return nil, err
+		}
+
+		keyFuncs[name] = keyFunc
+	}
+
+	return NewResponseCacheWithInterfaces(logger, clock.Provider, store, keyFuncs, settings), nil
+}
+
+// NewResponseCacheWithInterfaces creates a response cache from already constructed dependencies. The key functions
+// are looked up by the names used in the settings.
+func NewResponseCacheWithInterfaces(
+	logger log.Logger,
+	clock clock.Clock,
+	store ResponseCacheStore,
+	keyFuncs map[string]ResponseCacheKeyFunc,
+	settings ResponseCacheSettings,
+) *ResponseCache {
+	return &ResponseCache{
+		logger:   logger.WithChannel("response_cache"),
+		clock:    clock,
+		store:    store,
+		keyFuncs: keyFuncs,
+		settings: settings,
+		fills:    make(map[string]chan struct{}),
+	}
+}
+
+// RouteMiddleware returns the response cache middleware of the route with the given method and path. It returns nil
+// if the route isn't a GET route listed in the settings.
+func (rc *ResponseCache) RouteMiddleware(method string, path string) gin.HandlerFunc {
+	if method != http.MethodGet {
+		return nil
+	}
+
+	for _, route := range rc.settings.Routes {
+		if route.Path != path {
+			continue
+		}
+
+		keys, headers := rc.settings.Key, rc.settings.Headers
+
+		if len(route.Key) > 0 {
+			keys = route.Key
+		}
+
+		if len(route.Headers) > 0 {
+			headers = route.Headers
+		}
+
+		private := false
+		keyFuncs := make([]ResponseCacheKeyFunc, 0, len(keys))
+
+		for _, key := range keys {
+			if keyFunc, ok := rc.keyFuncs[key]; ok {
+				keyFuncs = append(keyFuncs, keyFunc)
+				private = private || isPrivateResponseCacheKey(key)
+			}
+		}
+
+		return rc.middleware(fmt.Sprintf("%s %s", method, path), keyFuncs, headers, private)
+	}
+
+	return nil
+}
+
+// middleware caches the responses of a route. Responses of private routes are cached per caller, requests with
+// credentials are handled without cache on other routes, as their responses might depend on the caller.
+func (rc *ResponseCache) middleware(route string, keyFuncs []ResponseCacheKeyFunc, headers []string, private bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		directives := parseCacheControl(c.GetHeader(HeaderCacheControl))
+
+		if directives.has("no-store") || !private && rc.hasCredentials(c.Request) {
+			c.Next()
+
+			return
+		}
+
+		key, err := getResponseCacheKey(c, route, keyFuncs, headers)
+		if err != nil {
+			rc.logger.Warn(c.Request.Context(), "can not get response cache key, handling the request without cache: %s", err)
+			c.Next()
+
+			return
+		}
+
+		// clients can ask for a response which isn't served from the cache
+		if maxAge, ok := directives.seconds("max-age"); !directives.has("no-cache") && (!ok || maxAge > 0) {
+			if rc.serveCached(c, key, private) {
+				return
+			}
+		}
+
+		fill, isFirst := rc.startFill(key)
+
+		if !isFirst {
+			select {
+			case <-fill:
+			case <-c.Request.Context().Done():
+			}
+
+			if rc.serveCached(c, key, private) {
+				return
+			}
+		} else {
+			defer rc.finishFill(key, fill)
+		}
+
+		rc.handle(c, key, private)
+	}
+}
+
+func (rc *ResponseCache) hasCredentials(request *http.Request) bool {
+	for _, name := range slices.Concat(credentialHeaders, rc.settings.CredentialHeaders) {
+		if request.Header.Get(name) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serveCached answers the request with the response stored for key, unless there is none or it is expired. Stale
+// responses are revalidated in the background.
+func (rc *ResponseCache) serveCached(c *gin.Context, key string, private bool) bool {
+	response, err := rc.lookup(c.Request.Context(), c.Request, key)
+	if err != nil {
+		rc.logger.Warn(c.Request.Context(), "can not get cached response, handling the request without cache: %s", err)
+
+		return false
+	}
+
+	if response == nil {
+		return false
+	}
+
+	now := rc.clock.Now()
+	status := ResponseCacheHit
+
+	switch {
+	case now.Before(response.Expires):
+	case now.Before(response.StaleUntil):
+		status = ResponseCacheStale
+		rc.revalidate(c, key, private)
+	default:
+		return false
+	}
+
+	rc.replay(c, response, status)
+
+	return true
+}
+
+func (rc *ResponseCache) lookup(ctx context.Context, request *http.Request, key string) (*CachedResponse, error) {
+	response, err := rc.store.Get(ctx, key)
+	if err != nil || response == nil || len(response.Vary) == 0 {
+		return response, err
+	}
+
+	return rc.store.Get(ctx, getResponseCacheVariantKey(key, request, response.Vary))
+}
+
+func (rc *ResponseCache) replay(c *gin.Context, response *CachedResponse, status string) {
+	for name, values := range response.Header {
+		c.Writer.Header()[name] = slices.Clone(values)
+	}
+	c.Header(HeaderAge, strconv.FormatInt(int64(rc.clock.Since(response.StoredAt)/time.Second), 10))
+	c.Header(HeaderXCache, strings.ToUpper(status))
+	c.Request = markResponseCacheStatus(c.Request, status)
+
+	switch evaluateResponsePreconditions(c.Request, response.StatusCode, c.Writer.Header()) {
+	case http.StatusPreconditionFailed:
+		AbortWithError(c, NewErrorWithStatus(http.StatusPreconditionFailed, ErrPreconditionFailed))
+
+		return
+	case http.StatusNotModified:
+		deleteRepresentationHeaders(c.Writer.Header())
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		c.Abort()
+
+		return
+	}
+
+	c.Status(response.StatusCode)
+
+	if len(response.Body) == 0 {
+		c.Writer.WriteHeaderNow()
+	} else if _, err := c.Writer.Write(response.Body); err != nil {
+		_ = c.Error(fmt.Errorf("can not write cached response: %w", err))
+	}
+
+	c.Abort()
+}
+
+// handle runs the handler of the request and stores its response if it is cacheable.
+func (rc *ResponseCache) handle(c *gin.Context, key string, private bool) {
+	header := c.Writer.Header().Clone()
+	recorder := newResponseRecorder(c.Writer, rc.settings.MaxResponseBytes)
+
+	c.Header(HeaderXCache, strings.ToUpper(ResponseCacheMiss))
+	c.Request = markResponseCacheStatus(c.Request, ResponseCacheMiss)
+
+	c.Writer = recorder
+	c.Next()
+	c.Writer = recorder.ResponseWriter
+
+	if !recorder.isComplete(c) {
+		return
+	}
+
+	// the context of the request might be canceled already
+	rc.storeResponse(context.WithoutCancel(c.Request.Context()), c.Request, key, private, recorder.Status(), recorder.getHandlerHeader(header), recorder.body.Bytes())
+}
+
+// revalidate runs the handler of the request in the background to replace the stale response of key. The handler
+// runs without the middlewares of the route and only once per key at a time.
+func (rc *ResponseCache) revalidate(c *gin.Context, key string, private bool) {
+	fill, isFirst := rc.startFill(key)
+	if !isFirst {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), rc.settings.RevalidationTimeout)
+
+	request := c.Request.Clone(ctx)
+	for _, name := range []string{HeaderIfMatch, HeaderIfNoneMatch, HeaderIfModifiedSince, HeaderIfUnmodifiedSince, HeaderCacheControl} {
+		request.Header.Del(name)
+	}
+
+	handler := c.Handler()
+	recorder := newResponseRecorder(newBufferResponseWriter(), rc.settings.MaxResponseBytes)
+
+	revalidationCtx := c.Copy()
+	revalidationCtx.Request = request
+	revalidationCtx.Writer = recorder
+
+	go func() {
+		defer rc.finishFill(key, fill)
+		defer cancel()
+		defer func() {
+			if err := recover(); err != nil {
+				rc.logger.Error(ctx, "panic while revalidating cached response: %v", err)
+			}
+		}()
+
+		handler(revalidationCtx)
+
+		if len(revalidationCtx.Errors) > 0 {
+			rc.logger.Warn(ctx, "can not revalidate cached response: %s", revalidationCtx.Errors.String())
+
+			return
+		}
+
+		if recorder.isComplete(revalidationCtx) {
+			rc.storeResponse(ctx, request, key, private, recorder.Status(), recorder.getHandlerHeader(http.Header{}), recorder.body.Bytes())
+		}
+	}()
+}
+
+// storeResponse stores the response for key if its status code and Cache-Control header allow a shared cache to
+// store it. Private responses are only stored for private routes, which cache them per caller.
+func (rc *ResponseCache) storeResponse(ctx context.Context, request *http.Request, key string, private bool, statusCode int, header http.Header, body []byte) {
+	if !slices.Contains(cacheableStatusCodes, statusCode) || len(header.Values(HeaderSetCookie)) > 0 {
+		return
+	}
+
+	directives := parseCacheControl(header.Get(HeaderCacheControl))
+
+	if directives.has("no-store") || directives.has("no-cache") || directives.has("private") && !private {
+		return
+	}
+
+	maxAge, ok := directives.seconds("s-maxage")
+	if !ok {
+		maxAge, ok = directives.seconds("max-age")
+	}
+
+	vary := parseVary(header)
+
+	if !ok || maxAge <= 0 || slices.Contains(vary, "*") {
+		return
+	}
+
+	staleWhileRevalidate, _ := directives.seconds("stale-while-revalidate")
+	if directives.has("must-revalidate") || directives.has("proxy-revalidate") {
+		staleWhileRevalidate = 0
+	}
+
+	now := rc.clock.Now()
+	ttl := maxAge + staleWhileRevalidate
+	response := CachedResponse{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       slices.Clone(body),
+		StoredAt:   now,
+		Expires:    now.Add(maxAge),
+		StaleUntil: now.Add(ttl),
+	}
+
+	if len(vary) > 0 {
+		index := CachedResponse{
+			Vary:       vary,
+			StoredAt:   now,
+			Expires:    response.Expires,
+			StaleUntil: response.StaleUntil,
+		}
+
+		if err := rc.store.Set(ctx, key, index, ttl); err != nil {
+			rc.logger.Warn(ctx, "can not store vary headers of cached response: %s", err)
+
+			return
+		}
+
+		key = getResponseCacheVariantKey(key, request, vary)
+	}
+
+	if err := rc.store.Set(ctx, key, response, ttl); err != nil {
+		rc.logger.Warn(ctx, "can not store cached response: %s", err)
+	}
+}
+
+func (rc *ResponseCache) startFill(key string) (chan struct{}, bool) {
+	rc.lck.Lock()
+	defer rc.lck.Unlock()
+
+	if fill, ok := rc.fills[key]; ok {
+		return fill, false
+	}
+
+	fill := make(chan struct{})
+	rc.fills[key] = fill
+
+	return fill, true
+}
+
+func (rc *ResponseCache) finishFill(key string, fill chan struct{}) {
+	rc.lck.Lock()
+	delete(rc.fills, key)
+	rc.lck.Unlock()
+
+	close(fill)
+}
+
+// getResponseCacheKey derives the cache key of a request from its route, path, key functions and headers. The parts
+// are hashed, so the key doesn't expose them to the store.
+func getResponseCacheKey(c *gin.Context, route string, keyFuncs []ResponseCacheKeyFunc, headers []string) (string, error) {
+	hash := sha256.New()
+	writeResponseCacheKeyPart(hash, c.Request.URL.EscapedPath())
+
+	for _, keyFunc := range keyFuncs {
+		part, err := keyFunc(c)
+		if err != nil {
+			return "", err
+		}
+
+		writeResponseCacheKeyPart(hash, part)
+	}
+
+	for _, header := range headers {
+		writeResponseCacheKeyPart(hash, strings.Join(c.Request.Header.Values(header), ","))
+	}
+
+	return route + "|" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// getResponseCacheVariantKey derives the key of the variant of a response matching the headers it varies by.
+func getResponseCacheVariantKey(key string, request *http.Request, vary []string) string {
+	hash := sha256.New()
+
+	for _, header := range vary {
+		writeResponseCacheKeyPart(hash, header)
+		writeResponseCacheKeyPart(hash, strings.Join(request.Header.Values(header), ","))
+	}
+
+	return key + "|" + hex.EncodeToString(hash.Sum(nil))
+}
+
+func writeResponseCacheKeyPart(hash hash.Hash, part string) {
+	hash.Write([]byte(part))
+	hash.Write([]byte{0})
+}
+
+// parseVary returns the canonical names of the request headers listed in the Vary headers of a response.
+func parseVary(header http.Header) []string {
+	vary := make([]string, 0)
+
+	for _, value := range header.Values(HeaderVary) {
+		for name := range strings.SplitSeq(value, ",") {
+			if name = Normalize(strings.TrimSpace(name)); name != "" && !slices.Contains(vary, name) {
+				vary = append(vary, name)
+			}
+		}
+	}
+
+	return vary
+}
+
+func parseCacheControl(value string) cacheControl {
+	directives := cacheControl{}
+
+	for directive := range strings.SplitSeq(value, ",") {
+		name, argument, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			directives[name] = strings.Trim(strings.TrimSpace(argument), `"`)
+		}
+	}
+
+	return directives
+}
+
+func (c cacheControl) has(name string) bool {
+	_, ok := c[name]
+
+	return ok
+}
+
+// seconds returns the duration of a directive with a delta-seconds argument like max-age.
+func (c cacheControl) seconds(name string) (time.Duration, bool) {
+	seconds, err := strconv.ParseInt(c[name], 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
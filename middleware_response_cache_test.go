@@ -0,0 +1,309 @@
+package httpserver_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	httpserverMocks "github.com/gosoline-project/httpserver/mocks"
+	"github.com/justtrackio/gosoline/pkg/clock"
+	logMocks "github.com/justtrackio/gosoline/pkg/log/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newResponseCacheTestSettings() httpserver.ResponseCacheSettings {
+	return httpserver.ResponseCacheSettings{
+		Enabled:             true,
+		Store:               httpserver.ResponseCacheStoreInMemory,
+		MaxEntries:          100,
+		MaxResponseBytes:    1024,
+		Key:                 []string{httpserver.ResponseCacheKeyQuery},
+		CredentialHeaders:   []string{"X-API-KEY"},
+		RevalidationTimeout: time.Second,
+		Routes: []httpserver.ResponseCacheRouteSettings{
+			{Path: "/reports/:id"},
+		},
+	}
+}
+
+func newResponseCacheTestRoute(t *testing.T, clk clock.Clock, store httpserver.ResponseCacheStore, settings httpserver.ResponseCacheSettings, handler gin.HandlerFunc) func(r *gin.Engine) {
+	logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+	keyFuncs := map[string]httpserver.ResponseCacheKeyFunc{
+		httpserver.ResponseCacheKeyQuery: httpserver.QueryResponseCacheKey,
+		"caller":                         responseCacheTestCallerKey,
+	}
+	responseCache := httpserver.NewResponseCacheWithInterfaces(logger, clk, store, keyFuncs, settings)
+
+	return func(r *gin.Engine) {
+		r.GET("/reports/:id", responseCache.RouteMiddleware(http.MethodGet, "/reports/:id"), handler)
+	}
+}
+
+// responseCacheTestCallerKey identifies the caller of a request by its credentials.
+func responseCacheTestCallerKey(ginCtx *gin.Context) (string, error) {
+	return ginCtx.GetHeader(httpserver.HeaderAuthorization) + ginCtx.GetHeader(httpserver.HeaderCookie) + ginCtx.GetHeader("X-API-KEY"), nil
+}
+
+// newResponseCacheTestHandler responds with the number of calls, so cached responses can be told apart.
+func newResponseCacheTestHandler(calls *atomic.Int32, cacheControl string) gin.HandlerFunc {
+	return httpserver.BindNR(func(ctx context.Context, req *http.Request) (httpserver.Response, error) {
+		call := calls.Add(1)
+
+		return httpserver.NewTextResponse(fmt.Sprintf("report %d", call),
+			httpserver.WithHeader(httpserver.HeaderCacheControl, cacheControl),
+			httpserver.WithETag(fmt.Sprintf("v%d", call)),
+		), nil
+	})
+}
+
+func TestResponseCacheServesFreshResponses(t *testing.T) {
+	calls := &atomic.Int32{}
+	clk := clock.NewFakeClock()
+	router := newTestRouter(newResponseCacheTestRoute(t, clk, httpserver.NewInMemoryResponseCacheStore(100), newResponseCacheTestSettings(), newResponseCacheTestHandler(calls, "public, max-age=60")))
+
+	first := serveTestRequest(router, http.MethodGet, "/reports/1?a=1&b=2", nil, nil)
+	assert.Equal(t, "report 1", first.Body.String())
+	assert.Equal(t, "MISS", first.Header().Get(httpserver.HeaderXCache))
+
+	clk.Advance(5 * time.Second)
+
+	hit := serveTestRequest(router, http.MethodGet, "/reports/1?b=2&a=1", nil, nil)
+	assert.Equal(t, http.StatusOK, hit.Code)
+	assert.Equal(t, "report 1", hit.Body.String(), "the order of query parameters doesn't matter")
+	assert.Equal(t, "HIT", hit.Header().Get(httpserver.HeaderXCache))
+	assert.Equal(t, "5", hit.Header().Get(httpserver.HeaderAge))
+	assert.Equal(t, httpserver.ContentTypeTextPlain, hit.Header().Get(httpserver.HeaderContentType))
+	assert.Equal(t, `"v1"`, hit.Header().Get(httpserver.HeaderETag))
+
+	other := serveTestRequest(router, http.MethodGet, "/reports/1?a=2", nil, nil)
+	assert.Equal(t, "report 2", other.Body.String())
+
+	notModified := serveTestRequest(router, http.MethodGet, "/reports/1?a=1&b=2", nil, map[string]string{httpserver.HeaderIfNoneMatch: `"v1"`})
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+
+	bypass := serveTestRequest(router, http.MethodGet, "/reports/1?a=1&b=2", nil, map[string]string{httpserver.HeaderCacheControl: "no-cache"})
+	assert.Equal(t, "report 3", bypass.Body.String(), "clients can skip the cache")
+
+	refreshed := serveTestRequest(router, http.MethodGet, "/reports/1?a=1&b=2", nil, nil)
+	assert.Equal(t, "report 3", refreshed.Body.String(), "the bypassing request refreshed the cache")
+
+	clk.Advance(time.Minute)
+
+	expired := serveTestRequest(router, http.MethodGet, "/reports/1?a=1&b=2", nil, nil)
+	assert.Equal(t, "report 4", expired.Body.String())
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestResponseCacheHonoursCacheControl(t *testing.T) {
+	for _, cacheControl := range []string{"", "max-age=0", "private, max-age=60", "no-store", "no-cache, max-age=60"} {
+		t.Run(cacheControl, func(t *testing.T) {
+			calls := &atomic.Int32{}
+			router := newTestRouter(newResponseCacheTestRoute(t, clock.NewFakeClock(), httpserver.NewInMemoryResponseCacheStore(100), newResponseCacheTestSettings(), newResponseCacheTestHandler(calls, cacheControl)))
+
+			serveTestRequest(router, http.MethodGet, "/reports/1", nil, nil)
+			rec := serveTestRequest(router, http.MethodGet, "/reports/1", nil, nil)
+
+			assert.Equal(t, "report 2", rec.Body.String())
+			assert.Equal(t, "MISS", rec.Header().Get(httpserver.HeaderXCache))
+		})
+	}
+}
+
+func TestResponseCacheRequestsWithCredentials(t *testing.T) {
+	httpserver.AddPrivateResponseCacheKeyFunc("caller", responseCacheTestCallerKey)
+
+	cases := []struct {
+		name         string
+		key          []string
+		cacheControl string
+		header       map[string]string
+		expectBody   string
+	}{
+		{
+			name:         "authorization is not cached",
+			cacheControl: "public, max-age=60",
+			header:       map[string]string{httpserver.HeaderAuthorization: "Bearer token"},
+			expectBody:   "report 2",
+		},
+		{
+			name:         "cookie is not cached",
+			cacheControl: "public, max-age=60",
+			header:       map[string]string{httpserver.HeaderCookie: "session=1"},
+			expectBody:   "report 2",
+		},
+		{
+			name:         "api key is not cached",
+			cacheControl: "s-maxage=60",
+			header:       map[string]string{"X-API-KEY": "key"},
+			expectBody:   "report 2",
+		},
+		{
+			name:         "private response of private route",
+			key:          []string{"caller"},
+			cacheControl: "private, max-age=60",
+			header:       map[string]string{httpserver.HeaderAuthorization: "Bearer token"},
+			expectBody:   "report 1",
+		},
+		{
+			name:         "api key of private route",
+			key:          []string{"caller"},
+			cacheControl: "max-age=60",
+			header:       map[string]string{"X-API-KEY": "key"},
+			expectBody:   "report 1",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := &atomic.Int32{}
+			settings := newResponseCacheTestSettings()
+			settings.Routes[0].Key = tc.key
+
+			router := newTestRouter(newResponseCacheTestRoute(t, clock.NewFakeClock(), httpserver.NewInMemoryResponseCacheStore(100), settings, newResponseCacheTestHandler(calls, tc.cacheControl)))
+
+			serveTestRequest(router, http.MethodGet, "/reports/1", nil, tc.header)
+			rec := serveTestRequest(router, http.MethodGet, "/reports/1", nil, tc.header)
+
+			assert.Equal(t, tc.expectBody, rec.Body.String())
+		})
+	}
+}
+
+func TestResponseCachePrivateRouteSeparatesCallers(t *testing.T) {
+	httpserver.AddPrivateResponseCacheKeyFunc("caller", responseCacheTestCallerKey)
+
+	calls := &atomic.Int32{}
+	settings := newResponseCacheTestSettings()
+	settings.Routes[0].Key = []string{"caller"}
+
+	router := newTestRouter(newResponseCacheTestRoute(t, clock.NewFakeClock(), httpserver.NewInMemoryResponseCacheStore(100), settings, newResponseCacheTestHandler(calls, "private, max-age=60")))
+
+	serveTestRequest(router, http.MethodGet, "/reports/1", nil, map[string]string{httpserver.HeaderAuthorization: "Bearer a"})
+	rec := serveTestRequest(router, http.MethodGet, "/reports/1", nil, map[string]string{httpserver.HeaderAuthorization: "Bearer b"})
+
+	assert.Equal(t, "report 2", rec.Body.String())
+}
+
+func TestResponseCacheKeyHeadersAndVary(t *testing.T) {
+	calls := &atomic.Int32{}
+	settings := newResponseCacheTestSettings()
+	settings.Routes[0].Headers = []string{"X-Tenant"}
+	router := newTestRouter(newResponseCacheTestRoute(t, clock.NewFakeClock(), httpserver.NewInMemoryResponseCacheStore(100), settings, func(c *gin.Context) {
+		call := calls.Add(1)
+
+		c.Header(httpserver.HeaderCacheControl, "max-age=60")
+		c.Header(httpserver.HeaderVary, httpserver.HeaderAcceptLanguage)
+		c.String(http.StatusOK, "%s %s %d", c.GetHeader("X-Tenant"), c.GetHeader(httpserver.HeaderAcceptLanguage), call)
+	}))
+
+	for _, step := range []struct {
+		tenant     string
+		language   string
+		expectBody string
+	}{
+		{tenant: "a", language: "en", expectBody: "a en 1"},
+		{tenant: "a", language: "de", expectBody: "a de 2"},
+		{tenant: "b", language: "en", expectBody: "b en 3"},
+		{tenant: "a", language: "en", expectBody: "a en 1"},
+		{tenant: "a", language: "de", expectBody: "a de 2"},
+	} {
+		rec := serveTestRequest(router, http.MethodGet, "/reports/1", nil, map[string]string{
+			"X-Tenant":                      step.tenant,
+			httpserver.HeaderAcceptLanguage: step.language,
+		})
+
+		assert.Equal(t, step.expectBody, rec.Body.String())
+	}
+}
+
+func TestResponseCacheRevalidatesStaleResponses(t *testing.T) {
+	calls := &atomic.Int32{}
+	clk := clock.NewFakeClock()
+	router := newTestRouter(newResponseCacheTestRoute(t, clk, httpserver.NewInMemoryResponseCacheStore(100), newResponseCacheTestSettings(), newResponseCacheTestHandler(calls, "max-age=10, stale-while-revalidate=60")))
+
+	serveTestRequest(router, http.MethodGet, "/reports/1", nil, nil)
+	clk.Advance(20 * time.Second)
+
+	stale := serveTestRequest(router, http.MethodGet, "/reports/1", nil, nil)
+	assert.Equal(t, "report 1", stale.Body.String())
+	assert.Equal(t, "STALE", stale.Header().Get(httpserver.HeaderXCache))
+
+	assert.Eventually(t, func() bool {
+		rec := serveTestRequest(router, http.MethodGet, "/reports/1", nil, nil)
+
+		return rec.Body.String() == "report 2" && rec.Header().Get(httpserver.HeaderXCache) == "HIT"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestResponseCacheCoalescesConcurrentMisses(t *testing.T) {
+	calls := &atomic.Int32{}
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := newResponseCacheTestHandler(calls, "max-age=60")
+	store := httpserver.NewInMemoryResponseCacheStore(100)
+	router := newTestRouter(newResponseCacheTestRoute(t, clock.NewFakeClock(), store, newResponseCacheTestSettings(), func(c *gin.Context) {
+		close(entered)
+		<-release
+
+		handler(c)
+	}))
+
+	wg := &sync.WaitGroup{}
+	bodies := make(chan string, 5)
+	serve := func() {
+		defer wg.Done()
+		bodies <- serveTestRequest(router, http.MethodGet, "/reports/1", nil, nil).Body.String()
+	}
+
+	wg.Add(1)
+	go serve()
+	<-entered
+
+	for range 4 {
+		wg.Add(1)
+		go serve()
+	}
+
+	// the waiting requests can't be observed, so give them time to join the first one
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(bodies)
+
+	for body := range bodies {
+		assert.Equal(t, "report 1", body)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestResponseCacheStoreErrors(t *testing.T) {
+	calls := &atomic.Int32{}
+	store := httpserverMocks.NewResponseCacheStore(t)
+	store.EXPECT().Get(mock.Anything, mock.Anything).Return(nil, fmt.Errorf("store down")).Once()
+	store.EXPECT().Set(mock.Anything, mock.Anything, mock.Anything, time.Minute).Return(fmt.Errorf("store down")).Once()
+	router := newTestRouter(newResponseCacheTestRoute(t, clock.NewFakeClock(), store, newResponseCacheTestSettings(), newResponseCacheTestHandler(calls, "max-age=60")))
+
+	rec := serveTestRequest(router, http.MethodGet, "/reports/1", nil, nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code, "requests are handled without cache")
+	assert.Equal(t, "report 1", rec.Body.String())
+}
+
+func TestResponseCacheRouteMiddleware(t *testing.T) {
+	logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+	responseCache := httpserver.NewResponseCacheWithInterfaces(logger, clock.NewFakeClock(), httpserver.NewInMemoryResponseCacheStore(100), nil, newResponseCacheTestSettings())
+
+	require.NotNil(t, responseCache.RouteMiddleware(http.MethodGet, "/reports/:id"))
+	assert.Nil(t, responseCache.RouteMiddleware(http.MethodGet, "/reports"))
+	assert.Nil(t, responseCache.RouteMiddleware(http.MethodPost, "/reports/:id"))
+}
@@ -0,0 +1,26 @@
+package httpserver
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const sseSettingsKey = "goso.sse.settings"
+
+// SseMiddleware makes the SSE settings of the server available to the BindSse family of functions.
+func SseMiddleware(settings SseSettings) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(sseSettingsKey, settings)
+
+		c.Next()
+	}
+}
+
+func getSseSettings(ginCtx *gin.Context) SseSettings {
+	if settings, ok := ginCtx.Value(sseSettingsKey).(SseSettings); ok {
+		return settings
+	}
+
+	return SseSettings{
+		HeartbeatInterval: DefaultSseHeartbeatInterval,
+	}
+}
@@ -0,0 +1,343 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	"github.com/justtrackio/gosoline/pkg/log"
+)
+
+// StaticServeImmutableCacheControl is sent for fingerprinted files, whose content never changes under the same name.
+const StaticServeImmutableCacheControl = "public, max-age=31536000, immutable"
+
+// ErrStaticFileNotFound is returned with status 404 for paths which don't match a file.
+var ErrStaticFileNotFound = errors.New("file not found")
+
+// staticServePrecompressedEncodings lists the encodings of precompressed files by preference and the extension of
+// their files.
+var staticServePrecompressedEncodings = []struct {
+	encoding  string
+	extension string
+}{
+	{encoding: HeaderValueBrotli, extension: ".br"},
+	{encoding: HeaderValueGzip, extension: ".gz"},
+}
+
+type (
+	// StaticServeOption configures how files are served by NewStaticServeHandler.
+	StaticServeOption func(settings *staticServeSettings)
+
+	staticServeSettings struct {
+		index         string
+		spaFallback   string
+		maxAge        time.Duration
+		fingerprinted *regexp.Regexp
+		precompressed bool
+		excludes      []string
+	}
+
+	staticServe struct {
+		files    fs.FS
+		settings staticServeSettings
+		etags    sync.Map
+	}
+
+	staticServeETag struct {
+		size    int64
+		modTime time.Time
+		tag     string
+	}
+)
+
+// WithStaticServeIndex sets the file served for requests of a directory, index.html by default. An empty name
+// disables directory indexes, so requests of directories are answered with 404.
+func WithStaticServeIndex(name string) StaticServeOption {
+	return func(settings *staticServeSettings) {
+		settings.index = name
+	}
+}
+
+// WithStaticServeSpaFallback serves the given file for paths without file extension which don't match a file, so
+// single page applications can route them on the client.
+func WithStaticServeSpaFallback(name string) StaticServeOption {
+	return func(settings *staticServeSettings) {
+		settings.spaFallback = strings.TrimPrefix(name, "/")
+	}
+}
+
+// WithStaticServeMaxAge allows clients to cache files for the given duration without revalidating them. By default,
+// clients have to revalidate files before using them again.
+func WithStaticServeMaxAge(maxAge time.Duration) StaticServeOption {
+	return func(settings *staticServeSettings) {
+		settings.maxAge = maxAge
+	}
+}
+
+// WithStaticServeFingerprinted marks files whose name matches pattern as fingerprinted. Their name changes with their
+// content, so clients can cache them for a year without revalidating them.
+func WithStaticServeFingerprinted(pattern *regexp.Regexp) StaticServeOption {
+	return func(settings *staticServeSettings) {
+		settings.fingerprinted = pattern
+	}
+}
+
+// WithStaticServePrecompressed serves the .br or .gz sibling of a file instead of the file itself if the client
+// accepts the encoding.
+func WithStaticServePrecompressed() StaticServeOption {
+	return func(settings *staticServeSettings) {
+		settings.precompressed = true
+	}
+}
+
+// WithStaticServeExcludes passes requests with one of the path prefixes on to the following handlers.
+func WithStaticServeExcludes(prefixes ...string) StaticServeOption {
+	return func(settings *staticServeSettings) {
+		settings.excludes = append(settings.excludes, prefixes...)
+	}
+}
+
+// CreateStaticServe creates middleware serving the files of an fs.FS, like an embed.FS or os.DirFS, for GET and
+// HEAD requests which aren't excluded. See NewStaticServeHandler.
+func CreateStaticServe(files fs.FS, options ...StaticServeOption) MiddlewareFactory {
+	return func(_ context.Context, _ cfg.Config, _ log.Logger, _ *Settings) (gin.HandlerFunc, error) {
+		return NewStaticServeHandler(files, options...), nil
+	}
+}
+
+// CreateEmbeddedStaticServe creates middleware that serves files from a directory of an embedded filesystem. Paths
+// without file extension which don't match a file are answered with the index.html of the directory.
+//
+// Deprecated: use CreateStaticServe with WithStaticServeSpaFallback instead.
+func CreateEmbeddedStaticServe(files embed.FS, dir string, excludes ...string) MiddlewareFactory {
+	return func(ctx context.Context, config cfg.Config, logger log.Logger, settings *Settings) (gin.HandlerFunc, error) {
+		dist, err := fs.Sub(files, dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sub %q directory: %w", dir, err)
+		}
+
+		return CreateStaticServe(dist, WithStaticServeSpaFallback("index.html"), WithStaticServeExcludes(excludes...))(ctx, config, logger, settings)
+	}
+}
+
+// NewStaticServeHandler creates a handler serving the files of an fs.FS with ETag and Last-Modified headers, so
+// clients can revalidate them, and support for Range requests. The handler can be used as middleware or for a route
+// with a catch-all parameter named filepath, like /assets/*filepath. Paths which don't match a file are answered with
+// ErrStaticFileNotFound through the error middleware.
+func NewStaticServeHandler(files fs.FS, options ...StaticServeOption) gin.HandlerFunc {
+	serve := &staticServe{
+		files: files,
+		settings: staticServeSettings{
+			index: "index.html",
+		},
+	}
+
+	for _, option := range options {
+		option(&serve.settings)
+	}
+
+	return serve.handle
+}
+
+func (s *staticServe) handle(ginCtx *gin.Context) {
+	if ginCtx.Request.Method != http.MethodGet && ginCtx.Request.Method != http.MethodHead {
+		return
+	}
+
+	for _, exclude := range s.settings.excludes {
+		if strings.HasPrefix(ginCtx.Request.URL.Path, exclude) {
+			return
+		}
+	}
+
+	requestPath := ginCtx.Param("filepath")
+	if requestPath == "" {
+		requestPath = ginCtx.Request.URL.Path
+	}
+
+	if err := s.serve(ginCtx, requestPath); err != nil {
+		AbortWithError(ginCtx, err)
+
+		return
+	}
+
+	ginCtx.Abort()
+}
+
+func (s *staticServe) serve(ginCtx *gin.Context, requestPath string) error {
+	name, info, err := s.resolve(requestPath)
+	if err != nil {
+		return err
+	}
+
+	options := []ResponseOption{
+		WithHeader(HeaderCacheControl, s.getCacheControl(name)),
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		options = append(options, WithHeader(HeaderContentType, contentType))
+	}
+
+	if s.settings.precompressed {
+		var encoding string
+
+		if name, info, encoding, err = s.negotiatePrecompressed(ginCtx, name, info); err != nil {
+			return err
+		}
+
+		if encoding != "" {
+			options = append(options, WithHeader(HeaderContentEncoding, encoding))
+		}
+	}
+
+	tag, err := s.getETag(name, info)
+	if err != nil {
+		return err
+	}
+
+	file, err := s.files.Open(name)
+	if err != nil {
+		return fmt.Errorf("can not open file %q: %w", name, err)
+	}
+
+	options = append(options, WithETag(tag))
+
+	return BindHandleResponse(NewFileResponse(file, options...), ginCtx)
+}
+
+// resolve returns the name of the file to serve for the request path, applying the directory index and the SPA fallback.
+func (s *staticServe) resolve(requestPath string) (string, fs.FileInfo, error) {
+	name := strings.TrimPrefix(path.Clean("/"+requestPath), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := s.stat(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if info != nil && info.IsDir() {
+		info = nil
+
+		if s.settings.index != "" {
+			name = path.Join(name, s.settings.index)
+
+			if info, err = s.stat(name); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	if info != nil {
+		return name, info, nil
+	}
+
+	if s.settings.spaFallback == "" || path.Ext(name) != "" {
+		return "", nil, NewErrorWithStatus(http.StatusNotFound, ErrStaticFileNotFound)
+	}
+
+	if info, err = s.stat(s.settings.spaFallback); err != nil {
+		return "", nil, err
+	}
+
+	if info == nil || info.IsDir() {
+		return "", nil, fmt.Errorf("the spa fallback %q is not a file", s.settings.spaFallback)
+	}
+
+	return s.settings.spaFallback, info, nil
+}
+
+// stat returns the info of the file with the given name or nil if there is none.
+func (s *staticServe) stat(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(s.files, name)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("can not stat file %q: %w", name, err)
+	default:
+		return info, nil
+	}
+}
+
+func (s *staticServe) getCacheControl(name string) string {
+	switch {
+	case s.settings.fingerprinted != nil && s.settings.fingerprinted.MatchString(path.Base(name)):
+		return StaticServeImmutableCacheControl
+	case s.settings.maxAge > 0:
+		return fmt.Sprintf("public, max-age=%d", int64(s.settings.maxAge.Seconds()))
+	default:
+		return "no-cache"
+	}
+}
+
+// negotiatePrecompressed returns the precompressed sibling of a file accepted by the client and its encoding. The
+// file itself is returned if the client accepts none of them.
+func (s *staticServe) negotiatePrecompressed(ginCtx *gin.Context, name string, info fs.FileInfo) (string, fs.FileInfo, string, error) {
+	accepted := parseAccept(ginCtx.GetHeader(HeaderAcceptEncoding))
+	varies := false
+
+	for _, precompressed := range staticServePrecompressedEncodings {
+		siblingInfo, err := s.stat(name + precompressed.extension)
+		if err != nil {
+			return "", nil, "", err
+		}
+
+		if siblingInfo == nil || siblingInfo.IsDir() {
+			continue
+		}
+
+		if !varies {
+			ginCtx.Writer.Header().Add(HeaderVary, HeaderAcceptEncoding)
+			varies = true
+		}
+
+		if quality, specificity := acceptQuality(accepted, precompressed.encoding); specificity >= 0 && quality > 0 {
+			return name + precompressed.extension, siblingInfo, precompressed.encoding, nil
+		}
+	}
+
+	return name, info, "", nil
+}
+
+// getETag returns the hash of the content of a file. The hash is kept until the size or the modification time of the
+// file changes.
+func (s *staticServe) getETag(name string, info fs.FileInfo) (string, error) {
+	if cached, ok := s.etags.Load(name); ok {
+		if etag := cached.(staticServeETag); etag.size == info.Size() && etag.modTime.Equal(info.ModTime()) {
+			return etag.tag, nil
+		}
+	}
+
+	file, err := s.files.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("can not open file %q: %w", name, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("can not hash file %q: %w", name, err)
+	}
+
+	tag := base64.RawURLEncoding.EncodeToString(hash.Sum(nil))
+	s.etags.Store(name, staticServeETag{size: info.Size(), modTime: info.ModTime(), tag: tag})
+
+	return tag, nil
+}
@@ -0,0 +1,182 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var staticServeTestFiles = fstest.MapFS{
+	"index.html":                {Data: []byte("<html>app</html>"), ModTime: conditionalTestModified},
+	"docs/index.html":           {Data: []byte("<html>docs</html>"), ModTime: conditionalTestModified},
+	"assets/app.js":             {Data: []byte("console.log('app')"), ModTime: conditionalTestModified},
+	"assets/app.js.br":          {Data: []byte("brotli"), ModTime: conditionalTestModified},
+	"assets/app.js.gz":          {Data: []byte("gzip"), ModTime: conditionalTestModified},
+	"assets/style.3f2a9b1c.css": {Data: []byte("body{}"), ModTime: conditionalTestModified},
+	"robots.txt":                {Data: []byte("User-agent: *"), ModTime: conditionalTestModified},
+}
+
+func newStaticServeTestRoutes(options ...httpserver.StaticServeOption) func(r *gin.Engine) {
+	return func(r *gin.Engine) {
+		r.Use(httpserver.NewStaticServeHandler(staticServeTestFiles, options...))
+		r.GET("/api/status", func(c *gin.Context) {
+			c.String(http.StatusOK, "api")
+		})
+	}
+}
+
+func TestStaticServeFile(t *testing.T) {
+	router := newTestRouter(newStaticServeTestRoutes())
+	rec := serveTestRequest(router, http.MethodGet, "/robots.txt", nil, nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "User-agent: *", rec.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get(httpserver.HeaderContentType))
+	assert.Equal(t, "no-cache", rec.Header().Get(httpserver.HeaderCacheControl))
+	assert.Equal(t, "Sun, 01 Mar 2026 12:00:00 GMT", rec.Header().Get(httpserver.HeaderLastModified))
+
+	etag := rec.Header().Get(httpserver.HeaderETag)
+	require.NotEmpty(t, etag)
+
+	rec = serveTestRequest(router, http.MethodGet, "/robots.txt", nil, map[string]string{httpserver.HeaderIfNoneMatch: etag})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = serveTestRequest(router, http.MethodGet, "/robots.txt", nil, map[string]string{httpserver.HeaderRange: "bytes=0-9"})
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "User-agent", rec.Body.String())
+}
+
+func TestStaticServePaths(t *testing.T) {
+	cases := []struct {
+		name         string
+		options      []httpserver.StaticServeOption
+		path         string
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			name:         "root index",
+			path:         "/",
+			expectStatus: http.StatusOK,
+			expectBody:   "<html>app</html>",
+		},
+		{
+			name:         "directory index",
+			path:         "/docs/",
+			expectStatus: http.StatusOK,
+			expectBody:   "<html>docs</html>",
+		},
+		{
+			name:         "directory index disabled",
+			options:      []httpserver.StaticServeOption{httpserver.WithStaticServeIndex("")},
+			path:         "/docs",
+			expectStatus: http.StatusNotFound,
+			expectBody:   `{"err":"file not found"}`,
+		},
+		{
+			name:         "path outside of the files",
+			path:         "/../index.html",
+			expectStatus: http.StatusOK,
+			expectBody:   "<html>app</html>",
+		},
+		{
+			name:         "missing path without spa fallback",
+			path:         "/users/1",
+			expectStatus: http.StatusNotFound,
+			expectBody:   `{"err":"file not found"}`,
+		},
+		{
+			name:         "missing path with spa fallback",
+			options:      []httpserver.StaticServeOption{httpserver.WithStaticServeSpaFallback("/index.html")},
+			path:         "/users/1",
+			expectStatus: http.StatusOK,
+			expectBody:   "<html>app</html>",
+		},
+		{
+			name:         "missing file with spa fallback",
+			options:      []httpserver.StaticServeOption{httpserver.WithStaticServeSpaFallback("index.html")},
+			path:         "/assets/missing.js",
+			expectStatus: http.StatusNotFound,
+			expectBody:   `{"err":"file not found"}`,
+		},
+		{
+			name:         "excluded path",
+			options:      []httpserver.StaticServeOption{httpserver.WithStaticServeExcludes("/api")},
+			path:         "/api/status",
+			expectStatus: http.StatusOK,
+			expectBody:   "api",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newTestRouter(newStaticServeTestRoutes(tc.options...))
+			rec := serveTestRequest(router, http.MethodGet, tc.path, nil, nil)
+
+			assert.Equal(t, tc.expectStatus, rec.Code)
+			assert.Equal(t, tc.expectBody, rec.Body.String())
+		})
+	}
+}
+
+func TestStaticServeCacheControl(t *testing.T) {
+	router := newTestRouter(newStaticServeTestRoutes(
+		httpserver.WithStaticServeMaxAge(time.Hour),
+		httpserver.WithStaticServeFingerprinted(regexp.MustCompile(`\.[0-9a-f]{8}\.`)),
+	))
+
+	rec := serveTestRequest(router, http.MethodGet, "/assets/style.3f2a9b1c.css", nil, nil)
+	assert.Equal(t, httpserver.StaticServeImmutableCacheControl, rec.Header().Get(httpserver.HeaderCacheControl))
+
+	rec = serveTestRequest(router, http.MethodGet, "/robots.txt", nil, nil)
+	assert.Equal(t, "public, max-age=3600", rec.Header().Get(httpserver.HeaderCacheControl))
+}
+
+func TestStaticServePrecompressed(t *testing.T) {
+	cases := map[string]struct {
+		acceptEncoding string
+		expectEncoding string
+		expectBody     string
+	}{
+		"brotli":       {acceptEncoding: "gzip, br", expectEncoding: "br", expectBody: "brotli"},
+		"gzip":         {acceptEncoding: "gzip, br;q=0", expectEncoding: "gzip", expectBody: "gzip"},
+		"uncompressed": {acceptEncoding: "", expectEncoding: "", expectBody: "console.log('app')"},
+	}
+
+	router := newTestRouter(newStaticServeTestRoutes(httpserver.WithStaticServePrecompressed()))
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := serveTestRequest(router, http.MethodGet, "/assets/app.js", nil, map[string]string{
+				httpserver.HeaderAcceptEncoding: tc.acceptEncoding,
+			})
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tc.expectEncoding, rec.Header().Get(httpserver.HeaderContentEncoding))
+			assert.Equal(t, "text/javascript; charset=utf-8", rec.Header().Get(httpserver.HeaderContentType))
+			assert.Equal(t, httpserver.HeaderAcceptEncoding, rec.Header().Get(httpserver.HeaderVary))
+			assert.Equal(t, tc.expectBody, rec.Body.String())
+		})
+	}
+}
+
+func TestStaticServeRoute(t *testing.T) {
+	router := newTestRouter(func(r *gin.Engine) {
+		r.GET("/static/*filepath", httpserver.NewStaticServeHandler(staticServeTestFiles))
+	})
+
+	rec := serveTestRequest(router, http.MethodGet, "/static/robots.txt", nil, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "User-agent: *", rec.Body.String())
+
+	rec = serveTestRequest(router, http.MethodGet, "/static/missing.txt", nil, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,284 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrHandlerDeadlineExceeded is the cause of the request context of a handler which exceeded its deadline. Requests
+// the handler didn't respond to in time are answered with 504 or the configured status code.
+var ErrHandlerDeadlineExceeded = errors.New("handler deadline exceeded")
+
+// timeoutResponseWriter discards the writes of a handler once its deadline expired before it started to respond. The
+// handler gets its own headers, which are copied to the response once it writes, so the timeout response can be written
+// while the handler still runs.
+type timeoutResponseWriter struct {
+	gin.ResponseWriter
+	ctx     context.Context
+	lck     sync.Mutex
+	header  http.Header
+	expired bool
+	done    bool
+}
+
+// TimeoutMiddleware bounds the execution time of the following handlers. The request context is canceled with the
+// cause ErrHandlerDeadlineExceeded once the timeout is exceeded. If the handlers didn't respond until then, the request
+// is answered with the status code right away, rendered like the error middleware does, and their later writes are
+// discarded. A timeout <= 0 disables the middleware, a status code of 0 uses 504.
+//
+// The handlers run in another goroutine. They aren't preempted, so the request only finishes once they returned, but
+// the client doesn't wait for it: the timeout response is complete as soon as it is sent.
+func TimeoutMiddleware(timeout time.Duration, statusCode int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handleWithTimeout(c, timeout, statusCode)
+	}
+}
+
+func handleWithTimeout(c *gin.Context, timeout time.Duration, statusCode int) {
+	if timeout <= 0 {
+		c.Next()
+
+		return
+	}
+
+	if statusCode == 0 {
+		statusCode = http.StatusGatewayTimeout
+	}
+
+	ctx, cancel := context.WithTimeoutCause(c.Request.Context(), timeout, ErrHandlerDeadlineExceeded)
+	defer cancel()
+
+	timeoutErr := NewErrorWithStatus(statusCode, fmt.Errorf("%w after %s", ErrHandlerDeadlineExceeded, timeout))
+	writer := &timeoutResponseWriter{
+		ResponseWriter: c.Writer,
+		ctx:            ctx,
+		header:         c.Writer.Header().Clone(),
+	}
+
+	// the timeout response is written with a copy, as the handlers still use the context when their deadline expires
+	timeoutCtx := c.Copy()
+	timeoutCtx.Writer = c.Writer
+
+	c.Writer = writer
+	c.Request = c.Request.WithContext(ctx)
+
+	done := make(chan any, 1)
+	go func() {
+		defer func() {
+			done <- recover()
+		}()
+
+		c.Next()
+	}()
+
+	var recovered any
+	var responseErr error
+	handled := false
+
+	select {
+	case recovered = <-done:
+		handled = true
+	case <-ctx.Done():
+	}
+
+	expired := writer.finish(func() {
+		responseErr = writeTimeoutResponse(timeoutCtx, timeoutErr)
+	})
+
+	// gin reuses the context for other requests once the chain returned, so the handlers are waited for
+	if !handled {
+		recovered = <-done
+	}
+
+	c.Writer = writer.ResponseWriter
+
+	if recovered != nil {
+		panic(recovered)
+	}
+
+	if !expired {
+		return
+	}
+
+	c.Request = MarkRequestTimedOut(c.Request)
+	reportGinError(c, timeoutErr)
+
+	if responseErr != nil {
+		reportGinError(c, fmt.Errorf("error response error: %w", responseErr))
+	}
+
+	c.Abort()
+}
+
+// writeTimeoutResponse answers the request like the error middleware. The response has a Content-Length and isn't
+// compressed, so the client receives all of it while the handlers of the request are still running.
+func writeTimeoutResponse(ginCtx *gin.Context, err error) error {
+	settings := getErrorsSettings(ginCtx)
+	statusCode := GetErrorStatusCode(err)
+	response := getErrorResponse(ginCtx, settings, statusCode, getPublicError(settings, statusCode, err))
+
+	if negotiable, ok := response.(negotiableResponse); ok {
+		addVary(ginCtx.Writer.Header(), HeaderAccept)
+
+		if response, err = negotiable.negotiate(ginCtx.Request, getNegotiationDefaultContentType(ginCtx)); err != nil {
+			return err
+		}
+	}
+
+	body, err := response.Body()
+	if err != nil {
+		return fmt.Errorf("body read error: %w", err)
+	}
+
+	DisableCompression(ginCtx)
+	ginCtx.Header(HeaderContentLength, strconv.Itoa(len(body)))
+
+	if err = BindHandleResponse(response, ginCtx); err != nil {
+		return err
+	}
+
+	ginCtx.Writer.Flush()
+
+	return nil
+}
+
+type timedOutRequestKey struct{}
+
+// MarkRequestTimedOut marks a request as timed out so metric middleware can record it.
+func MarkRequestTimedOut(request *http.Request) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), timedOutRequestKey{}, true))
+}
+
+// WasRequestTimedOut reports whether a request was marked as timed out.
+func WasRequestTimedOut(request *http.Request) bool {
+	isTimedOut, ok := request.Context().Value(timedOutRequestKey{}).(bool)
+
+	return ok && isTimedOut
+}
+
+// isExpired reports whether the deadline expired before the handler started to respond. It is evaluated on every
+// write instead of on expiry of the context, so a handler observing the canceled context can't respond anymore.
+func (w *timeoutResponseWriter) isExpired() bool {
+	if !w.expired && !w.done && !w.ResponseWriter.Written() {
+		w.expired = errors.Is(context.Cause(w.ctx), ErrHandlerDeadlineExceeded)
+	}
+
+	if !w.expired {
+		w.copyHeader()
+	}
+
+	return w.expired
+}
+
+// copyHeader copies the headers of the handler to the response until it is written.
+func (w *timeoutResponseWriter) copyHeader() {
+	if w.ResponseWriter.Written() {
+		return
+	}
+
+	header := w.ResponseWriter.Header()
+	clear(header)
+	maps.Copy(header, w.header)
+}
+
+// finish calls onExpired if the deadline expired before the handler responded. The writer doesn't expire afterward.
+func (w *timeoutResponseWriter) finish(onExpired func()) bool {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	expired := w.isExpired()
+	w.done = true
+
+	if expired {
+		onExpired()
+	}
+
+	return expired
+}
+
+func (w *timeoutResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutResponseWriter) Status() int {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	return w.ResponseWriter.Status()
+}
+
+func (w *timeoutResponseWriter) Size() int {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	return w.ResponseWriter.Size()
+}
+
+func (w *timeoutResponseWriter) Written() bool {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	return w.ResponseWriter.Written()
+}
+
+func (w *timeoutResponseWriter) WriteHeader(code int) {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	if !w.isExpired() {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *timeoutResponseWriter) WriteHeaderNow() {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	if !w.isExpired() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *timeoutResponseWriter) Write(data []byte) (int, error) {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	if w.isExpired() {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timeoutResponseWriter) WriteString(s string) (int, error) {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	if w.isExpired() {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timeoutResponseWriter) Flush() {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	if !w.isExpired() {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the connection of the response.
+func (w *timeoutResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,130 @@
+package httpserver_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTimeoutTestRouter(timeout time.Duration, statusCode int, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(httpserver.ErrorMiddlewareWithSettings(httpserver.ErrorsSettings{Privacy: httpserver.ErrorPrivacyPublic}))
+	router.Use(httpserver.TimeoutMiddleware(timeout, statusCode))
+	router.GET("/", handler)
+
+	return router
+}
+
+func TestTimeoutMiddlewareDiscardsLateWrites(t *testing.T) {
+	handlerErr := make(chan error, 1)
+	router := newTimeoutTestRouter(time.Millisecond, 0, func(c *gin.Context) {
+		<-c.Done()
+
+		c.Header("X-Late", "true")
+		c.Status(http.StatusOK)
+		_, err := c.Writer.WriteString("too late")
+		handlerErr <- err
+	})
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	assert.ErrorIs(t, <-handlerErr, http.ErrHandlerTimeout)
+	assert.Equal(t, http.StatusGatewayTimeout, response.Code)
+	assert.JSONEq(t, `{"err":"handler deadline exceeded after 1ms"}`, response.Body.String())
+	assert.Empty(t, response.Header().Get("X-Late"))
+}
+
+func TestTimeoutMiddlewareStatusCode(t *testing.T) {
+	router := newTimeoutTestRouter(time.Millisecond, http.StatusServiceUnavailable, func(c *gin.Context) {
+		<-c.Done()
+
+		assert.True(t, errors.Is(context.Cause(c), httpserver.ErrHandlerDeadlineExceeded))
+	})
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+}
+
+func TestTimeoutMiddlewareAfterResponseStarted(t *testing.T) {
+	router := newTimeoutTestRouter(time.Millisecond, 0, func(c *gin.Context) {
+		c.String(http.StatusOK, "started")
+		c.Writer.Flush()
+
+		<-c.Done()
+
+		_, err := c.Writer.WriteString(" and finished")
+		require.NoError(t, err)
+	})
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "started and finished", response.Body.String())
+}
+
+func TestTimeoutMiddlewareInTime(t *testing.T) {
+	var timedOut bool
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(func(c *gin.Context) {
+		c.Next()
+
+		timedOut = httpserver.WasRequestTimedOut(c.Request)
+	})
+	router.Use(httpserver.TimeoutMiddleware(time.Minute, 0))
+	router.GET("/", func(c *gin.Context) {
+		_, hasDeadline := c.Deadline()
+		assert.True(t, hasDeadline)
+
+		c.String(http.StatusOK, "in time")
+	})
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "in time", response.Body.String())
+	assert.False(t, timedOut)
+}
+
+func TestTimeoutMiddlewareHandlerIgnoringContext(t *testing.T) {
+	release := make(chan struct{})
+	router := newTimeoutTestRouter(10*time.Millisecond, 0, func(c *gin.Context) {
+		<-release
+
+		c.String(http.StatusOK, "ignored the deadline")
+	})
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	t.Cleanup(func() {
+		close(release)
+	})
+
+	res, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusGatewayTimeout, res.StatusCode, "the client is answered while the handler still runs")
+	assert.JSONEq(t, `{"err":"handler deadline exceeded after 10ms"}`, string(body))
+}
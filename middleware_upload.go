@@ -0,0 +1,42 @@
+package httpserver
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	uploadSettingsKey       = "goso.upload.settings"
+	uploadMetricRecorderKey = "goso.upload.metricRecorder"
+)
+
+// UploadMiddleware makes the upload settings and the metric recorder of the server available to the binding of
+// multipart/form-data requests.
+func UploadMiddleware(settings UploadSettings, metricRecorder ServerMetricRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(uploadSettingsKey, settings)
+		c.Set(uploadMetricRecorderKey, metricRecorder)
+
+		c.Next()
+	}
+}
+
+func getUploadSettings(ginCtx *gin.Context) UploadSettings {
+	if settings, ok := ginCtx.Value(uploadSettingsKey).(UploadSettings); ok {
+		return settings
+	}
+
+	return UploadSettings{
+		MaxFileBytes:  DefaultUploadMaxBytes,
+		MaxTotalBytes: DefaultUploadMaxBytes,
+		MaxFiles:      DefaultUploadMaxFiles,
+		MaxValueBytes: DefaultUploadMaxValueBytes,
+	}
+}
+
+func getUploadMetricRecorder(ginCtx *gin.Context) ServerMetricRecorder {
+	if metricRecorder, ok := ginCtx.Value(uploadMetricRecorderKey).(ServerMetricRecorder); ok {
+		return metricRecorder
+	}
+
+	return nil
+}
@@ -38,8 +38,8 @@ func (_m *ConnectionLifeCycleAdvisor) EXPECT() *ConnectionLifeCycleAdvisor_Expec
 }
 
 // ShouldCloseConnection provides a mock function for the type ConnectionLifeCycleAdvisor
-func (_mock *ConnectionLifeCycleAdvisor) ShouldCloseConnection(remoteAddr string, headers http.Header) bool {
-	ret := _mock.Called(remoteAddr, headers)
+func (_mock *ConnectionLifeCycleAdvisor) ShouldCloseConnection(clientIP string, headers http.Header) bool {
+	ret := _mock.Called(clientIP, headers)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ShouldCloseConnection")
@@ -47,7 +47,7 @@ func (_mock *ConnectionLifeCycleAdvisor) ShouldCloseConnection(remoteAddr string
 
 	var r0 bool
 	if returnFunc, ok := ret.Get(0).(func(string, http.Header) bool); ok {
-		r0 = returnFunc(remoteAddr, headers)
+		r0 = returnFunc(clientIP, headers)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
@@ -60,13 +60,13 @@ type ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call struct {
 }
 
 // ShouldCloseConnection is a helper method to define mock.On call
-//   - remoteAddr string
+//   - clientIP string
 //   - headers http.Header
-func (_e *ConnectionLifeCycleAdvisor_Expecter) ShouldCloseConnection(remoteAddr interface{}, headers interface{}) *ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call {
-	return &ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call{Call: _e.mock.On("ShouldCloseConnection", remoteAddr, headers)}
+func (_e *ConnectionLifeCycleAdvisor_Expecter) ShouldCloseConnection(clientIP interface{}, headers interface{}) *ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call {
+	return &ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call{Call: _e.mock.On("ShouldCloseConnection", clientIP, headers)}
 }
 
-func (_c *ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call) Run(run func(remoteAddr string, headers http.Header)) *ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call {
+func (_c *ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call) Run(run func(clientIP string, headers http.Header)) *ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 string
 		if args[0] != nil {
@@ -89,7 +89,7 @@ func (_c *ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call) Return(b bool)
 	return _c
 }
 
-func (_c *ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call) RunAndReturn(run func(remoteAddr string, headers http.Header) bool) *ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call {
+func (_c *ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call) RunAndReturn(run func(clientIP string, headers http.Header) bool) *ConnectionLifeCycleAdvisor_ShouldCloseConnection_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -88,6 +88,52 @@ func (_c *ServerMetricRecorder_Run_Call) RunAndReturn(run func(ctx context.Conte
 	return _c
 }
 
+// TrackConcurrencyLimit provides a mock function for the type ServerMetricRecorder
+func (_mock *ServerMetricRecorder) TrackConcurrencyLimit(ctx context.Context, limit int) {
+	_mock.Called(ctx, limit)
+	return
+}
+
+// ServerMetricRecorder_TrackConcurrencyLimit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TrackConcurrencyLimit'
+type ServerMetricRecorder_TrackConcurrencyLimit_Call struct {
+	*mock.Call
+}
+
+// TrackConcurrencyLimit is a helper method to define mock.On call
+//   - ctx context.Context
+//   - limit int
+func (_e *ServerMetricRecorder_Expecter) TrackConcurrencyLimit(ctx interface{}, limit interface{}) *ServerMetricRecorder_TrackConcurrencyLimit_Call {
+	return &ServerMetricRecorder_TrackConcurrencyLimit_Call{Call: _e.mock.On("TrackConcurrencyLimit", ctx, limit)}
+}
+
+func (_c *ServerMetricRecorder_TrackConcurrencyLimit_Call) Run(run func(ctx context.Context, limit int)) *ServerMetricRecorder_TrackConcurrencyLimit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackConcurrencyLimit_Call) Return() *ServerMetricRecorder_TrackConcurrencyLimit_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackConcurrencyLimit_Call) RunAndReturn(run func(ctx context.Context, limit int)) *ServerMetricRecorder_TrackConcurrencyLimit_Call {
+	_c.Run(run)
+	return _c
+}
+
 // TrackConnectionClosed provides a mock function for the type ServerMetricRecorder
 func (_mock *ServerMetricRecorder) TrackConnectionClosed(ctx context.Context) {
 	_mock.Called(ctx)
@@ -168,6 +214,46 @@ func (_c *ServerMetricRecorder_TrackConnectionOpened_Call) RunAndReturn(run func
 	return _c
 }
 
+// TrackDecompressionLimitExceeded provides a mock function for the type ServerMetricRecorder
+func (_mock *ServerMetricRecorder) TrackDecompressionLimitExceeded(ctx context.Context) {
+	_mock.Called(ctx)
+	return
+}
+
+// ServerMetricRecorder_TrackDecompressionLimitExceeded_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TrackDecompressionLimitExceeded'
+type ServerMetricRecorder_TrackDecompressionLimitExceeded_Call struct {
+	*mock.Call
+}
+
+// TrackDecompressionLimitExceeded is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *ServerMetricRecorder_Expecter) TrackDecompressionLimitExceeded(ctx interface{}) *ServerMetricRecorder_TrackDecompressionLimitExceeded_Call {
+	return &ServerMetricRecorder_TrackDecompressionLimitExceeded_Call{Call: _e.mock.On("TrackDecompressionLimitExceeded", ctx)}
+}
+
+func (_c *ServerMetricRecorder_TrackDecompressionLimitExceeded_Call) Run(run func(ctx context.Context)) *ServerMetricRecorder_TrackDecompressionLimitExceeded_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackDecompressionLimitExceeded_Call) Return() *ServerMetricRecorder_TrackDecompressionLimitExceeded_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackDecompressionLimitExceeded_Call) RunAndReturn(run func(ctx context.Context)) *ServerMetricRecorder_TrackDecompressionLimitExceeded_Call {
+	_c.Run(run)
+	return _c
+}
+
 // TrackRequestCompleted provides a mock function for the type ServerMetricRecorder
 func (_mock *ServerMetricRecorder) TrackRequestCompleted(ctx context.Context) {
 	_mock.Called(ctx)
@@ -247,3 +333,169 @@ func (_c *ServerMetricRecorder_TrackRequestStarted_Call) RunAndReturn(run func(c
 	_c.Run(run)
 	return _c
 }
+
+// TrackSseSubscribed provides a mock function for the type ServerMetricRecorder
+func (_mock *ServerMetricRecorder) TrackSseSubscribed(ctx context.Context) {
+	_mock.Called(ctx)
+	return
+}
+
+// ServerMetricRecorder_TrackSseSubscribed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TrackSseSubscribed'
+type ServerMetricRecorder_TrackSseSubscribed_Call struct {
+	*mock.Call
+}
+
+// TrackSseSubscribed is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *ServerMetricRecorder_Expecter) TrackSseSubscribed(ctx interface{}) *ServerMetricRecorder_TrackSseSubscribed_Call {
+	return &ServerMetricRecorder_TrackSseSubscribed_Call{Call: _e.mock.On("TrackSseSubscribed", ctx)}
+}
+
+func (_c *ServerMetricRecorder_TrackSseSubscribed_Call) Run(run func(ctx context.Context)) *ServerMetricRecorder_TrackSseSubscribed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackSseSubscribed_Call) Return() *ServerMetricRecorder_TrackSseSubscribed_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackSseSubscribed_Call) RunAndReturn(run func(ctx context.Context)) *ServerMetricRecorder_TrackSseSubscribed_Call {
+	_c.Run(run)
+	return _c
+}
+
+// TrackSseUnsubscribed provides a mock function for the type ServerMetricRecorder
+func (_mock *ServerMetricRecorder) TrackSseUnsubscribed(ctx context.Context) {
+	_mock.Called(ctx)
+	return
+}
+
+// ServerMetricRecorder_TrackSseUnsubscribed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TrackSseUnsubscribed'
+type ServerMetricRecorder_TrackSseUnsubscribed_Call struct {
+	*mock.Call
+}
+
+// TrackSseUnsubscribed is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *ServerMetricRecorder_Expecter) TrackSseUnsubscribed(ctx interface{}) *ServerMetricRecorder_TrackSseUnsubscribed_Call {
+	return &ServerMetricRecorder_TrackSseUnsubscribed_Call{Call: _e.mock.On("TrackSseUnsubscribed", ctx)}
+}
+
+func (_c *ServerMetricRecorder_TrackSseUnsubscribed_Call) Run(run func(ctx context.Context)) *ServerMetricRecorder_TrackSseUnsubscribed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackSseUnsubscribed_Call) Return() *ServerMetricRecorder_TrackSseUnsubscribed_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackSseUnsubscribed_Call) RunAndReturn(run func(ctx context.Context)) *ServerMetricRecorder_TrackSseUnsubscribed_Call {
+	_c.Run(run)
+	return _c
+}
+
+// TrackUploadRejected provides a mock function for the type ServerMetricRecorder
+func (_mock *ServerMetricRecorder) TrackUploadRejected(ctx context.Context) {
+	_mock.Called(ctx)
+	return
+}
+
+// ServerMetricRecorder_TrackUploadRejected_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TrackUploadRejected'
+type ServerMetricRecorder_TrackUploadRejected_Call struct {
+	*mock.Call
+}
+
+// TrackUploadRejected is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *ServerMetricRecorder_Expecter) TrackUploadRejected(ctx interface{}) *ServerMetricRecorder_TrackUploadRejected_Call {
+	return &ServerMetricRecorder_TrackUploadRejected_Call{Call: _e.mock.On("TrackUploadRejected", ctx)}
+}
+
+func (_c *ServerMetricRecorder_TrackUploadRejected_Call) Run(run func(ctx context.Context)) *ServerMetricRecorder_TrackUploadRejected_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackUploadRejected_Call) Return() *ServerMetricRecorder_TrackUploadRejected_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackUploadRejected_Call) RunAndReturn(run func(ctx context.Context)) *ServerMetricRecorder_TrackUploadRejected_Call {
+	_c.Run(run)
+	return _c
+}
+
+// TrackUploadedBytes provides a mock function for the type ServerMetricRecorder
+func (_mock *ServerMetricRecorder) TrackUploadedBytes(ctx context.Context, bytes int64) {
+	_mock.Called(ctx, bytes)
+	return
+}
+
+// ServerMetricRecorder_TrackUploadedBytes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TrackUploadedBytes'
+type ServerMetricRecorder_TrackUploadedBytes_Call struct {
+	*mock.Call
+}
+
+// TrackUploadedBytes is a helper method to define mock.On call
+//   - ctx context.Context
+//   - bytes int64
+func (_e *ServerMetricRecorder_Expecter) TrackUploadedBytes(ctx interface{}, bytes interface{}) *ServerMetricRecorder_TrackUploadedBytes_Call {
+	return &ServerMetricRecorder_TrackUploadedBytes_Call{Call: _e.mock.On("TrackUploadedBytes", ctx, bytes)}
+}
+
+func (_c *ServerMetricRecorder_TrackUploadedBytes_Call) Run(run func(ctx context.Context, bytes int64)) *ServerMetricRecorder_TrackUploadedBytes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackUploadedBytes_Call) Return() *ServerMetricRecorder_TrackUploadedBytes_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *ServerMetricRecorder_TrackUploadedBytes_Call) RunAndReturn(run func(ctx context.Context, bytes int64)) *ServerMetricRecorder_TrackUploadedBytes_Call {
+	_c.Run(run)
+	return _c
+}
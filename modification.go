@@ -15,7 +15,8 @@ type Modifier interface {
 	Struct(ctx context.Context, v any) error
 }
 
-// WithCustomModifier replaces the package-level input modifier used after binding.
+// WithCustomModifier replaces the package-level input modifier used after binding. Routers can override it
+// with Router.SetModifier.
 func WithCustomModifier(modifier Modifier) {
 	defaultModifier = modifier
 }
@@ -27,7 +28,7 @@ func newDefaultModifier() Modifier {
 	return mod
 }
 
-func modifyInput(ctx context.Context, input any) error {
+func modifyInput(ctx context.Context, modifier Modifier, input any) error {
 	if input == nil {
 		return nil
 	}
@@ -45,7 +46,7 @@ func modifyInput(ctx context.Context, input any) error {
 		return nil
 	}
 
-	err := defaultModifier.Struct(ctx, input)
+	err := modifier.Struct(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to modify input: %w", err)
 	}
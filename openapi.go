@@ -0,0 +1,440 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	"github.com/justtrackio/gosoline/pkg/encoding/json"
+	"github.com/justtrackio/gosoline/pkg/log"
+)
+
+// OpenApiVersion is the version of the OpenAPI specification generated documents conform to.
+const OpenApiVersion = "3.1.0"
+
+type (
+	// OpenApiDocument is the root of an OpenAPI 3.1 document.
+	OpenApiDocument struct {
+		OpenApi    string                     `json:"openapi"`
+		Info       OpenApiInfo                `json:"info"`
+		Paths      map[string]OpenApiPathItem `json:"paths"`
+		Components *OpenApiComponents         `json:"components,omitempty"`
+	}
+
+	// OpenApiInfo provides metadata about the API.
+	OpenApiInfo struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// OpenApiPathItem maps the lower case HTTP methods of a path to their operations.
+	OpenApiPathItem map[string]*OpenApiOperation
+
+	// OpenApiOperation describes a single API operation on a path.
+	OpenApiOperation struct {
+		OperationId string                      `json:"operationId,omitempty"`
+		Summary     string                      `json:"summary,omitempty"`
+		Description string                      `json:"description,omitempty"`
+		Tags        []string                    `json:"tags,omitempty"`
+		Deprecated  bool                        `json:"deprecated,omitempty"`
+		Parameters  []*OpenApiParameter         `json:"parameters,omitempty"`
+		RequestBody *OpenApiRequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*OpenApiResponse `json:"responses"`
+	}
+
+	// OpenApiParameter describes a path, query, or header parameter of an operation.
+	OpenApiParameter struct {
+		Name     string         `json:"name"`
+		In       string         `json:"in"`
+		Required bool           `json:"required,omitempty"`
+		Schema   *OpenApiSchema `json:"schema"`
+	}
+
+	// OpenApiRequestBody describes the request body of an operation.
+	OpenApiRequestBody struct {
+		Required bool                         `json:"required,omitempty"`
+		Content  map[string]*OpenApiMediaType `json:"content"`
+	}
+
+	// OpenApiResponse describes a single response of an operation.
+	OpenApiResponse struct {
+		Description string                       `json:"description"`
+		Content     map[string]*OpenApiMediaType `json:"content,omitempty"`
+	}
+
+	// OpenApiMediaType provides the schema of a request or response body for one content type.
+	OpenApiMediaType struct {
+		Schema *OpenApiSchema `json:"schema,omitempty"`
+	}
+
+	// OpenApiComponents holds the reusable schemas referenced by the document.
+	OpenApiComponents struct {
+		Schemas map[string]*OpenApiSchema `json:"schemas,omitempty"`
+	}
+)
+
+// BuildOpenApiDocument creates an OpenAPI document from route definitions. Inputs and responses are taken
+// from the RouteSpec of each definition, routes without a spec are documented with their path parameters only.
+func BuildOpenApiDocument(info OpenApiInfo, definitions []Definition) *OpenApiDocument {
+	builder := newOpenApiSchemaBuilder()
+	document := &OpenApiDocument{
+		OpenApi: OpenApiVersion,
+		Info:    info,
+		Paths:   map[string]OpenApiPathItem{},
+	}
+
+	for _, definition := range definitions {
+		path, pathParams := convertOpenApiPath(definition.getAbsolutePath())
+
+		if _, ok := document.Paths[path]; !ok {
+			document.Paths[path] = OpenApiPathItem{}
+		}
+
+		document.Paths[path][strings.ToLower(definition.HttpMethod)] = buildOpenApiOperation(builder, definition, pathParams)
+	}
+
+	if len(builder.components) > 0 {
+		document.Components = &OpenApiComponents{
+			Schemas: builder.components,
+		}
+	}
+
+	return document
+}
+
+// GenerateOpenApiDocument builds the routes of a named server without starting it and returns the resulting
+// OpenAPI document. Use it to write the document to a file at build time.
+func GenerateOpenApiDocument(ctx context.Context, config cfg.Config, logger log.Logger, name string, definer RouterFactory) (*OpenApiDocument, error) {
+	var err error
+	var definitionList []Definition
+
+	settings := &Settings{}
+	if err = config.UnmarshalKey(HttpserverSettingsKey(name), settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal httpserver settings: %w", err)
+	}
+	settings.Name = name
+
+	gin.SetMode(settings.Mode)
+
+	definitions := &Router{}
+	if err = definer(ctx, config, logger, definitions); err != nil {
+		return nil, fmt.Errorf("could not define routes: %w", err)
+	}
+
+	if definitionList, err = buildRouter(ctx, config, logger, settings, definitions, gin.New()); err != nil {
+		return nil, fmt.Errorf("could not build router: %w", err)
+	}
+
+	return BuildOpenApiDocument(getOpenApiInfo(settings), definitionList), nil
+}
+
+func addOpenApiEndpoint(router gin.IRouter, settings *Settings, definitions []Definition) error {
+	var err error
+	var body []byte
+
+	document := BuildOpenApiDocument(getOpenApiInfo(settings), definitions)
+
+	if body, err = json.Marshal(document); err != nil {
+		return fmt.Errorf("could not marshal openapi document: %w", err)
+	}
+
+	router.GET(settings.OpenApi.Path, func(c *gin.Context) {
+		c.Data(http.StatusOK, ContentTypeJson, body)
+	})
+
+	return nil
+}
+
+func getOpenApiInfo(settings *Settings) OpenApiInfo {
+	info := OpenApiInfo{
+		Title:       settings.OpenApi.Title,
+		Version:     settings.OpenApi.Version,
+		Description: settings.OpenApi.Description,
+	}
+
+	if info.Title == "" {
+		info.Title = settings.Name
+	}
+
+	return info
+}
+
+func buildOpenApiOperation(builder *openApiSchemaBuilder, definition Definition, pathParams []string) *OpenApiOperation {
+	spec := definition.Spec
+	if spec == nil {
+		spec = &RouteSpec{}
+	}
+
+	operation := &OpenApiOperation{
+		OperationId: spec.OperationId,
+		Summary:     spec.Summary,
+		Description: spec.Description,
+		Tags:        spec.Tags,
+		Deprecated:  spec.Deprecated,
+		Responses:   map[string]*OpenApiResponse{},
+	}
+
+	if operation.OperationId == "" {
+		operation.OperationId = buildOpenApiOperationId(definition.HttpMethod, definition.getAbsolutePath())
+	}
+
+	if spec.Input != nil {
+		operation.Parameters = buildOpenApiParameters(builder, spec.Input)
+		operation.RequestBody = buildOpenApiRequestBody(builder, spec.Input, spec.RequestContentTypes)
+	}
+
+	for _, name := range pathParams {
+		if !slices.ContainsFunc(operation.Parameters, func(param *OpenApiParameter) bool { return param.In == "path" && param.Name == name }) {
+			operation.Parameters = append(operation.Parameters, &OpenApiParameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &OpenApiSchema{Type: openApiTypeString},
+			})
+		}
+	}
+
+	for _, response := range spec.Responses {
+		operation.Responses[strconv.Itoa(response.StatusCode)] = buildOpenApiResponse(builder, response)
+	}
+
+	hasSuccessResponse := slices.ContainsFunc(spec.Responses, func(response RouteResponse) bool {
+		return response.StatusCode >= 200 && response.StatusCode < 400
+	})
+
+	if !hasSuccessResponse {
+		operation.Responses[strconv.Itoa(http.StatusOK)] = &OpenApiResponse{
+			Description: http.StatusText(http.StatusOK),
+		}
+	}
+
+	return operation
+}
+
+func buildOpenApiParameters(builder *openApiSchemaBuilder, input reflect.Type) []*OpenApiParameter {
+	for input.Kind() == reflect.Pointer {
+		input = input.Elem()
+	}
+
+	if input.Kind() != reflect.Struct {
+		return nil
+	}
+
+	params := make([]*OpenApiParameter, 0)
+
+	for _, field := range openApiParameterFields(input) {
+		for _, source := range []struct{ tag, in string }{{"uri", "path"}, {"form", "query"}, {"header", "header"}} {
+			tag := field.Tag.Get(source.tag)
+			name, options, _ := strings.Cut(tag, ",")
+
+			if name == "" || name == "-" {
+				continue
+			}
+
+			schema := builder.schemaFor(field.Type)
+			required := applyOpenApiValidationRules(schema, field.Type, openApiValidationRules(field))
+
+			for option := range strings.SplitSeq(options, ",") {
+				if defaultValue, ok := strings.CutPrefix(option, "default="); ok {
+					schema.Default = defaultValue
+				}
+			}
+
+			params = append(params, &OpenApiParameter{
+				Name:     name,
+				In:       source.in,
+				Required: required || source.in == "path",
+				Schema:   schema,
+			})
+		}
+	}
+
+	return params
+}
+
+func openApiParameterFields(typ reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, 0, typ.NumField())
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, openApiParameterFields(field.Type)...)
+
+			continue
+		}
+
+		// uploads are part of the multipart body, although they are named by a form tag
+		if field.IsExported() && !isUploadType(field.Type) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+func buildOpenApiRequestBody(builder *openApiSchemaBuilder, input reflect.Type, contentTypes []string) *OpenApiRequestBody {
+	var schema *OpenApiSchema
+
+	if len(contentTypes) == 0 {
+		return nil
+	}
+
+	for input.Kind() == reflect.Pointer {
+		input = input.Elem()
+	}
+
+	if input.Kind() == reflect.Struct && hasOpenApiParameterFields(input) {
+		schema = builder.structSchema(input, isOpenApiBodyField)
+	} else {
+		schema = builder.schemaFor(input)
+	}
+
+	if schema.Type == openApiTypeObject && len(schema.Properties) == 0 {
+		return nil
+	}
+
+	body := &OpenApiRequestBody{
+		Required: len(schema.Required) > 0,
+		Content:  map[string]*OpenApiMediaType{},
+	}
+
+	for _, contentType := range contentTypes {
+		body.Content[contentType] = &OpenApiMediaType{Schema: schema}
+	}
+
+	return body
+}
+
+func hasOpenApiParameterFields(typ reflect.Type) bool {
+	return slices.ContainsFunc(openApiJsonFields(typ), func(field openApiField) bool {
+		return !isOpenApiBodyField(field)
+	})
+}
+
+// isOpenApiBodyField reports whether a field is decoded from the request body. Fields only tagged as
+// path, query or header parameters are not part of the body.
+func isOpenApiBodyField(field openApiField) bool {
+	if _, ok := field.field.Tag.Lookup("json"); ok {
+		return true
+	}
+
+	for _, tag := range []string{"uri", "form", "header"} {
+		if _, ok := field.field.Tag.Lookup(tag); ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func buildOpenApiResponse(builder *openApiSchemaBuilder, response RouteResponse) *OpenApiResponse {
+	result := &OpenApiResponse{
+		Description: response.Description,
+	}
+
+	if result.Description == "" {
+		result.Description = http.StatusText(response.StatusCode)
+	}
+
+	if response.Type == nil && response.StatusCode >= http.StatusBadRequest {
+		result.Content = buildOpenApiErrorContent(builder)
+	}
+
+	if response.Type == nil {
+		return result
+	}
+
+	schema := builder.schemaFor(response.Type)
+	result.Content = map[string]*OpenApiMediaType{}
+
+	for _, contentType := range response.ContentTypes {
+		mediaType, _, _ := strings.Cut(contentType, ";")
+		result.Content[mediaType] = &OpenApiMediaType{Schema: schema}
+	}
+
+	return result
+}
+
+// buildOpenApiErrorContent documents the bodies of error responses rendered by the error middleware: the JSON body of
+// the default error handler and the problem details of the problem error format.
+func buildOpenApiErrorContent(builder *openApiSchemaBuilder) map[string]*OpenApiMediaType {
+	violations := builder.schemaFor(reflect.TypeFor[[]FieldViolation]())
+	stringSchema := func() *OpenApiSchema {
+		return &OpenApiSchema{Type: openApiTypeString}
+	}
+
+	return map[string]*OpenApiMediaType{
+		ContentTypeApplicationJson: {
+			Schema: &OpenApiSchema{
+				Type:     openApiTypeObject,
+				Required: []string{"err"},
+				Properties: map[string]*OpenApiSchema{
+					"err":        stringSchema(),
+					"violations": violations,
+				},
+			},
+		},
+		ContentTypeProblemJson: {
+			Schema: &OpenApiSchema{
+				Type: openApiTypeObject,
+				Properties: map[string]*OpenApiSchema{
+					"type":       stringSchema(),
+					"title":      stringSchema(),
+					"status":     {Type: openApiTypeInteger, Format: "int64"},
+					"detail":     stringSchema(),
+					"instance":   stringSchema(),
+					"violations": violations,
+				},
+			},
+		},
+	}
+}
+
+// convertOpenApiPath converts a gin path like /users/:id/*file into the OpenAPI form /users/{id}/{file}
+// and returns the names of the path parameters.
+func convertOpenApiPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	params := make([]string, 0)
+
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+
+		params = append(params, segment[1:])
+		segments[i] = "{" + segment[1:] + "}"
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+func buildOpenApiOperationId(method string, path string) string {
+	var builder strings.Builder
+
+	builder.WriteString(strings.ToLower(method))
+
+	for segment := range strings.SplitSeq(path, "/") {
+		if segment == "" {
+			continue
+		}
+
+		if segment[0] == ':' || segment[0] == '*' {
+			builder.WriteString("By")
+			segment = segment[1:]
+		}
+
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			builder.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+
+	return builder.String()
+}
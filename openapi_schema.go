@@ -0,0 +1,390 @@
+package httpserver
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenApiSchema is a JSON Schema (draft 2020-12) as used by OpenAPI 3.1.
+type OpenApiSchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Default              any                       `json:"default,omitempty"`
+	Enum                 []any                     `json:"enum,omitempty"`
+	Items                *OpenApiSchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenApiSchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *OpenApiSchema            `json:"additionalProperties,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64                  `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64                  `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty"`
+	MinProperties        *int                      `json:"minProperties,omitempty"`
+	MaxProperties        *int                      `json:"maxProperties,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	ContentEncoding      string                    `json:"contentEncoding,omitempty"`
+}
+
+const (
+	openApiTypeArray   = "array"
+	openApiTypeBoolean = "boolean"
+	openApiTypeInteger = "integer"
+	openApiTypeNumber  = "number"
+	openApiTypeObject  = "object"
+	openApiTypeString  = "string"
+)
+
+var (
+	openApiComponentNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+	openApiTimeType                  = reflect.TypeFor[time.Time]()
+	openApiDurationType              = reflect.TypeFor[time.Duration]()
+	openApiJsonMarshalerType         = reflect.TypeFor[json.Marshaler]()
+	openApiTextMarshalerType         = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+var openApiValidationFormats = map[string]string{
+	"email":    "email",
+	"url":      "uri",
+	"uri":      "uri",
+	"uuid":     "uuid",
+	"uuid4":    "uuid",
+	"ip":       "ip",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"hostname": "hostname",
+}
+
+var openApiValidationPatterns = map[string]string{
+	"alpha":    "^[a-zA-Z]+$",
+	"alphanum": "^[a-zA-Z0-9]+$",
+	"numeric":  "^[-+]?[0-9]+(?:\\.[0-9]+)?$",
+}
+
+type openApiSchemaBuilder struct {
+	components map[string]*OpenApiSchema
+	names      map[reflect.Type]string
+}
+
+type openApiField struct {
+	field reflect.StructField
+	name  string
+	rules string
+}
+
+func newOpenApiSchemaBuilder() *openApiSchemaBuilder {
+	return &openApiSchemaBuilder{
+		components: map[string]*OpenApiSchema{},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+// schemaFor returns the schema for a type. Named struct types are added to the components and
+// referenced from the returned schema.
+func (b *openApiSchemaBuilder) schemaFor(typ reflect.Type) *OpenApiSchema {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == openApiTimeType:
+		return &OpenApiSchema{Type: openApiTypeString, Format: "date-time"}
+	case typ == openApiDurationType:
+		return &OpenApiSchema{Type: openApiTypeInteger, Format: "int64"}
+	case typ.Implements(openApiJsonMarshalerType) || reflect.PointerTo(typ).Implements(openApiJsonMarshalerType):
+		// the JSON representation is up to the type, so we can't say anything about it
+		return &OpenApiSchema{}
+	case typ.Implements(openApiTextMarshalerType) || reflect.PointerTo(typ).Implements(openApiTextMarshalerType):
+		return &OpenApiSchema{Type: openApiTypeString}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &OpenApiSchema{Type: openApiTypeBoolean}
+	case reflect.Int, reflect.Int64:
+		return &OpenApiSchema{Type: openApiTypeInteger, Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &OpenApiSchema{Type: openApiTypeInteger, Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		minimum := 0.0
+
+		return &OpenApiSchema{Type: openApiTypeInteger, Minimum: &minimum}
+	case reflect.Float32:
+		return &OpenApiSchema{Type: openApiTypeNumber, Format: "float"}
+	case reflect.Float64:
+		return &OpenApiSchema{Type: openApiTypeNumber, Format: "double"}
+	case reflect.String:
+		return &OpenApiSchema{Type: openApiTypeString}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &OpenApiSchema{Type: openApiTypeString, ContentEncoding: "base64"}
+		}
+
+		return &OpenApiSchema{Type: openApiTypeArray, Items: b.schemaFor(typ.Elem())}
+	case reflect.Map:
+		return &OpenApiSchema{Type: openApiTypeObject, AdditionalProperties: b.schemaFor(typ.Elem())}
+	case reflect.Struct:
+		return b.structRef(typ)
+	default:
+		return &OpenApiSchema{}
+	}
+}
+
+func (b *openApiSchemaBuilder) structRef(typ reflect.Type) *OpenApiSchema {
+	if typ.Name() == "" {
+		return b.structSchema(typ, func(openApiField) bool { return true })
+	}
+
+	if name, ok := b.names[typ]; ok {
+		return &OpenApiSchema{Ref: openApiComponentRef(name)}
+	}
+
+	name := b.componentName(typ)
+	b.names[typ] = name
+	// register the name before building the schema to support recursive types
+	b.components[name] = nil
+	b.components[name] = b.structSchema(typ, func(openApiField) bool { return true })
+
+	return &OpenApiSchema{Ref: openApiComponentRef(name)}
+}
+
+func (b *openApiSchemaBuilder) structSchema(typ reflect.Type, include func(field openApiField) bool) *OpenApiSchema {
+	schema := &OpenApiSchema{
+		Type:       openApiTypeObject,
+		Properties: map[string]*OpenApiSchema{},
+	}
+
+	for _, field := range openApiJsonFields(typ) {
+		if !include(field) {
+			continue
+		}
+
+		fieldSchema := b.schemaFor(field.field.Type)
+
+		if field.rules != "" {
+			if required := applyOpenApiValidationRules(fieldSchema, field.field.Type, field.rules); required {
+				schema.Required = append(schema.Required, field.name)
+			}
+		}
+
+		schema.Properties[field.name] = fieldSchema
+	}
+
+	return schema
+}
+
+func (b *openApiSchemaBuilder) componentName(typ reflect.Type) string {
+	base := openApiComponentNameInvalidChars.ReplaceAllString(typ.Name(), "_")
+	base = strings.Trim(base, "_")
+	name := base
+
+	for i := 2; ; i++ {
+		if _, taken := b.components[name]; !taken {
+			return name
+		}
+
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+}
+
+func openApiComponentRef(name string) string {
+	return "#/components/schemas/" + name
+}
+
+// openApiJsonFields returns the fields of a struct as seen by encoding/json, including fields of
+// embedded structs.
+func openApiJsonFields(typ reflect.Type) []openApiField {
+	fields := make([]openApiField, 0, typ.NumField())
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, openApiJsonFields(embedded)...)
+
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields = append(fields, openApiField{
+			field: field,
+			name:  name,
+			rules: openApiValidationRules(field),
+		})
+	}
+
+	return fields
+}
+
+func openApiValidationRules(field reflect.StructField) string {
+	rules := make([]string, 0, 2)
+
+	for _, tag := range []string{"binding", "validate"} {
+		if value := field.Tag.Get(tag); value != "" && value != "-" {
+			rules = append(rules, value)
+		}
+	}
+
+	return strings.Join(rules, ",")
+}
+
+// applyOpenApiValidationRules maps validator rules to JSON schema constraints and reports whether the
+// field is required. Rules after "dive" apply to the elements of a slice or map.
+func applyOpenApiValidationRules(schema *OpenApiSchema, typ reflect.Type, rules string) bool {
+	required := false
+
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	parts := strings.Split(rules, ",")
+	for i, rule := range parts {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "dive":
+			if schema.Items != nil {
+				applyOpenApiValidationRules(schema.Items, typ.Elem(), strings.Join(parts[i+1:], ","))
+			} else if schema.AdditionalProperties != nil {
+				applyOpenApiValidationRules(schema.AdditionalProperties, typ.Elem(), strings.Join(parts[i+1:], ","))
+			}
+
+			return required
+		case "required":
+			required = true
+		case "oneof":
+			schema.Enum = openApiEnumValues(typ, param)
+		case "len":
+			applyOpenApiBound(schema, typ, param, true, false)
+			applyOpenApiBound(schema, typ, param, false, false)
+		case "min", "gte":
+			applyOpenApiBound(schema, typ, param, true, false)
+		case "max", "lte":
+			applyOpenApiBound(schema, typ, param, false, false)
+		case "gt":
+			applyOpenApiBound(schema, typ, param, true, true)
+		case "lt":
+			applyOpenApiBound(schema, typ, param, false, true)
+		default:
+			if format, ok := openApiValidationFormats[name]; ok {
+				schema.Format = format
+			}
+
+			if pattern, ok := openApiValidationPatterns[name]; ok {
+				schema.Pattern = pattern
+			}
+		}
+	}
+
+	return required
+}
+
+func applyOpenApiBound(schema *OpenApiSchema, typ reflect.Type, param string, lower bool, exclusive bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		switch {
+		case lower && exclusive:
+			schema.ExclusiveMinimum = &value
+		case lower:
+			schema.Minimum = &value
+		case exclusive:
+			schema.ExclusiveMaximum = &value
+		default:
+			schema.Maximum = &value
+		}
+
+		return
+	}
+
+	count := int(value)
+	switch {
+	case exclusive && lower:
+		count++
+	case exclusive:
+		count--
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		setOpenApiCount(&schema.MinLength, &schema.MaxLength, count, lower)
+	case reflect.Slice, reflect.Array:
+		setOpenApiCount(&schema.MinItems, &schema.MaxItems, count, lower)
+	case reflect.Map:
+		setOpenApiCount(&schema.MinProperties, &schema.MaxProperties, count, lower)
+	}
+}
+
+func setOpenApiCount(minimum **int, maximum **int, count int, lower bool) {
+	if lower {
+		*minimum = &count
+
+		return
+	}
+
+	*maximum = &count
+}
+
+func openApiEnumValues(typ reflect.Type, param string) []any {
+	values := strings.Fields(param)
+	result := make([]any, 0, len(values))
+
+	for _, value := range values {
+		value = strings.Trim(value, "'")
+
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+				result = append(result, parsed)
+
+				continue
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if parsed, err := strconv.ParseUint(value, 10, 64); err == nil {
+				result = append(result, parsed)
+
+				continue
+			}
+		}
+
+		result = append(result, value)
+	}
+
+	return result
+}
@@ -0,0 +1,230 @@
+package httpserver_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	"github.com/justtrackio/gosoline/pkg/encoding/json"
+	"github.com/justtrackio/gosoline/pkg/log"
+	logMocks "github.com/justtrackio/gosoline/pkg/log/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type openApiCreateUserInput struct {
+	TeamId int      `uri:"team_id"`
+	Trace  string   `header:"X-Trace-Id" binding:"required"`
+	DryRun bool     `form:"dry_run,default=false"`
+	Name   string   `json:"name" binding:"required,min=3,max=32"`
+	Email  string   `json:"email" binding:"omitempty,email"`
+	Role   string   `json:"role" binding:"oneof=admin member"`
+	Tags   []string `json:"tags" binding:"max=5,dive,min=1"`
+	Age    int      `json:"age" validate:"gte=18,lt=130"`
+}
+
+type openApiUser struct {
+	Id        int              `json:"id"`
+	Name      string           `json:"name"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Friends   []openApiUser    `json:"friends,omitempty"`
+	Labels    map[string]int64 `json:"labels"`
+	Avatar    []byte           `json:"avatar"`
+	internal  string
+}
+
+func TestBuildOpenApiDocument(t *testing.T) {
+	createUser := httpserver.Describe(
+		httpserver.Bind(func(ctx context.Context, input *openApiCreateUserInput) (httpserver.Response, error) {
+			return httpserver.NewJsonResponse(openApiUser{internal: input.Name}), nil
+		}),
+		httpserver.WithRouteSummary("create a user"),
+		httpserver.WithRouteTags("users"),
+		httpserver.WithRouteResponse[openApiUser](http.StatusCreated, "the created user"),
+	)
+	listUsers := httpserver.BindN(func(ctx context.Context) (httpserver.Response, error) {
+		return httpserver.NewJsonResponse([]openApiUser{}), nil
+	})
+
+	definitions := []httpserver.Definition{
+		{
+			Group:        &httpserver.Router{},
+			HttpMethod:   http.MethodPost,
+			RelativePath: "/teams/:team_id/users",
+			Handlers:     []gin.HandlerFunc{createUser},
+			Spec:         httpserver.GetRouteSpec(createUser),
+		},
+		{
+			Group:        &httpserver.Router{},
+			HttpMethod:   http.MethodGet,
+			RelativePath: "/teams/:team_id/users",
+			Handlers:     []gin.HandlerFunc{listUsers},
+			Spec:         httpserver.GetRouteSpec(listUsers),
+		},
+	}
+
+	document := httpserver.BuildOpenApiDocument(httpserver.OpenApiInfo{Title: "api", Version: "1.2.3"}, definitions)
+	actual, err := json.Marshal(document)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"openapi": "3.1.0",
+		"info": {"title": "api", "version": "1.2.3"},
+		"paths": {
+			"/teams/{team_id}/users": {
+				"post": {
+					"operationId": "postTeamsByTeamIdUsers",
+					"summary": "create a user",
+					"tags": ["users"],
+					"parameters": [
+						{"name": "team_id", "in": "path", "required": true, "schema": {"type": "integer", "format": "int64"}},
+						{"name": "X-Trace-Id", "in": "header", "required": true, "schema": {"type": "string"}},
+						{"name": "dry_run", "in": "query", "schema": {"type": "boolean", "default": "false"}}
+					],
+					"requestBody": {
+						"required": true,
+						"content": {
+							"application/json": {
+								"schema": {
+									"type": "object",
+									"required": ["name"],
+									"properties": {
+										"name": {"type": "string", "minLength": 3, "maxLength": 32},
+										"email": {"type": "string", "format": "email"},
+										"role": {"type": "string", "enum": ["admin", "member"]},
+										"tags": {"type": "array", "maxItems": 5, "items": {"type": "string", "minLength": 1}},
+										"age": {"type": "integer", "format": "int64", "minimum": 18, "exclusiveMaximum": 130}
+									}
+								}
+							}
+						}
+					},
+					"responses": {
+						"201": {
+							"description": "the created user",
+							"content": {"application/json": {"schema": {"$ref": "#/components/schemas/openApiUser"}}}
+						},
+						"400": {
+							"description": "Bad Request",
+							"content": {
+								"application/json": {"schema": {
+									"type": "object",
+									"required": ["err"],
+									"properties": {
+										"err": {"type": "string"},
+										"violations": {"type": "array", "items": {"$ref": "#/components/schemas/FieldViolation"}}
+									}
+								}},
+								"application/problem+json": {"schema": {
+									"type": "object",
+									"properties": {
+										"type": {"type": "string"},
+										"title": {"type": "string"},
+										"status": {"type": "integer", "format": "int64"},
+										"detail": {"type": "string"},
+										"instance": {"type": "string"},
+										"violations": {"type": "array", "items": {"$ref": "#/components/schemas/FieldViolation"}}
+									}
+								}}
+							}
+						}
+					}
+				},
+				"get": {
+					"operationId": "getTeamsByTeamIdUsers",
+					"parameters": [
+						{"name": "team_id", "in": "path", "required": true, "schema": {"type": "string"}}
+					],
+					"responses": {
+						"200": {"description": "OK"}
+					}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"FieldViolation": {
+					"type": "object",
+					"properties": {
+						"field": {"type": "string"},
+						"rule": {"type": "string"},
+						"param": {"type": "string"},
+						"message": {"type": "string"}
+					}
+				},
+				"openApiUser": {
+					"type": "object",
+					"properties": {
+						"id": {"type": "integer", "format": "int64"},
+						"name": {"type": "string"},
+						"createdAt": {"type": "string", "format": "date-time"},
+						"friends": {"type": "array", "items": {"$ref": "#/components/schemas/openApiUser"}},
+						"labels": {"type": "object", "additionalProperties": {"type": "integer", "format": "int64"}},
+						"avatar": {"type": "string", "contentEncoding": "base64"}
+					}
+				}
+			}
+		}
+	}`, string(actual))
+}
+
+func TestGenerateOpenApiDocument(t *testing.T) {
+	logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+	config := cfg.New(map[string]any{
+		"httpserver": map[string]any{
+			"default": map[string]any{
+				"openapi": map[string]any{
+					"title": "my api",
+				},
+			},
+		},
+	})
+
+	document, err := httpserver.GenerateOpenApiDocument(t.Context(), config, logger, "default", func(ctx context.Context, config cfg.Config, logger log.Logger, router *httpserver.Router) error {
+		v1 := router.Group("/v1")
+		v1.GET("/files/*path", func(c *gin.Context) {})
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, httpserver.OpenApiInfo{Title: "my api", Version: "1.0.0"}, document.Info)
+	require.Contains(t, document.Paths, "/v1/files/{path}")
+	operation := document.Paths["/v1/files/{path}"]["get"]
+	require.NotNil(t, operation)
+	assert.Equal(t, "getV1FilesByPath", operation.OperationId)
+	assert.Equal(t, []*httpserver.OpenApiParameter{
+		{Name: "path", In: "path", Required: true, Schema: &httpserver.OpenApiSchema{Type: "string"}},
+	}, operation.Parameters)
+}
+
+func TestDescribeKeepsHandlerBehavior(t *testing.T) {
+	handler := httpserver.Describe(httpserver.BindN(func(ctx context.Context) (httpserver.Response, error) {
+		return httpserver.NewTextResponse("described"), nil
+	}), httpserver.WithRouteDeprecated())
+
+	response := httpserver.HttpTest(http.MethodGet, "/", "/", "", handler)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "described", response.Body.String())
+	assert.True(t, httpserver.GetRouteSpec(handler).Deprecated)
+	assert.Nil(t, httpserver.GetRouteSpec(func(c *gin.Context) {}))
+}
+
+func TestGetRouteSpecAfterGarbageCollection(t *testing.T) {
+	for i := range 100 {
+		operationId := fmt.Sprintf("operation-%d", i)
+		handler := httpserver.Describe(func(c *gin.Context) {}, httpserver.WithRouteOperationId(operationId))
+
+		runtime.GC()
+
+		assert.Nil(t, httpserver.GetRouteSpec(func(c *gin.Context) {}))
+		assert.Equal(t, operationId, httpserver.GetRouteSpec(handler).OperationId)
+	}
+}
@@ -0,0 +1,132 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/justtrackio/gosoline/pkg/encoding/json"
+)
+
+// ProblemTypeDefault is the problem type used if a problem does not define a more specific one (RFC 9457 section 4.2.1).
+const ProblemTypeDefault = "about:blank"
+
+// Problem is an RFC 9457 problem details object. A Problem is an error carrying its status code, so handlers can
+// return it to control the error response rendered by the error middleware.
+type Problem struct {
+	// Type is a URI reference identifying the problem type.
+	Type string
+	// Title is a short summary of the problem type.
+	Title string
+	// Status is the HTTP status code of the response.
+	Status int
+	// Detail is an explanation specific to this occurrence of the problem.
+	Detail string
+	// Instance is a URI reference identifying this occurrence of the problem.
+	Instance string
+	// Extensions are additional members written next to the standard members.
+	Extensions map[string]any
+}
+
+var (
+	_ ErrorWithStatus = &Problem{}
+	_ json.Marshaler  = &Problem{}
+)
+
+// NewProblem creates a problem of the default type with the status text as title.
+func NewProblem(statusCode int, detail string) *Problem {
+	return &Problem{
+		Type:   ProblemTypeDefault,
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+	}
+}
+
+// GetProblem returns the problem carried by err with the given status code, or creates a new problem
+// from the message of err otherwise. Field violations of bind failures are added as "violations" member.
+func GetProblem(statusCode int, err error) *Problem {
+	var problem *Problem
+
+	if !errors.As(err, &problem) {
+		problem = NewProblem(statusCode, err.Error())
+
+		if violations := GetFieldViolations(err); violations != nil {
+			problem.WithExtension("violations", violations)
+		}
+
+		return problem
+	}
+
+	result := *problem
+	result.Status = statusCode
+
+	if result.Type == "" {
+		result.Type = ProblemTypeDefault
+	}
+
+	if result.Title == "" && result.Type == ProblemTypeDefault {
+		result.Title = http.StatusText(statusCode)
+	}
+
+	return &result
+}
+
+// WithExtension sets an extension member of the problem.
+func (p *Problem) WithExtension(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+
+	p.Extensions[key] = value
+
+	return p
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+
+	return p.Title
+}
+
+func (p *Problem) StatusCode() int {
+	return p.Status
+}
+
+// MarshalJSON writes the standard members and the extension members into one object. Extension members
+// can't override the standard members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+
+	for key, value := range map[string]string{"type": p.Type, "title": p.Title, "detail": p.Detail, "instance": p.Instance} {
+		if value != "" {
+			members[key] = value
+		} else {
+			delete(members, key)
+		}
+	}
+
+	delete(members, "status")
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+
+	return json.Marshal(members)
+}
+
+// NewProblemResponse creates an application/problem+json response with the status of the problem.
+func NewProblemResponse(problem *Problem, options ...ResponseOption) *jsonResponse[*Problem] {
+	responseOptions := append([]ResponseOption{
+		WithStatusCode(problem.Status),
+		func(r *response) {
+			r.header.Set(HeaderContentType, ContentTypeProblemJson)
+		},
+	}, options...)
+
+	return NewJsonResponse(problem, responseOptions...)
+}
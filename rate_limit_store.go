@@ -0,0 +1,158 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/justtrackio/gosoline/pkg/cache"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	"github.com/justtrackio/gosoline/pkg/clock"
+	"github.com/justtrackio/gosoline/pkg/log"
+)
+
+const (
+	// RateLimitStoreInMemory keeps the buckets in the memory of the server.
+	RateLimitStoreInMemory = "in_memory"
+
+	rateLimitStoreMaxSize   = 65535
+	rateLimitStorePruneSize = 1000
+)
+
+//go:generate go run github.com/vektra/mockery/v2 --name RateLimitStore --with-expecter
+type (
+	// RateLimitStore keeps the token buckets of the rate limit middleware.
+	RateLimitStore interface {
+		// Take removes one token from the bucket of key and reports the state of the bucket afterward.
+		Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+	}
+
+	// RateLimitStoreFactory creates a RateLimitStore for a server.
+	RateLimitStoreFactory func(ctx context.Context, config cfg.Config, logger log.Logger, settings RateLimitSettings) (RateLimitStore, error)
+
+	// RateLimit describes a token bucket: it holds up to Burst tokens and is refilled with Rate tokens per Period.
+	RateLimit struct {
+		Rate   int
+		Period time.Duration
+		Burst  int
+	}
+
+	// RateLimitResult is the state of a bucket after taking a token.
+	RateLimitResult struct {
+		// Allowed reports whether a token was available.
+		Allowed bool
+		// Remaining is the number of tokens left in the bucket.
+		Remaining int
+		// RetryAfter is the time until the next token is available if the request was not allowed.
+		RetryAfter time.Duration
+		// Reset is the time until the bucket is full again.
+		Reset time.Duration
+	}
+
+	inMemoryRateLimitStore struct {
+		clock   clock.Clock
+		buckets cache.Cache[tokenBucket]
+	}
+
+	tokenBucket struct {
+		tokens    float64
+		updatedAt time.Time
+	}
+)
+
+var (
+	rateLimitStoreFactoriesLck sync.RWMutex
+	rateLimitStoreFactories    = map[string]RateLimitStoreFactory{
+		RateLimitStoreInMemory: func(_ context.Context, _ cfg.Config, _ log.Logger, _ RateLimitSettings) (RateLimitStore, error) {
+			return NewInMemoryRateLimitStore(), nil
+		},
+	}
+)
+
+// AddRateLimitStoreFactory makes a store available to the rate limit settings of all servers under the given name.
+func AddRateLimitStoreFactory(name string, factory RateLimitStoreFactory) {
+	rateLimitStoreFactoriesLck.Lock()
+	defer rateLimitStoreFactoriesLck.Unlock()
+
+	rateLimitStoreFactories[name] = factory
+}
+
+func newRateLimitStore(ctx context.Context, config cfg.Config, logger log.Logger, settings RateLimitSettings) (RateLimitStore, error) {
+	rateLimitStoreFactoriesLck.RLock()
+	factory, ok := rateLimitStoreFactories[settings.Store]
+	rateLimitStoreFactoriesLck.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("there is no rate limit store with the name %q", settings.Store)
+	}
+
+	return factory(ctx, config, logger, settings)
+}
+
+// Capacity returns the number of tokens a full bucket holds.
+func (l RateLimit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+
+	return l.Rate
+}
+
+// Interval returns the time it takes to refill one token.
+func (l RateLimit) Interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// NewInMemoryRateLimitStore creates a store keeping the buckets in memory. Buckets are dropped once they are full again.
+func NewInMemoryRateLimitStore() RateLimitStore {
+	return NewInMemoryRateLimitStoreWithInterfaces(clock.Provider)
+}
+
+// NewInMemoryRateLimitStoreWithInterfaces creates an in-memory store using the provided clock.
+func NewInMemoryRateLimitStoreWithInterfaces(providedClock clock.Clock) RateLimitStore {
+	return &inMemoryRateLimitStore{
+		clock:   providedClock,
+		buckets: cache.New[tokenBucket](rateLimitStoreMaxSize, rateLimitStorePruneSize, time.Minute),
+	}
+}
+
+func (s *inMemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	var result RateLimitResult
+
+	now := s.clock.Now()
+	capacity := float64(limit.Capacity())
+	interval := limit.Interval()
+
+	// the bucket is only needed until it is full again, a missing bucket is treated as full
+	ttl := time.Duration(capacity*float64(interval)) + time.Second
+
+	s.buckets.MutateX(key, func(bucket *tokenBucket) tokenBucket {
+		if bucket == nil {
+			bucket = &tokenBucket{
+				tokens:    capacity,
+				updatedAt: now,
+			}
+		}
+
+		tokens := math.Min(capacity, bucket.tokens+float64(now.Sub(bucket.updatedAt))/float64(interval))
+
+		if tokens >= 1 {
+			tokens--
+			result.Allowed = true
+		} else {
+			result.RetryAfter = time.Duration((1 - tokens) * float64(interval))
+		}
+
+		result.Remaining = int(tokens)
+		result.Reset = time.Duration((capacity - tokens) * float64(interval))
+
+		return tokenBucket{
+			tokens:    tokens,
+			updatedAt: now,
+		}
+	}, ttl)
+
+	return result, nil
+}
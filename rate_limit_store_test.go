@@ -0,0 +1,56 @@
+package httpserver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gosoline-project/httpserver"
+	"github.com/justtrackio/gosoline/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRateLimitStore(t *testing.T) {
+	fakeClock := clock.NewFakeClock()
+	store := httpserver.NewInMemoryRateLimitStoreWithInterfaces(fakeClock)
+	limit := httpserver.RateLimit{Rate: 2, Period: time.Minute, Burst: 3}
+
+	for i := range 3 {
+		result, err := store.Take(t.Context(), "client", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	result, err := store.Take(t.Context(), "client", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+	assert.Equal(t, 90*time.Second, result.Reset)
+
+	result, err = store.Take(t.Context(), "other", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed, "buckets are kept per key")
+
+	fakeClock.Advance(30 * time.Second)
+
+	result, err = store.Take(t.Context(), "client", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	fakeClock.Advance(10 * time.Minute)
+
+	result, err = store.Take(t.Context(), "client", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining, "a refilled bucket doesn't exceed its burst")
+}
+
+func TestRateLimitCapacityDefaultsToRate(t *testing.T) {
+	limit := httpserver.RateLimit{Rate: 10, Period: time.Second}
+
+	assert.Equal(t, 10, limit.Capacity())
+	assert.Equal(t, 100*time.Millisecond, limit.Interval())
+}
@@ -1,6 +1,8 @@
 package httpserver
 
 import (
+	"encoding/xml"
+	"fmt"
 	"net/http"
 
 	"github.com/justtrackio/gosoline/pkg/encoding/json"
@@ -14,12 +16,27 @@ type Response interface {
 	StatusCode() int
 }
 
+// bodyETag selects whether and how the entity tag of a response is computed from its encoded body.
+type bodyETag int
+
+const (
+	bodyETagNone bodyETag = iota
+	bodyETagStrong
+	bodyETagWeak
+)
+
+// bodyETagResponse is implemented by responses created with WithBodyETag or WithWeakBodyETag.
+type bodyETagResponse interface {
+	getBodyETag() bodyETag
+}
+
 var _ Response = &response{}
 
 type response struct {
 	body       []byte
 	header     http.Header
 	statusCode int
+	bodyETag   bodyETag
 }
 
 // NewResponse creates a response with an empty body and status 200 by default.
@@ -57,6 +74,10 @@ func (r response) StatusCode() int {
 	return r.statusCode
 }
 
+func (r response) getBodyETag() bodyETag {
+	return r.bodyETag
+}
+
 // NewStatusResponse creates a response with an HTTP status code. For client or
 // server error responses, it includes the standard status text as plain-text body.
 func NewStatusResponse(statusCode int, options ...ResponseOption) *response {
@@ -142,3 +163,126 @@ func (j jsonResponse[T]) Header() http.Header {
 func (j jsonResponse[T]) StatusCode() int {
 	return j.statusCode
 }
+
+var _ Response = &xmlResponse[string]{}
+
+type xmlResponse[T any] struct {
+	*response
+	body T
+}
+
+// NewXmlResponse creates a XML response with status 200 by default.
+func NewXmlResponse[T any](body T, options ...ResponseOption) *xmlResponse[T] {
+	header := make(http.Header)
+	header.Set(HeaderContentType, ContentTypeXml)
+
+	resp := &xmlResponse[T]{
+		response: &response{
+			header:     header,
+			statusCode: http.StatusOK,
+		},
+		body: body,
+	}
+
+	for _, option := range options {
+		option(resp.response)
+	}
+
+	return resp
+}
+
+func (x xmlResponse[T]) Body() ([]byte, error) {
+	return xml.Marshal(x.body)
+}
+
+var _ Response = &protobufResponse{}
+
+type protobufResponse struct {
+	*response
+	body ProtobufEncodable
+}
+
+// NewProtobufResponse creates a protobuf response with status 200 by default.
+func NewProtobufResponse(body ProtobufEncodable, options ...ResponseOption) *protobufResponse {
+	header := make(http.Header)
+	header.Set(HeaderContentType, ContentTypeProtobuf)
+
+	resp := &protobufResponse{
+		response: &response{
+			header:     header,
+			statusCode: http.StatusOK,
+		},
+		body: body,
+	}
+
+	for _, option := range options {
+		option(resp.response)
+	}
+
+	return resp
+}
+
+func (p protobufResponse) Body() ([]byte, error) {
+	return encodeProtobufValue(p.body)
+}
+
+// negotiableResponse is a response whose encoding is chosen by BindHandleResponse based on the request.
+type negotiableResponse interface {
+	Response
+	negotiate(request *http.Request, defaultMediaType string) (Response, error)
+}
+
+var _ negotiableResponse = &negotiatedResponse[string]{}
+
+type negotiatedResponse[T any] struct {
+	*response
+	body T
+}
+
+// NewNegotiatedResponse creates a response with status 200 by default, which is encoded as JSON, XML, or
+// protobuf (for ProtobufEncodable bodies) depending on the Accept header of the request. If the client sent no
+// Accept header, the default content type of the server is used. If none of the accepted media types can be
+// produced, 406 Not Acceptable is returned instead.
+func NewNegotiatedResponse[T any](body T, options ...ResponseOption) *negotiatedResponse[T] {
+	resp := &negotiatedResponse[T]{
+		response: &response{
+			header:     make(http.Header),
+			statusCode: http.StatusOK,
+		},
+		body: body,
+	}
+
+	for _, option := range options {
+		option(resp.response)
+	}
+
+	return resp
+}
+
+func (n negotiatedResponse[T]) Body() ([]byte, error) {
+	return jsonResponseEncoding.encode(n.body)
+}
+
+func (n negotiatedResponse[T]) negotiate(request *http.Request, defaultMediaType string) (Response, error) {
+	var err error
+	var encoding responseEncoding
+	var body []byte
+
+	if encoding, err = negotiateResponseEncoding(request.Header.Get(HeaderAccept), n.body, defaultMediaType); err != nil {
+		return nil, err
+	}
+
+	if body, err = encoding.encode(n.body); err != nil {
+		return nil, fmt.Errorf("failed to encode response of type %T: %w", n.body, err)
+	}
+
+	header := n.header.Clone()
+	header.Set(HeaderContentType, encoding.contentType)
+
+	return &response{
+		body:       body,
+		header:     header,
+		statusCode: n.statusCode,
+		bodyETag:   n.bodyETag,
+	}, nil
+}
@@ -0,0 +1,100 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/justtrackio/gosoline/pkg/cache"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	"github.com/justtrackio/gosoline/pkg/log"
+)
+
+// ResponseCacheStoreInMemory keeps the responses in the memory of the server and evicts the least recently used ones.
+const ResponseCacheStoreInMemory = "in_memory"
+
+//go:generate go run github.com/vektra/mockery/v2 --name ResponseCacheStore --with-expecter
+type (
+	// ResponseCacheStore keeps the cached responses of a server.
+	ResponseCacheStore interface {
+		// Get returns the response stored for key or nil if there is none.
+		Get(ctx context.Context, key string) (*CachedResponse, error)
+		// Set stores the response for key. The store can drop it after ttl.
+		Set(ctx context.Context, key string, response CachedResponse, ttl time.Duration) error
+	}
+
+	// ResponseCacheStoreFactory creates a ResponseCacheStore for a server.
+	ResponseCacheStoreFactory func(ctx context.Context, config cfg.Config, logger log.Logger, settings ResponseCacheSettings) (ResponseCacheStore, error)
+
+	// CachedResponse is a response stored by the response cache. A response with a Vary header is stored under a key
+	// including the values of the listed request headers. The key of the request without them stores a response with
+	// only Vary set, which tells the cache how to find the matching variant.
+	CachedResponse struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header"`
+		Body       []byte      `json:"body"`
+		Vary       []string    `json:"vary"`
+		// StoredAt is the time the response was created. The response is fresh until Expires and can be served while
+		// it is revalidated until StaleUntil.
+		StoredAt   time.Time `json:"stored_at"`
+		Expires    time.Time `json:"expires"`
+		StaleUntil time.Time `json:"stale_until"`
+	}
+
+	inMemoryResponseCacheStore struct {
+		responses cache.Cache[CachedResponse]
+	}
+)
+
+var (
+	responseCacheStoreFactoriesLck sync.RWMutex
+	responseCacheStoreFactories    = map[string]ResponseCacheStoreFactory{
+		ResponseCacheStoreInMemory: func(_ context.Context, _ cfg.Config, _ log.Logger, settings ResponseCacheSettings) (ResponseCacheStore, error) {
+			return NewInMemoryResponseCacheStore(settings.MaxEntries), nil
+		},
+	}
+)
+
+// AddResponseCacheStoreFactory makes a store available to the response cache settings of all servers under the given name.
+func AddResponseCacheStoreFactory(name string, factory ResponseCacheStoreFactory) {
+	responseCacheStoreFactoriesLck.Lock()
+	defer responseCacheStoreFactoriesLck.Unlock()
+
+	responseCacheStoreFactories[name] = factory
+}
+
+func newResponseCacheStore(ctx context.Context, config cfg.Config, logger log.Logger, settings ResponseCacheSettings) (ResponseCacheStore, error) {
+	responseCacheStoreFactoriesLck.RLock()
+	factory, ok := responseCacheStoreFactories[settings.Store]
+	responseCacheStoreFactoriesLck.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("there is no response cache store with the name %q", settings.Store)
+	}
+
+	return factory(ctx, config, logger, settings)
+}
+
+// NewInMemoryResponseCacheStore creates a store keeping up to maxEntries responses in memory. The least recently used
+// responses are evicted first once the store is full.
+func NewInMemoryResponseCacheStore(maxEntries int) ResponseCacheStore {
+	return &inMemoryResponseCacheStore{
+		responses: cache.New[CachedResponse](int64(maxEntries), uint32(max(maxEntries/100, 1)), time.Minute),
+	}
+}
+
+func (s *inMemoryResponseCacheStore) Get(_ context.Context, key string) (*CachedResponse, error) {
+	if response, ok := s.responses.Get(key); ok {
+		return &response, nil
+	}
+
+	return nil, nil
+}
+
+func (s *inMemoryResponseCacheStore) Set(_ context.Context, key string, response CachedResponse, ttl time.Duration) error {
+	s.responses.SetX(key, response, ttl)
+
+	return nil
+}
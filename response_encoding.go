@@ -0,0 +1,220 @@
+package httpserver
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/justtrackio/gosoline/pkg/encoding/json"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotAcceptable is returned if none of the media types accepted by the client can be produced for a response.
+var ErrNotAcceptable = errors.New("none of the accepted media types can be produced")
+
+// responseEncoding encodes response values for one media type.
+type responseEncoding struct {
+	mediaTypes  []string
+	contentType string
+	supports    func(value any) bool
+	encode      func(value any) ([]byte, error)
+}
+
+var (
+	jsonResponseEncoding = responseEncoding{
+		mediaTypes:  []string{binding.MIMEJSON},
+		contentType: ContentTypeJson,
+		supports:    func(any) bool { return true },
+		encode:      json.Marshal,
+	}
+	xmlResponseEncoding = responseEncoding{
+		mediaTypes:  []string{binding.MIMEXML, binding.MIMEXML2},
+		contentType: ContentTypeXml,
+		supports:    func(any) bool { return true },
+		encode:      xml.Marshal,
+	}
+	protobufResponseEncoding = responseEncoding{
+		mediaTypes:  []string{binding.MIMEPROTOBUF},
+		contentType: ContentTypeProtobuf,
+		supports: func(value any) bool {
+			_, ok := value.(ProtobufEncodable)
+
+			return ok
+		},
+		encode: encodeProtobufValue,
+	}
+)
+
+// responseEncodings lists the supported encodings in the order of preference if the client accepts several of them equally.
+var responseEncodings = []responseEncoding{jsonResponseEncoding, xmlResponseEncoding, protobufResponseEncoding}
+
+// acceptedMediaType is a single media range of an Accept header.
+type acceptedMediaType struct {
+	mediaType string
+	quality   float64
+}
+
+// specificity ranks exact media types over type/* ranges over */*.
+func (a acceptedMediaType) specificity() int {
+	switch {
+	case a.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(a.mediaType, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (a acceptedMediaType) matches(mediaType string) bool {
+	switch a.specificity() {
+	case 0:
+		return true
+	case 1:
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(a.mediaType, "*"))
+	default:
+		return a.mediaType == mediaType
+	}
+}
+
+// parseAccept parses the media ranges and q-values of an Accept header. Ranges with an invalid q-value are ignored.
+func parseAccept(header string) []acceptedMediaType {
+	accepted := make([]acceptedMediaType, 0)
+
+	for part := range strings.SplitSeq(header, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+
+		if mediaType == "" {
+			continue
+		}
+
+		if mediaType == "*" {
+			mediaType = "*/*"
+		}
+
+		entry := acceptedMediaType{
+			mediaType: mediaType,
+			quality:   1,
+		}
+
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if !strings.EqualFold(strings.TrimSpace(key), "q") {
+				continue
+			}
+
+			quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || quality < 0 || quality > 1 {
+				entry.quality = -1
+			} else {
+				entry.quality = quality
+			}
+		}
+
+		if entry.quality >= 0 {
+			accepted = append(accepted, entry)
+		}
+	}
+
+	return accepted
+}
+
+// acceptQuality returns the q-value and specificity of the most specific media range matching mediaType.
+// A specificity of -1 means no range matches.
+func acceptQuality(accepted []acceptedMediaType, mediaType string) (quality float64, specificity int) {
+	specificity = -1
+
+	for _, entry := range accepted {
+		if entry.matches(mediaType) && entry.specificity() > specificity {
+			quality = entry.quality
+			specificity = entry.specificity()
+		}
+	}
+
+	return quality, specificity
+}
+
+// getResponseEncoding returns the encoding producing the given media type.
+func getResponseEncoding(mediaType string) (responseEncoding, bool) {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	for _, encoding := range responseEncodings {
+		if slices.Contains(encoding.mediaTypes, mediaType) {
+			return encoding, true
+		}
+	}
+
+	return responseEncoding{}, false
+}
+
+// negotiateResponseEncoding picks the encoding for value with the highest q-value in the accept header. Ties are broken
+// by the specificity of the matching media range, then by the default media type, then by the order of responseEncodings.
+// The default encoding is used if the client sent no Accept header. ErrNotAcceptable is returned if nothing matches.
+func negotiateResponseEncoding(accept string, value any, defaultMediaType string) (responseEncoding, error) {
+	defaultEncoding, ok := getResponseEncoding(defaultMediaType)
+	if !ok || !defaultEncoding.supports(value) {
+		defaultEncoding = jsonResponseEncoding
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return defaultEncoding, nil
+	}
+
+	accepted := parseAccept(accept)
+
+	var best *responseEncoding
+	bestQuality, bestSpecificity := 0.0, -1
+
+	for _, encoding := range responseEncodings {
+		if !encoding.supports(value) {
+			continue
+		}
+
+		quality, specificity := 0.0, -1
+		for _, mediaType := range encoding.mediaTypes {
+			if q, s := acceptQuality(accepted, mediaType); s >= 0 && (q > quality || q == quality && s > specificity) {
+				quality, specificity = q, s
+			}
+		}
+
+		if quality <= 0 {
+			continue
+		}
+
+		isBetter := best == nil || quality > bestQuality ||
+			quality == bestQuality && specificity > bestSpecificity ||
+			quality == bestQuality && specificity == bestSpecificity && encoding.contentType == defaultEncoding.contentType
+
+		if isBetter {
+			best, bestQuality, bestSpecificity = &encoding, quality, specificity
+		}
+	}
+
+	if best == nil {
+		return responseEncoding{}, NewErrorWithStatus(http.StatusNotAcceptable, ErrNotAcceptable)
+	}
+
+	return *best, nil
+}
+
+func encodeProtobufValue(value any) ([]byte, error) {
+	var err error
+	var message proto.Message
+
+	encodable, ok := value.(ProtobufEncodable)
+	if !ok {
+		return nil, fmt.Errorf("value of type %T is not protobuf encodable", value)
+	}
+
+	if message, err = encodable.ToMessage(); err != nil {
+		return nil, fmt.Errorf("failed to convert body to message: %w", err)
+	}
+
+	return proto.Marshal(message)
+}
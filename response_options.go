@@ -1,6 +1,10 @@
 package httpserver
 
-import "net/http"
+import (
+	"mime"
+	"net/http"
+	"time"
+)
 
 // ResponseOption modifies a response while it is being created.
 type ResponseOption func(*response)
@@ -36,3 +40,65 @@ func WithStatusCode(statusCode int) ResponseOption {
 		r.statusCode = statusCode
 	}
 }
+
+// WithETag sets a strong entity tag for the response. The tag is quoted if it isn't quoted already.
+func WithETag(tag string) ResponseOption {
+	return func(r *response) {
+		r.header.Set(HeaderETag, formatETag(tag, false))
+		r.bodyETag = bodyETagNone
+	}
+}
+
+// WithWeakETag sets a weak entity tag for the response, which only marks semantically equivalent representations.
+func WithWeakETag(tag string) ResponseOption {
+	return func(r *response) {
+		r.header.Set(HeaderETag, formatETag(tag, true))
+		r.bodyETag = bodyETagNone
+	}
+}
+
+// WithBodyETag computes a strong entity tag from the hash of the encoded response body.
+func WithBodyETag() ResponseOption {
+	return func(r *response) {
+		r.header.Del(HeaderETag)
+		r.bodyETag = bodyETagStrong
+	}
+}
+
+// WithWeakBodyETag computes a weak entity tag from the hash of the encoded response body.
+func WithWeakBodyETag() ResponseOption {
+	return func(r *response) {
+		r.header.Del(HeaderETag)
+		r.bodyETag = bodyETagWeak
+	}
+}
+
+// WithAttachment sets the Content-Disposition header so clients download the response as a file with the given name.
+// Names with characters outside of US-ASCII are encoded as described by RFC 2231.
+func WithAttachment(filename string) ResponseOption {
+	return withContentDisposition("attachment", filename)
+}
+
+// WithInline sets the Content-Disposition header so clients display the response, using the given name if it is saved.
+func WithInline(filename string) ResponseOption {
+	return withContentDisposition("inline", filename)
+}
+
+func withContentDisposition(disposition string, filename string) ResponseOption {
+	return func(r *response) {
+		if filename == "" {
+			r.header.Set(HeaderContentDisposition, disposition)
+
+			return
+		}
+
+		r.header.Set(HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": filename}))
+	}
+}
+
+// WithLastModified sets the Last-Modified header of the response. The time is sent with a precision of seconds.
+func WithLastModified(lastModified time.Time) ResponseOption {
+	return func(r *response) {
+		r.header.Set(HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+}
@@ -0,0 +1,195 @@
+package httpserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	// responseRecorder records the response of a handler while it is written, so middlewares like the idempotency
+	// guard or the response cache can store it.
+	responseRecorder struct {
+		gin.ResponseWriter
+		header   http.Header
+		body     bytes.Buffer
+		maxBytes int64
+		overflow bool
+		streamed bool
+	}
+
+	// bufferResponseWriter collects the response of a handler which runs without a client, like the background
+	// revalidation of a cached response.
+	bufferResponseWriter struct {
+		header http.Header
+		body   bytes.Buffer
+		status int
+		size   int
+	}
+)
+
+func newResponseRecorder(writer gin.ResponseWriter, maxBytes int64) *responseRecorder {
+	return &responseRecorder{
+		ResponseWriter: writer,
+		maxBytes:       maxBytes,
+	}
+}
+
+// isComplete reports whether the complete response was recorded without the request failing, being rejected or
+// running into its deadline.
+func (w *responseRecorder) isComplete(c *gin.Context) bool {
+	if len(c.Errors) > 0 || w.overflow || w.streamed {
+		return false
+	}
+
+	return !WasRequestRejected(c.Request) && !errors.Is(context.Cause(c.Request.Context()), ErrHandlerDeadlineExceeded)
+}
+
+// getHandlerHeader returns the response headers the handler set or changed, headers of middlewares running earlier,
+// like rate limit headers, are up-to-date on a replay already.
+func (w *responseRecorder) getHandlerHeader(before http.Header) http.Header {
+	w.snapshotHeader()
+
+	header := http.Header{}
+	for name, values := range w.header {
+		if !slices.Equal(before[name], values) {
+			header[name] = values
+		}
+	}
+
+	return header
+}
+
+// snapshotHeader keeps the headers before the response is written, as writers like the compression writer add
+// headers describing the encoded response.
+func (w *responseRecorder) snapshotHeader() {
+	if w.header == nil {
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+}
+
+func (w *responseRecorder) record(data []byte) {
+	if w.overflow {
+		return
+	}
+
+	if int64(w.body.Len()+len(data)) > w.maxBytes {
+		w.overflow = true
+		w.body.Reset()
+
+		return
+	}
+
+	w.body.Write(data)
+}
+
+func (w *responseRecorder) WriteHeaderNow() {
+	w.snapshotHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.snapshotHeader()
+
+	n, err := w.ResponseWriter.Write(data)
+	w.record(data[:n])
+
+	return n, err
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.snapshotHeader()
+
+	n, err := w.ResponseWriter.WriteString(s)
+	w.record([]byte(s[:n]))
+
+	return n, err
+}
+
+func (w *responseRecorder) Flush() {
+	w.streamed = true
+	w.ResponseWriter.Flush()
+}
+
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.streamed = true
+
+	return w.ResponseWriter.Hijack()
+}
+
+// Unwrap allows http.ResponseController to reach the connection of the response.
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func newBufferResponseWriter() *bufferResponseWriter {
+	return &bufferResponseWriter{
+		header: make(http.Header),
+		status: http.StatusOK,
+		size:   -1,
+	}
+}
+
+func (w *bufferResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferResponseWriter) WriteHeader(statusCode int) {
+	if statusCode > 0 && !w.Written() {
+		w.status = statusCode
+	}
+}
+
+func (w *bufferResponseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+	}
+}
+
+func (w *bufferResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+
+	n, err := w.body.Write(data)
+	w.size += n
+
+	return n, err
+}
+
+func (w *bufferResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *bufferResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferResponseWriter) Size() int {
+	return w.size
+}
+
+func (w *bufferResponseWriter) Written() bool {
+	return w.size != -1
+}
+
+func (w *bufferResponseWriter) Flush() {
+	w.WriteHeaderNow()
+}
+
+func (w *bufferResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, fmt.Errorf("the response of a handler running without client can not be hijacked")
+}
+
+func (w *bufferResponseWriter) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+func (w *bufferResponseWriter) Pusher() http.Pusher {
+	return nil
+}
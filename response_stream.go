@@ -0,0 +1,160 @@
+package httpserver
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamingResponse is implemented by responses which write their body from a reader instead of buffering it.
+type streamingResponse interface {
+	writeTo(ginCtx *gin.Context) error
+}
+
+var _ Response = &streamResponse{}
+
+type streamResponse struct {
+	*response
+	reader io.Reader
+}
+
+// NewStreamResponse creates a response streaming the content of reader with status 200 by default. Readers
+// implementing io.ReadSeeker support Range and If-Range requests. Readers implementing io.Closer are closed once the
+// response is written. The content type is sniffed from the content unless it is set with an option. Entity tags
+// computed from the body aren't supported as the content isn't buffered.
+func NewStreamResponse(reader io.Reader, options ...ResponseOption) *streamResponse {
+	resp := &streamResponse{
+		response: &response{
+			header:     make(http.Header),
+			statusCode: http.StatusOK,
+		},
+		reader: reader,
+	}
+
+	for _, option := range options {
+		option(resp.response)
+	}
+
+	return resp
+}
+
+// NewFileResponse creates a response streaming a file like NewStreamResponse. The content type is derived from the
+// extension of the file name and the Last-Modified header from its modification time, unless they are set with an
+// option. The file is closed once the response is written.
+func NewFileResponse(file fs.File, options ...ResponseOption) *streamResponse {
+	return NewStreamResponse(file, options...)
+}
+
+// Body reads the whole content and closes the reader if it is an io.Closer. BindHandleResponse streams the content
+// instead.
+func (s streamResponse) Body() (body []byte, err error) {
+	if closer, ok := s.reader.(io.Closer); ok {
+		defer func() {
+			if closeErr := closer.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("body close error: %w", closeErr)
+			}
+		}()
+	}
+
+	return io.ReadAll(s.reader)
+}
+
+func (s streamResponse) writeTo(ginCtx *gin.Context) (err error) {
+	if closer, ok := s.reader.(io.Closer); ok {
+		defer func() {
+			if closeErr := closer.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("body close error: %w", closeErr)
+			}
+		}()
+	}
+
+	var name string
+	var modTime time.Time
+	size := int64(-1)
+
+	if file, ok := s.reader.(fs.File); ok {
+		var stat fs.FileInfo
+
+		if stat, err = file.Stat(); err != nil {
+			return fmt.Errorf("can not stat file: %w", err)
+		}
+
+		name, modTime = stat.Name(), stat.ModTime()
+
+		if stat.Mode().IsRegular() {
+			size = stat.Size()
+		}
+	}
+
+	if lastModified, ok := parseHttpTime(s.header.Get(HeaderLastModified)); ok {
+		modTime = lastModified
+	}
+
+	for key, values := range s.header {
+		for _, value := range values {
+			ginCtx.Header(key, value)
+		}
+	}
+
+	if seeker, ok := s.reader.(io.ReadSeeker); ok && s.statusCode == http.StatusOK {
+		// ranges are advertised for the uncompressed content, so later range requests refer to the same representation
+		DisableCompression(ginCtx)
+
+		http.ServeContent(ginCtx.Writer, ginCtx.Request, name, modTime, seeker)
+
+		return nil
+	}
+
+	return s.copyTo(ginCtx, name, modTime, size)
+}
+
+// copyTo writes content which can't be seeked as a whole, so Range requests are answered with the full content.
+func (s streamResponse) copyTo(ginCtx *gin.Context, name string, modTime time.Time, size int64) error {
+	header := ginCtx.Writer.Header()
+	statusCode := s.statusCode
+
+	if header.Get(HeaderContentType) == "" {
+		contentType := mime.TypeByExtension(filepath.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header.Set(HeaderContentType, contentType)
+	}
+
+	if !modTime.IsZero() && header.Get(HeaderLastModified) == "" {
+		header.Set(HeaderLastModified, modTime.UTC().Format(http.TimeFormat))
+	}
+
+	if size >= 0 && header.Get(HeaderContentLength) == "" {
+		header.Set(HeaderContentLength, strconv.FormatInt(size, 10))
+	}
+
+	switch evaluateResponsePreconditions(ginCtx.Request, statusCode, header) {
+	case http.StatusPreconditionFailed:
+		return NewErrorWithStatus(http.StatusPreconditionFailed, ErrPreconditionFailed)
+	case http.StatusNotModified:
+		statusCode = http.StatusNotModified
+		deleteRepresentationHeaders(header)
+	}
+
+	ginCtx.Status(statusCode)
+	ginCtx.Writer.WriteHeaderNow()
+
+	if hasBodylessResponse(ginCtx.Request, statusCode) {
+		return nil
+	}
+
+	if _, err := io.Copy(ginCtx.Writer, s.reader); err != nil {
+		return fmt.Errorf("body write error: %w", err)
+	}
+
+	return nil
+}
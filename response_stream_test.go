@@ -0,0 +1,230 @@
+package httpserver_test
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const streamTestContent = "0123456789abcdefghij"
+
+var streamTestFiles = fstest.MapFS{
+	"report.txt": &fstest.MapFile{Data: []byte(streamTestContent), ModTime: conditionalTestModified},
+}
+
+type streamTestReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *streamTestReader) Close() error {
+	r.closed = true
+
+	return nil
+}
+
+func newStreamTestRoute(response func() httpserver.Response) func(r *gin.Engine) {
+	return func(r *gin.Engine) {
+		r.Match([]string{http.MethodGet, http.MethodHead}, "/report", httpserver.BindN(func(ctx context.Context) (httpserver.Response, error) {
+			return response(), nil
+		}))
+	}
+}
+
+func newStreamTestFileRoute(t *testing.T, options ...httpserver.ResponseOption) func(r *gin.Engine) {
+	return newStreamTestRoute(func() httpserver.Response {
+		file, err := streamTestFiles.Open("report.txt")
+		require.NoError(t, err)
+
+		return httpserver.NewFileResponse(file, options...)
+	})
+}
+
+func TestStreamResponseFile(t *testing.T) {
+	router := newTestRouter(newStreamTestFileRoute(t))
+	rec := serveTestRequest(router, http.MethodGet, "/report", nil, nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get(httpserver.HeaderContentType))
+	assert.Equal(t, "20", rec.Header().Get(httpserver.HeaderContentLength))
+	assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+	assert.Equal(t, "Sun, 01 Mar 2026 12:00:00 GMT", rec.Header().Get(httpserver.HeaderLastModified))
+	assert.Equal(t, streamTestContent, rec.Body.String())
+
+	rec = serveTestRequest(router, http.MethodGet, "/report", nil, map[string]string{
+		httpserver.HeaderIfModifiedSince: "Sun, 01 Mar 2026 12:00:00 GMT",
+	})
+
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestStreamResponseRange(t *testing.T) {
+	cases := []struct {
+		name               string
+		options            []httpserver.ResponseOption
+		header             map[string]string
+		expectStatus       int
+		expectContentRange string
+		expectBody         string
+	}{
+		{
+			name:               "single range",
+			header:             map[string]string{httpserver.HeaderRange: "bytes=2-5"},
+			expectStatus:       http.StatusPartialContent,
+			expectContentRange: "bytes 2-5/20",
+			expectBody:         "2345",
+		},
+		{
+			name:               "suffix range",
+			header:             map[string]string{httpserver.HeaderRange: "bytes=-3"},
+			expectStatus:       http.StatusPartialContent,
+			expectContentRange: "bytes 17-19/20",
+			expectBody:         "hij",
+		},
+		{
+			name:               "unsatisfiable range",
+			header:             map[string]string{httpserver.HeaderRange: "bytes=30-40"},
+			expectStatus:       http.StatusRequestedRangeNotSatisfiable,
+			expectContentRange: "bytes */20",
+			expectBody:         "invalid range: failed to overlap\n",
+		},
+		{
+			name:               "if-range matches the entity tag",
+			options:            []httpserver.ResponseOption{httpserver.WithETag("v1")},
+			header:             map[string]string{httpserver.HeaderRange: "bytes=0-1", "If-Range": `"v1"`},
+			expectStatus:       http.StatusPartialContent,
+			expectContentRange: "bytes 0-1/20",
+			expectBody:         "01",
+		},
+		{
+			name:         "if-range doesn't match the entity tag",
+			options:      []httpserver.ResponseOption{httpserver.WithETag("v2")},
+			header:       map[string]string{httpserver.HeaderRange: "bytes=0-1", "If-Range": `"v1"`},
+			expectStatus: http.StatusOK,
+			expectBody:   streamTestContent,
+		},
+		{
+			name:         "if-range doesn't match the modification time",
+			header:       map[string]string{httpserver.HeaderRange: "bytes=0-1", "If-Range": "Sat, 28 Feb 2026 12:00:00 GMT"},
+			expectStatus: http.StatusOK,
+			expectBody:   streamTestContent,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newTestRouter(newStreamTestFileRoute(t, tc.options...))
+			rec := serveTestRequest(router, http.MethodGet, "/report", nil, tc.header)
+
+			assert.Equal(t, tc.expectStatus, rec.Code)
+			assert.Equal(t, tc.expectContentRange, rec.Header().Get("Content-Range"))
+			assert.Equal(t, tc.expectBody, rec.Body.String())
+		})
+	}
+}
+
+func TestStreamResponseMultipleRanges(t *testing.T) {
+	router := newTestRouter(newStreamTestFileRoute(t))
+	rec := serveTestRequest(router, http.MethodGet, "/report", nil, map[string]string{
+		httpserver.HeaderRange: "bytes=0-1,10-12",
+	})
+
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+
+	mediaType, params, err := mime.ParseMediaType(rec.Header().Get(httpserver.HeaderContentType))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/byteranges", mediaType)
+
+	reader := multipart.NewReader(rec.Body, params["boundary"])
+	expected := map[string]string{"bytes 0-1/20": "01", "bytes 10-12/20": "abc"}
+
+	for range expected {
+		part, err := reader.NextPart()
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+
+		assert.Equal(t, "text/plain; charset=utf-8", part.Header.Get(httpserver.HeaderContentType))
+		assert.Equal(t, expected[part.Header.Get("Content-Range")], string(body))
+	}
+
+	_, err = reader.NextPart()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestStreamResponseReader(t *testing.T) {
+	reader := &streamTestReader{Reader: io.MultiReader(strings.NewReader(streamTestContent))}
+	router := newTestRouter(newStreamTestRoute(func() httpserver.Response {
+		return httpserver.NewStreamResponse(reader, httpserver.WithAttachment("report.csv"), httpserver.WithStatusCode(http.StatusCreated))
+	}))
+
+	rec := serveTestRequest(router, http.MethodGet, "/report", nil, map[string]string{
+		httpserver.HeaderRange: "bytes=0-1",
+	})
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "application/octet-stream", rec.Header().Get(httpserver.HeaderContentType))
+	assert.Equal(t, "attachment; filename=report.csv", rec.Header().Get(httpserver.HeaderContentDisposition))
+	assert.Empty(t, rec.Header().Get("Content-Range"))
+	assert.Equal(t, streamTestContent, rec.Body.String())
+	assert.True(t, reader.closed)
+}
+
+func TestStreamResponseBody(t *testing.T) {
+	reader := &streamTestReader{Reader: strings.NewReader(streamTestContent)}
+
+	body, err := httpserver.NewStreamResponse(reader).Body()
+	assert.NoError(t, err)
+	assert.Equal(t, streamTestContent, string(body))
+	assert.True(t, reader.closed)
+}
+
+func TestStreamResponseReaderPreconditions(t *testing.T) {
+	modified := conditionalTestModified.Add(time.Hour)
+	router := newTestRouter(newStreamTestRoute(func() httpserver.Response {
+		reader := io.MultiReader(strings.NewReader(streamTestContent))
+
+		return httpserver.NewStreamResponse(reader, httpserver.WithETag("v1"), httpserver.WithLastModified(modified))
+	}))
+
+	rec := serveTestRequest(router, http.MethodGet, "/report", nil, map[string]string{
+		httpserver.HeaderIfNoneMatch: `"v1"`,
+	})
+
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Header().Get(httpserver.HeaderContentType))
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestContentDispositionOptions(t *testing.T) {
+	cases := map[string]struct {
+		option httpserver.ResponseOption
+		expect string
+	}{
+		"attachment":         {option: httpserver.WithAttachment("report.csv"), expect: "attachment; filename=report.csv"},
+		"attachment quoted":  {option: httpserver.WithAttachment("my report.csv"), expect: `attachment; filename="my report.csv"`},
+		"attachment encoded": {option: httpserver.WithAttachment("bericht-ä.csv"), expect: "attachment; filename*=utf-8''bericht-%C3%A4.csv"},
+		"attachment unnamed": {option: httpserver.WithAttachment(""), expect: "attachment"},
+		"inline":             {option: httpserver.WithInline("report.pdf"), expect: "inline; filename=report.pdf"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			response := httpserver.NewStatusResponse(http.StatusOK, tc.option)
+			assert.Equal(t, tc.expect, response.Header().Get(httpserver.HeaderContentDisposition))
+		})
+	}
+}
@@ -137,3 +137,92 @@ func TestResponseCases(t *testing.T) {
 		}
 	}
 }
+
+func TestNegotiateResponseEncodingCases(t *testing.T) {
+	cases := []struct {
+		name              string
+		accept            string
+		value             any
+		defaultMediaType  string
+		expectContentType string
+		expectErr         bool
+	}{
+		{
+			name:              "no accept header uses default",
+			value:             sample{},
+			defaultMediaType:  "application/xml",
+			expectContentType: ContentTypeXml,
+		},
+		{
+			name:              "unsupported default falls back to json",
+			value:             sample{},
+			defaultMediaType:  "application/x-protobuf",
+			expectContentType: ContentTypeJson,
+		},
+		{
+			name:              "wildcard uses default",
+			accept:            "*/*",
+			value:             sample{},
+			defaultMediaType:  "text/xml",
+			expectContentType: ContentTypeXml,
+		},
+		{
+			name:              "highest quality wins",
+			accept:            "application/json;q=0.5, application/xml;q=0.9",
+			value:             sample{},
+			defaultMediaType:  "application/json",
+			expectContentType: ContentTypeXml,
+		},
+		{
+			name:              "explicit media type wins over wildcard with same quality",
+			accept:            "*/*, application/xml",
+			value:             sample{},
+			defaultMediaType:  "application/json",
+			expectContentType: ContentTypeXml,
+		},
+		{
+			name:              "most specific range determines quality",
+			accept:            "application/*, application/json;q=0",
+			value:             sample{},
+			defaultMediaType:  "application/json",
+			expectContentType: ContentTypeXml,
+		},
+		{
+			name:              "media type parameters are ignored",
+			accept:            "text/html, application/json; charset=utf-8; q=0.8",
+			value:             sample{},
+			defaultMediaType:  "application/json",
+			expectContentType: ContentTypeJson,
+		},
+		{
+			name:             "protobuf for non encodable value",
+			accept:           "application/x-protobuf",
+			value:            sample{},
+			defaultMediaType: "application/json",
+			expectErr:        true,
+		},
+		{
+			name:             "nothing acceptable",
+			accept:           "text/html, application/json;q=0",
+			value:            sample{},
+			defaultMediaType: "application/json",
+			expectErr:        true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoding, err := negotiateResponseEncoding(tc.accept, tc.value, tc.defaultMediaType)
+
+			if tc.expectErr {
+				assert.ErrorIs(t, err, ErrNotAcceptable)
+				assert.Equal(t, http.StatusNotAcceptable, GetErrorStatusCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectContentType, encoding.contentType)
+		})
+	}
+}
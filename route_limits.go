@@ -0,0 +1,146 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	// RouteLimits override the request limits of the server for routes. Zero values keep the limits of the parent
+	// router or the server.
+	RouteLimits struct {
+		// MaxBodyBytes is the maximum size of an incoming request body in bytes. A negative value disables the limit.
+		MaxBodyBytes int64
+		// Deadline is the maximum duration of the handler, replacing the handler timeout of the server. Routes of the
+		// BindSse and BindWs families don't use the handler timeout of the server, only a deadline set for them.
+		Deadline time.Duration
+		// WriteTimeout replaces the write timeout of the server for the response.
+		WriteTimeout time.Duration
+		// Priority of the requests of the route under pressure, one of the RequestPriority constants.
+		Priority string
+	}
+
+	// routeLimiter applies the limits of the route serving a request.
+	routeLimiter struct {
+		server            RouteLimits
+		timeoutStatusCode int
+		settings          []RouteSettings
+		routes            map[string]RouteLimits
+	}
+)
+
+// SetLimits overrides the request limits of the server for the routes of this router and its groups. Limits of
+// nested groups take precedence over the limits of their parents. The routes settings of the server take precedence
+// over both. Use a group without path to set the limits of a single route:
+//
+//	router.Group("").SetLimits(httpserver.RouteLimits{MaxBodyBytes: 500 << 20})
+func (d *Router) SetLimits(limits RouteLimits) {
+	d.limits = limits
+}
+
+func (d *Router) getLimits() RouteLimits {
+	limits := RouteLimits{}
+
+	if d.parent != nil {
+		limits = d.parent.getLimits()
+	}
+
+	return limits.merge(d.limits)
+}
+
+func (l RouteLimits) merge(other RouteLimits) RouteLimits {
+	if other.MaxBodyBytes != 0 {
+		l.MaxBodyBytes = other.MaxBodyBytes
+	}
+
+	if other.Deadline != 0 {
+		l.Deadline = other.Deadline
+	}
+
+	if other.WriteTimeout != 0 {
+		l.WriteTimeout = other.WriteTimeout
+	}
+
+	if other.Priority != "" {
+		l.Priority = other.Priority
+	}
+
+	return l
+}
+
+// newRouteLimitsMiddleware creates the middleware applying the limits of the route serving a request and a setup
+// hook collecting the limits of the defined routes. Requests of unknown routes use the limits of the server.
+func newRouteLimitsMiddleware(settings *Settings) (middleware gin.HandlerFunc, setupHandler func(definitions []Definition)) {
+	limiter := &routeLimiter{
+		server: RouteLimits{
+			MaxBodyBytes: settings.MaxBodyBytes,
+			Deadline:     settings.Timeout.Handler,
+		},
+		timeoutStatusCode: settings.Timeout.HandlerStatusCode,
+		settings:          settings.Routes,
+		routes:            map[string]RouteLimits{},
+	}
+
+	return limiter.middleware, limiter.setup
+}
+
+func (l *routeLimiter) setup(definitions []Definition) {
+	routes := make(map[string]RouteLimits, len(definitions))
+
+	for _, definition := range definitions {
+		path := definition.getAbsolutePath()
+		limits := l.server
+
+		// the handler timeout of the server would cut long-lived streams, only deadlines set for the route apply
+		if definition.Spec.isStreaming() {
+			limits.Deadline = 0
+		}
+
+		limits = limits.merge(definition.Group.getLimits())
+
+		for _, route := range l.settings {
+			if route.Path == path && (route.Method == "" || strings.EqualFold(route.Method, definition.HttpMethod)) {
+				limits = limits.merge(RouteLimits{
+					MaxBodyBytes: route.MaxBodyBytes,
+					Deadline:     route.Deadline,
+					WriteTimeout: route.WriteTimeout,
+					Priority:     route.Priority,
+				})
+			}
+		}
+
+		routes[routeLimitsKey(definition.HttpMethod, path)] = limits
+	}
+
+	l.routes = routes
+}
+
+func (l *routeLimiter) middleware(c *gin.Context) {
+	limits, ok := l.routes[routeLimitsKey(c.Request.Method, removeDuplicates(trimRightPath(c.FullPath())))]
+	if !ok {
+		limits = l.server
+	}
+
+	if limits.MaxBodyBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxBodyBytes)
+	}
+
+	if limits.WriteTimeout > 0 {
+		// writers not supporting deadlines, like the recorders of tests, keep the write timeout of the server
+		_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(limits.WriteTimeout))
+	}
+
+	if limits.Priority != "" {
+		SetRequestPriority(c, limits.Priority)
+	}
+
+	handleWithTimeout(c, limits.Deadline, l.timeoutStatusCode)
+}
+
+func routeLimitsKey(method string, path string) string {
+	return fmt.Sprintf("%s %s", method, path)
+}
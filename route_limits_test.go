@@ -0,0 +1,248 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	logMocks "github.com/justtrackio/gosoline/pkg/log/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type routeLimitsTestInput struct {
+	Content string `json:"content"`
+}
+
+func newRouteLimitsTestEngine(t *testing.T, settings *Settings, define func(router *Router)) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	middleware, setup := newRouteLimitsMiddleware(settings)
+
+	engine := gin.New()
+	engine.ContextWithFallback = true
+	engine.Use(ErrorMiddlewareWithSettings(ErrorsSettings{Privacy: ErrorPrivacyPublic}))
+	engine.Use(middleware)
+
+	definitions := &Router{}
+	define(definitions)
+
+	logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+	definitionList, err := buildRouter(t.Context(), cfg.New(), logger, settings, definitions, engine)
+	require.NoError(t, err)
+
+	setup(definitionList)
+
+	return engine
+}
+
+func routeLimitsTestHandler() gin.HandlerFunc {
+	return Bind(func(ctx context.Context, input *routeLimitsTestInput) (Response, error) {
+		return NewStatusResponse(http.StatusNoContent), nil
+	}, binding.JSON)
+}
+
+func routeLimitsTestSlowHandler() gin.HandlerFunc {
+	return BindN(func(ctx context.Context) (Response, error) {
+		<-ctx.Done()
+
+		return nil, context.Cause(ctx)
+	})
+}
+
+func TestRouteLimitsMaxBodyBytes(t *testing.T) {
+	settings := &Settings{
+		MaxBodyBytes: 20,
+		Routes: []RouteSettings{
+			{
+				Path:         "/unlimited",
+				MaxBodyBytes: -1,
+			},
+			{
+				Method:       http.MethodPost,
+				Path:         "/uploads/small",
+				MaxBodyBytes: 10,
+			},
+		},
+	}
+
+	engine := newRouteLimitsTestEngine(t, settings, func(router *Router) {
+		router.POST("/default", routeLimitsTestHandler())
+		router.POST("/unlimited", routeLimitsTestHandler())
+
+		uploads := router.Group("/uploads")
+		uploads.SetLimits(RouteLimits{MaxBodyBytes: 1000})
+		uploads.POST("/large", routeLimitsTestHandler())
+		uploads.POST("/small", routeLimitsTestHandler())
+	})
+
+	body := `{"content":"` + strings.Repeat("a", 100) + `"}`
+
+	for path, expectedStatus := range map[string]int{
+		"/default":       http.StatusRequestEntityTooLarge,
+		"/unlimited":     http.StatusNoContent,
+		"/uploads/large": http.StatusNoContent,
+		"/uploads/small": http.StatusRequestEntityTooLarge,
+	} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(HeaderContentType, ContentTypeApplicationJson)
+
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+
+		assert.Equal(t, expectedStatus, rec.Code, path)
+	}
+}
+
+func TestRouteLimitsDeadline(t *testing.T) {
+	settings := &Settings{
+		Routes: []RouteSettings{
+			{
+				Path:     "/api/configured",
+				Deadline: 10 * time.Millisecond,
+			},
+		},
+	}
+
+	engine := newRouteLimitsTestEngine(t, settings, func(router *Router) {
+		api := router.Group("/api")
+		api.SetLimits(RouteLimits{Deadline: time.Hour})
+		api.GET("/configured", routeLimitsTestSlowHandler())
+
+		fast := api.Group("")
+		fast.SetLimits(RouteLimits{Deadline: 10 * time.Millisecond})
+		fast.GET("/fast", routeLimitsTestSlowHandler())
+	})
+
+	for _, path := range []string{"/api/configured", "/api/fast"} {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code, path)
+		assert.JSONEq(t, `{"err":"handler deadline exceeded after 10ms"}`, rec.Body.String(), path)
+	}
+}
+
+func TestRouteLimitsDeadlineAfterResponse(t *testing.T) {
+	engine := newRouteLimitsTestEngine(t, &Settings{}, func(router *Router) {
+		group := router.Group("")
+		group.SetLimits(RouteLimits{Deadline: time.Millisecond})
+		group.GET("/", func(c *gin.Context) {
+			c.Status(http.StatusAccepted)
+			c.Writer.WriteHeaderNow()
+
+			<-c.Done()
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestRouteLimitsWriteTimeout(t *testing.T) {
+	engine := newRouteLimitsTestEngine(t, &Settings{}, func(router *Router) {
+		slow := router.Group("/slow")
+		slow.SetLimits(RouteLimits{WriteTimeout: 10 * time.Millisecond})
+		slow.GET("", func(c *gin.Context) {
+			time.Sleep(50 * time.Millisecond)
+			c.String(http.StatusOK, "too late")
+		})
+
+		router.GET("/fast", func(c *gin.Context) {
+			time.Sleep(50 * time.Millisecond)
+			c.String(http.StatusOK, "in time")
+		})
+	})
+
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+
+	res, err := server.Client().Get(server.URL + "/fast")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	require.NoError(t, res.Body.Close())
+
+	_, err = server.Client().Get(server.URL + "/slow")
+	assert.Error(t, err, "the connection is closed once the write deadline is exceeded")
+}
+
+func TestRouteLimitsPriority(t *testing.T) {
+	settings := &Settings{
+		Routes: []RouteSettings{
+			{
+				Path:     "/checkout",
+				Priority: RequestPriorityHigh,
+			},
+		},
+	}
+
+	priorities := map[string]string{}
+	handler := func(c *gin.Context) {
+		priorities[c.Request.URL.Path] = GetRequestPriority(c)
+	}
+
+	engine := newRouteLimitsTestEngine(t, settings, func(router *Router) {
+		router.GET("/checkout", handler)
+		router.GET("/default", handler)
+
+		reports := router.Group("/reports")
+		reports.SetLimits(RouteLimits{Priority: RequestPriorityLow})
+		reports.GET("/daily", handler)
+	})
+
+	for _, path := range []string{"/checkout", "/default", "/reports/daily"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, http.NoBody))
+	}
+
+	assert.Equal(t, map[string]string{
+		"/checkout":      RequestPriorityHigh,
+		"/default":       RequestPriorityNormal,
+		"/reports/daily": RequestPriorityLow,
+	}, priorities)
+}
+
+func TestRouteLimitsStreamsOutliveHandlerTimeout(t *testing.T) {
+	settings := &Settings{
+		Timeout: TimeoutSettings{Handler: 10 * time.Millisecond},
+	}
+
+	engine := newRouteLimitsTestEngine(t, settings, func(router *Router) {
+		router.GET("/events", BindSseN(func(ctx context.Context, writer *SseWriter) error {
+			time.Sleep(30 * time.Millisecond)
+
+			if err := context.Cause(ctx); err != nil {
+				return err
+			}
+
+			return writer.Send("still streaming")
+		}))
+
+		limited := router.Group("")
+		limited.SetLimits(RouteLimits{Deadline: 10 * time.Millisecond})
+		limited.GET("/limited-events", BindSseN(func(ctx context.Context, writer *SseWriter) error {
+			<-ctx.Done()
+
+			return nil
+		}))
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "data: still streaming\n\n")
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/limited-events", http.NoBody))
+
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code, "deadlines set for the route apply")
+}
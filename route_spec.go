@@ -0,0 +1,330 @@
+package httpserver
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type (
+	// RouteSpec describes what a route consumes and produces. It is recorded by the Bind family of
+	// functions and can be extended with Describe. It is used for introspection and OpenAPI generation.
+	RouteSpec struct {
+		// Input is the bound input type of the handler or nil if the handler does not bind any input.
+		Input reflect.Type
+		// Output is the type returned by handlers created with the BindT family of functions or nil otherwise.
+		Output reflect.Type
+		// RequestContentTypes lists the content types the request body can be decoded from.
+		RequestContentTypes []string
+		// Responses lists the documented responses of the route.
+		Responses []RouteResponse
+		// OperationId is a unique identifier of the operation.
+		OperationId string
+		// Summary is a short summary of what the route does.
+		Summary string
+		// Description is a longer explanation of the route behavior.
+		Description string
+		// Tags are used to group routes in the generated documentation.
+		Tags []string
+		// Deprecated marks the route as deprecated.
+		Deprecated bool
+	}
+
+	// RouteResponse describes one possible response of a route.
+	RouteResponse struct {
+		// StatusCode is the HTTP status code of the response.
+		StatusCode int
+		// Description explains when the response is returned.
+		Description string
+		// Type is the type of the response body or nil if the response has no body.
+		Type reflect.Type
+		// ContentTypes lists the content types the body can be encoded with.
+		ContentTypes []string
+	}
+
+	// RouteSpecOption modifies the RouteSpec of a handler created by Describe.
+	RouteSpecOption func(spec *RouteSpec)
+
+	// describedHandler carries the RouteSpec of a handler created by the Bind family of functions or Describe.
+	describedHandler struct {
+		handler gin.HandlerFunc
+		spec    *RouteSpec
+	}
+)
+
+const routeSpecKey = "goso.route.spec"
+
+// routeSpecProbeRequest is passed to a described handler to query its RouteSpec instead of handling a request.
+var routeSpecProbeRequest = &http.Request{}
+
+// describedHandlerCode is the code pointer shared by all handlers returned by registerRouteSpec. It identifies
+// them, so only described handlers are probed for their RouteSpec.
+var describedHandlerCode = reflect.ValueOf((&describedHandler{}).handle).Pointer()
+
+// Describe attaches additional documentation to a handler. The returned handler behaves exactly like the
+// provided one, but its RouteSpec is extended by the given options.
+func Describe(handler gin.HandlerFunc, options ...RouteSpecOption) gin.HandlerFunc {
+	spec := &RouteSpec{}
+
+	if existing := GetRouteSpec(handler); existing != nil {
+		spec = existing.clone()
+	}
+
+	for _, option := range options {
+		option(spec)
+	}
+
+	return registerRouteSpec(handler, spec)
+}
+
+// GetRouteSpec returns the RouteSpec recorded for a handler or nil if the handler was not created by
+// the Bind family of functions or Describe.
+func GetRouteSpec(handler gin.HandlerFunc) *RouteSpec {
+	if handler == nil {
+		return nil
+	}
+
+	if reflect.ValueOf(handler).Pointer() != describedHandlerCode {
+		return nil
+	}
+
+	probe := &gin.Context{Request: routeSpecProbeRequest}
+	handler(probe)
+
+	if spec, ok := probe.Get(routeSpecKey); ok {
+		return spec.(*RouteSpec)
+	}
+
+	return nil
+}
+
+// WithRouteOperationId sets the operation id of a route.
+func WithRouteOperationId(operationId string) RouteSpecOption {
+	return func(spec *RouteSpec) {
+		spec.OperationId = operationId
+	}
+}
+
+// WithRouteSummary sets the summary of a route.
+func WithRouteSummary(summary string) RouteSpecOption {
+	return func(spec *RouteSpec) {
+		spec.Summary = summary
+	}
+}
+
+// WithRouteDescription sets the description of a route.
+func WithRouteDescription(description string) RouteSpecOption {
+	return func(spec *RouteSpec) {
+		spec.Description = description
+	}
+}
+
+// WithRouteTags adds tags to a route.
+func WithRouteTags(tags ...string) RouteSpecOption {
+	return func(spec *RouteSpec) {
+		spec.Tags = append(spec.Tags, tags...)
+	}
+}
+
+// WithRouteDeprecated marks a route as deprecated.
+func WithRouteDeprecated() RouteSpecOption {
+	return func(spec *RouteSpec) {
+		spec.Deprecated = true
+	}
+}
+
+// WithRouteResponse documents a JSON response with a body of type T for the given status code.
+func WithRouteResponse[T any](statusCode int, description string) RouteSpecOption {
+	return func(spec *RouteSpec) {
+		spec.setResponse(RouteResponse{
+			StatusCode:   statusCode,
+			Description:  description,
+			Type:         reflect.TypeFor[T](),
+			ContentTypes: []string{binding.MIMEJSON},
+		})
+	}
+}
+
+// WithRouteStatus documents a response without a body for the given status code.
+func WithRouteStatus(statusCode int, description string) RouteSpecOption {
+	return func(spec *RouteSpec) {
+		spec.setResponse(RouteResponse{
+			StatusCode:  statusCode,
+			Description: description,
+		})
+	}
+}
+
+// WithRouteSuccessStatus documents the success response of a handler created with the BindT family of functions
+// with the status code the handler sets with ResponseMeta.SetStatusCode, e.g. 201 for create endpoints, instead of 200.
+func WithRouteSuccessStatus(statusCode int, description string) RouteSpecOption {
+	return func(spec *RouteSpec) {
+		response := RouteResponse{
+			StatusCode:  statusCode,
+			Description: description,
+		}
+
+		for i, existing := range spec.Responses {
+			if spec.Output == nil || existing.Type != spec.Output || existing.StatusCode < 200 || existing.StatusCode > 299 {
+				continue
+			}
+
+			response.Type = existing.Type
+			response.ContentTypes = existing.ContentTypes
+			spec.Responses = slices.Delete(spec.Responses, i, i+1)
+
+			break
+		}
+
+		spec.setResponse(response)
+	}
+}
+
+// Response returns the documented response for the status code or nil if there is none.
+func (s *RouteSpec) Response(statusCode int) *RouteResponse {
+	for i := range s.Responses {
+		if s.Responses[i].StatusCode == statusCode {
+			return &s.Responses[i]
+		}
+	}
+
+	return nil
+}
+
+// producesContentType reports whether any documented response of the route can be encoded with the content type.
+func (s *RouteSpec) producesContentType(contentType string) bool {
+	for _, response := range s.Responses {
+		if slices.Contains(response.ContentTypes, contentType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isStreaming reports whether the route keeps its connection open, as for server-sent events or WebSocket connections.
+func (s *RouteSpec) isStreaming() bool {
+	return s != nil && (s.producesContentType(ContentTypeEventStream) || s.Response(http.StatusSwitchingProtocols) != nil)
+}
+
+func (s *RouteSpec) setResponse(response RouteResponse) {
+	if existing := s.Response(response.StatusCode); existing != nil {
+		*existing = response
+
+		return
+	}
+
+	s.Responses = append(s.Responses, response)
+}
+
+func (s *RouteSpec) clone() *RouteSpec {
+	cloned := *s
+	cloned.RequestContentTypes = slices.Clone(s.RequestContentTypes)
+	cloned.Responses = slices.Clone(s.Responses)
+	cloned.Tags = slices.Clone(s.Tags)
+
+	return &cloned
+}
+
+func registerRouteSpec(handler gin.HandlerFunc, spec *RouteSpec) gin.HandlerFunc {
+	described := &describedHandler{
+		handler: handler,
+		spec:    spec,
+	}
+
+	return described.handle
+}
+
+// handle serves requests with the described handler and answers probes of GetRouteSpec with the RouteSpec.
+func (h *describedHandler) handle(ginCtx *gin.Context) {
+	if ginCtx.Request == routeSpecProbeRequest {
+		ginCtx.Set(routeSpecKey, h.spec)
+
+		return
+	}
+
+	h.handler(ginCtx)
+}
+
+// lookupRouteSpec returns the spec of the last handler of a route that has one, as the last handler
+// of a chain is the one producing the response.
+func lookupRouteSpec(handlers []gin.HandlerFunc) *RouteSpec {
+	var result *RouteSpec
+
+	for _, handler := range handlers {
+		if spec := GetRouteSpec(handler); spec != nil {
+			result = spec
+		}
+	}
+
+	return result
+}
+
+func newBindRouteSpec[I any](tags []string, binders []binding.Binding) *RouteSpec {
+	return &RouteSpec{
+		Input:               reflect.TypeFor[I](),
+		RequestContentTypes: getRequestContentTypes(tags, binders),
+		Responses: []RouteResponse{
+			{
+				StatusCode:  http.StatusBadRequest,
+				Description: http.StatusText(http.StatusBadRequest),
+			},
+		},
+	}
+}
+
+func sseRouteResponse() RouteResponse {
+	return RouteResponse{
+		StatusCode:   http.StatusOK,
+		Description:  "Stream of server-sent events",
+		Type:         reflect.TypeFor[string](),
+		ContentTypes: []string{ContentTypeEventStream},
+	}
+}
+
+func wsRouteResponse() RouteResponse {
+	return RouteResponse{
+		StatusCode:  http.StatusSwitchingProtocols,
+		Description: "Upgrade to a WebSocket connection",
+	}
+}
+
+func getRequestContentTypes(tags []string, binders []binding.Binding) []string {
+	if len(binders) == 0 {
+		binders = getTagBinders(tags)
+	}
+
+	contentTypes := make([]string, 0, len(binders))
+
+	for _, binder := range binders {
+		if contentType := getBinderContentType(binder); contentType != "" && !slices.Contains(contentTypes, contentType) {
+			contentTypes = append(contentTypes, contentType)
+		}
+	}
+
+	return contentTypes
+}
+
+func getBinderContentType(binder binding.Binding) string {
+	switch binder.Name() {
+	case binding.JSON.Name():
+		return binding.MIMEJSON
+	case binding.XML.Name():
+		return binding.MIMEXML
+	case binding.YAML.Name():
+		return binding.MIMEYAML
+	case binding.ProtoBuf.Name():
+		return binding.MIMEPROTOBUF
+	case binding.MsgPack.Name():
+		return binding.MIMEMSGPACK
+	case binding.TOML.Name():
+		return binding.MIMETOML
+	case binding.FormMultipart.Name():
+		return binding.MIMEMultipartPOSTForm
+	}
+
+	return ""
+}
@@ -20,6 +20,8 @@ type (
 	RouterFactory func(ctx context.Context, config cfg.Config, logger log.Logger, router *Router) error
 	// MiddlewareFactory creates a Gin middleware from application dependencies and server settings.
 	MiddlewareFactory func(ctx context.Context, config cfg.Config, logger log.Logger, settings *Settings) (gin.HandlerFunc, error)
+	// routeMiddlewareFactory creates a middleware for a single route. It returns nil if the route needs none.
+	routeMiddlewareFactory func(definition Definition) gin.HandlerFunc
 )
 
 // Definition stores one route registered on a Router.
@@ -28,6 +30,9 @@ type Definition struct {
 	HttpMethod   string
 	RelativePath string
 	Handlers     []gin.HandlerFunc
+	// Spec describes the input and responses of the route. It is nil if none of the handlers
+	// was created by the Bind family of functions or Describe.
+	Spec *RouteSpec
 }
 
 func (d *Definition) getAbsolutePath() string {
@@ -45,6 +50,10 @@ type Router struct {
 	registerFactories   []RegisterFactoryFunc
 	middlewareFactories []MiddlewareFactory
 	routes              []Definition
+	errorHandler        ErrorHandler
+	limits              RouteLimits
+	modifier            Modifier
+	validations         validations
 
 	children []*Router
 	parent   *Router
@@ -98,6 +107,7 @@ func (d *Router) Handle(httpMethod, relativePath string, handlers ...gin.Handler
 		HttpMethod:   httpMethod,
 		RelativePath: relativePath,
 		Handlers:     handlers,
+		Spec:         lookupRouteSpec(handlers),
 	})
 }
 
@@ -136,7 +146,7 @@ func (d *Router) OPTIONS(relativePath string, handlers ...gin.HandlerFunc) {
 	d.Handle(http.MethodOptions, relativePath, handlers...)
 }
 
-func buildRouter(ctx context.Context, config cfg.Config, logger log.Logger, settings *Settings, definitions *Router, router gin.IRouter) ([]Definition, error) {
+func buildRouter(ctx context.Context, config cfg.Config, logger log.Logger, settings *Settings, definitions *Router, router gin.IRouter, routeMiddlewares ...routeMiddlewareFactory) ([]Definition, error) {
 	if definitions == nil {
 		return nil, fmt.Errorf("route definitions should not be nil")
 	}
@@ -160,6 +170,15 @@ func buildRouter(ctx context.Context, config cfg.Config, logger log.Logger, sett
 		grp = router.Group(definitions.basePath)
 	}
 
+	if definitions.hasScope() {
+		var scope *routerScope
+		if scope, err = definitions.getScope(); err != nil {
+			return nil, err
+		}
+
+		grp.Use(routerScopeMiddleware(scope))
+	}
+
 	for _, f := range definitions.middlewareFactories {
 		if middleware, err = f(ctx, config, logger, settings); err != nil {
 			return nil, fmt.Errorf("error creating middleware: %w", err)
@@ -169,7 +188,14 @@ func buildRouter(ctx context.Context, config cfg.Config, logger log.Logger, sett
 	}
 
 	for _, d := range definitions.routes {
-		handlers := make([]gin.HandlerFunc, 0, len(d.Handlers)+1)
+		handlers := make([]gin.HandlerFunc, 0, len(d.Handlers)+len(routeMiddlewares))
+
+		for _, f := range routeMiddlewares {
+			if middleware = f(d); middleware != nil {
+				handlers = append(handlers, middleware)
+			}
+		}
+
 		handlers = append(handlers, d.Handlers...)
 
 		grp.Handle(d.HttpMethod, d.RelativePath, handlers...)
@@ -177,7 +203,7 @@ func buildRouter(ctx context.Context, config cfg.Config, logger log.Logger, sett
 
 	definitionList = append(definitionList, definitions.routes...)
 	for _, c := range definitions.children {
-		if childDefinitions, err = buildRouter(ctx, config, logger, settings, c, grp); err != nil {
+		if childDefinitions, err = buildRouter(ctx, config, logger, settings, c, grp, routeMiddlewares...); err != nil {
 			return nil, fmt.Errorf("error building children: %w", err)
 		}
 
@@ -0,0 +1,286 @@
+package httpserver
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const routerScopeKey = "goso.router.scope"
+
+// routerScope holds the error handler, modifier, and validator of a router group. Unset values fall back to the
+// values of the parent group and finally to the package-level defaults.
+type routerScope struct {
+	errorHandler ErrorHandler
+	modifier     Modifier
+	validations  validations
+	validator    binding.StructValidator
+}
+
+// validations collects validator registrations which are applied to a validator engine.
+type validations struct {
+	customValidators []CustomValidator
+	structValidators []StructValidator
+	customTypeFuncs  []CustomTypeFunc
+	aliases          []ValidateAlias
+}
+
+func (v validations) isEmpty() bool {
+	return len(v.customValidators) == 0 && len(v.structValidators) == 0 && len(v.customTypeFuncs) == 0 && len(v.aliases) == 0
+}
+
+func (v validations) merge(other validations) validations {
+	return validations{
+		customValidators: append(append([]CustomValidator{}, v.customValidators...), other.customValidators...),
+		structValidators: append(append([]StructValidator{}, v.structValidators...), other.structValidators...),
+		customTypeFuncs:  append(append([]CustomTypeFunc{}, v.customTypeFuncs...), other.customTypeFuncs...),
+		aliases:          append(append([]ValidateAlias{}, v.aliases...), other.aliases...),
+	}
+}
+
+func (v validations) apply(engine *validator.Validate) error {
+	for _, alias := range v.aliases {
+		engine.RegisterAlias(alias.Alias, alias.Tags)
+	}
+
+	for _, customTypeFunc := range v.customTypeFuncs {
+		engine.RegisterCustomTypeFunc(customTypeFunc.Func, customTypeFunc.Types...)
+	}
+
+	for _, customValidator := range v.customValidators {
+		if err := engine.RegisterValidation(customValidator.Name, customValidator.Validator); err != nil {
+			return fmt.Errorf("can not register custom validator %s: %w", customValidator.Name, err)
+		}
+	}
+
+	for _, structValidator := range v.structValidators {
+		engine.RegisterStructValidation(structValidator.Validator, structValidator.Struct)
+	}
+
+	return nil
+}
+
+// SetErrorHandler sets the error handler rendering errors of the routes of this router and its groups. It takes
+// precedence over the error format of the server settings and the package-level error handler.
+func (d *Router) SetErrorHandler(handler ErrorHandler) {
+	d.errorHandler = handler
+}
+
+// SetModifier sets the input modifier used after binding requests of the routes of this router and its groups.
+func (d *Router) SetModifier(modifier Modifier) {
+	d.modifier = modifier
+}
+
+// AddCustomValidators registers field-level validators for the routes of this router and its groups.
+func (d *Router) AddCustomValidators(customValidators []CustomValidator) {
+	d.validations.customValidators = append(d.validations.customValidators, customValidators...)
+}
+
+// AddStructValidators registers struct-level validators for the routes of this router and its groups.
+func (d *Router) AddStructValidators(structValidators []StructValidator) {
+	d.validations.structValidators = append(d.validations.structValidators, structValidators...)
+}
+
+// AddCustomTypeFuncs registers type conversion functions for the routes of this router and its groups.
+func (d *Router) AddCustomTypeFuncs(customTypeFuncs []CustomTypeFunc) {
+	d.validations.customTypeFuncs = append(d.validations.customTypeFuncs, customTypeFuncs...)
+}
+
+// AddValidateAlias registers validation tag aliases for the routes of this router and its groups.
+func (d *Router) AddValidateAlias(aliases []ValidateAlias) {
+	d.validations.aliases = append(d.validations.aliases, aliases...)
+}
+
+func (d *Router) hasScope() bool {
+	return d.errorHandler != nil || d.modifier != nil || !d.validations.isEmpty()
+}
+
+// getScope merges the scope of this router with the scopes of its parents. It returns nil if neither this router
+// nor its parents define a scope.
+func (d *Router) getScope() (*routerScope, error) {
+	var err error
+	var scope *routerScope
+
+	if d.parent != nil {
+		if scope, err = d.parent.getScope(); err != nil {
+			return nil, err
+		}
+	}
+
+	if !d.hasScope() {
+		return scope, nil
+	}
+
+	result := &routerScope{}
+	if scope != nil {
+		*result = *scope
+	}
+
+	if d.errorHandler != nil {
+		result.errorHandler = d.errorHandler
+	}
+
+	if d.modifier != nil {
+		result.modifier = d.modifier
+	}
+
+	if !d.validations.isEmpty() {
+		result.validations = result.validations.merge(d.validations)
+
+		if result.validator, err = newScopedValidator(result.validations); err != nil {
+			return nil, fmt.Errorf("can not create validator for router %s: %w", d.getAbsolutePath(), err)
+		}
+	}
+
+	return result, nil
+}
+
+func routerScopeMiddleware(scope *routerScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(routerScopeKey, scope)
+		c.Next()
+	}
+}
+
+func getRouterScope(ginCtx *gin.Context) *routerScope {
+	if scope, ok := ginCtx.Value(routerScopeKey).(*routerScope); ok {
+		return scope
+	}
+
+	return nil
+}
+
+func getScopedErrorHandler(ginCtx *gin.Context) ErrorHandler {
+	if scope := getRouterScope(ginCtx); scope != nil && scope.errorHandler != nil {
+		return scope.errorHandler
+	}
+
+	return nil
+}
+
+func getModifier(ginCtx *gin.Context) Modifier {
+	if scope := getRouterScope(ginCtx); scope != nil && scope.modifier != nil {
+		return scope.modifier
+	}
+
+	return defaultModifier
+}
+
+// scopedValidator validates structs like the default validator of Gin, but with its own engine.
+type scopedValidator struct {
+	engine *validator.Validate
+}
+
+// newScopedValidator creates a validator with the package-level registrations and the given ones.
+func newScopedValidator(scoped validations) (binding.StructValidator, error) {
+	engine := validator.New()
+	engine.SetTagName("binding")
+
+	if err := globalValidations.merge(scoped).apply(engine); err != nil {
+		return nil, err
+	}
+
+	acceptScopedTags(scoped)
+
+	return &scopedValidator{engine: engine}, nil
+}
+
+// getScopedValidator returns the validator of the router scope, or nil if the package-level validator of Gin applies.
+func getScopedValidator(ginCtx *gin.Context) binding.StructValidator {
+	if scope := getRouterScope(ginCtx); scope != nil && scope.validator != nil {
+		return scope.validator
+	}
+
+	return nil
+}
+
+var acceptScopedTagsLck sync.Mutex
+
+// acceptScopedTags registers the tags of router scopes unknown to the package-level validator as always valid with
+// it. Gin validates bound inputs with the package-level validator, which panics on unknown tags. The validator of the
+// scope validates the tags after binding.
+func acceptScopedTags(scoped validations) {
+	engine, err := getValidateEngine()
+	if err != nil {
+		return
+	}
+
+	acceptScopedTagsLck.Lock()
+	defer acceptScopedTagsLck.Unlock()
+
+	tags := make([]string, 0, len(scoped.customValidators)+len(scoped.aliases))
+	for _, customValidator := range scoped.customValidators {
+		tags = append(tags, customValidator.Name)
+	}
+
+	for _, alias := range scoped.aliases {
+		tags = append(tags, alias.Alias)
+	}
+
+	for _, tag := range tags {
+		if isUndefinedTag(engine, tag) {
+			_ = engine.RegisterValidation(tag, func(validator.FieldLevel) bool {
+				return true
+			})
+		}
+	}
+}
+
+func isUndefinedTag(engine *validator.Validate, tag string) (undefined bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			undefined = strings.HasPrefix(fmt.Sprint(r), "Undefined validation function")
+		}
+	}()
+
+	_ = engine.Var(nil, tag)
+
+	return false
+}
+
+func (v *scopedValidator) ValidateStruct(obj any) error {
+	if obj == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(obj)
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+
+		if value.Elem().Kind() != reflect.Struct {
+			return v.ValidateStruct(value.Elem().Interface())
+		}
+
+		return v.engine.Struct(obj)
+	case reflect.Struct:
+		return v.engine.Struct(obj)
+	case reflect.Slice, reflect.Array:
+		var errs binding.SliceValidationError
+
+		for i := range value.Len() {
+			if err := v.ValidateStruct(value.Index(i).Interface()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if len(errs) == 0 {
+			return nil
+		}
+
+		return errs
+	default:
+		return nil
+	}
+}
+
+func (v *scopedValidator) Engine() any {
+	return v.engine
+}
@@ -0,0 +1,212 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	logMocks "github.com/justtrackio/gosoline/pkg/log/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type routerScopeInput struct {
+	Tenant string `json:"tenant" binding:"tenant"`
+}
+
+type routerScopeModifier struct{}
+
+func (routerScopeModifier) Struct(_ context.Context, v any) error {
+	v.(*routerScopeInput).Tenant = strings.ToUpper(v.(*routerScopeInput).Tenant)
+
+	return nil
+}
+
+func newRouterScopeTenantValidator(tenant string) CustomValidator {
+	return CustomValidator{
+		Name: "tenant",
+		Validator: func(fl validator.FieldLevel) bool {
+			return fl.Field().String() == tenant
+		},
+	}
+}
+
+func TestRouterScopeCases(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := Bind(func(ctx context.Context, input *routerScopeInput) (Response, error) {
+		return NewTextResponse(input.Tenant), nil
+	})
+	failing := BindN(func(ctx context.Context) (Response, error) {
+		return nil, NewErrorWithStatus(http.StatusConflict, assert.AnError)
+	})
+
+	definitions := &Router{}
+	definitions.SetErrorHandler(func(statusCode int, err error) Response {
+		return NewTextResponse("public: "+err.Error(), WithStatusCode(statusCode))
+	})
+	definitions.AddCustomValidators([]CustomValidator{newRouterScopeTenantValidator("a")})
+	definitions.POST("/tenant", handler)
+	definitions.GET("/fail", failing)
+
+	admin := definitions.Group("/admin")
+	admin.SetErrorHandler(func(statusCode int, err error) Response {
+		return NewTextResponse("admin: "+err.Error(), WithStatusCode(statusCode))
+	})
+	admin.SetModifier(routerScopeModifier{})
+	admin.AddCustomValidators([]CustomValidator{newRouterScopeTenantValidator("b")})
+	admin.POST("/tenant", handler)
+	admin.GET("/fail", failing)
+
+	engine := gin.New()
+	engine.Use(ErrorMiddleware())
+
+	logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+	_, err := buildRouter(t.Context(), cfg.New(), logger, &Settings{}, definitions, engine)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "root validator accepts",
+			method:       http.MethodPost,
+			path:         "/tenant",
+			body:         `{"tenant":"a"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: "a",
+		},
+		{
+			name:         "root validator rejects",
+			method:       http.MethodPost,
+			path:         "/tenant",
+			body:         `{"tenant":"b"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "public: Key: 'routerScopeInput.Tenant' Error:Field validation for 'Tenant' failed on the 'tenant' tag",
+		},
+		{
+			name:         "group validator and modifier",
+			method:       http.MethodPost,
+			path:         "/admin/tenant",
+			body:         `{"tenant":"b"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: "B",
+		},
+		{
+			name:         "group error handler",
+			method:       http.MethodGet,
+			path:         "/admin/fail",
+			expectedCode: http.StatusConflict,
+			expectedBody: "admin: " + assert.AnError.Error(),
+		},
+		{
+			name:         "root error handler",
+			method:       http.MethodGet,
+			path:         "/fail",
+			expectedCode: http.StatusConflict,
+			expectedBody: "public: " + assert.AnError.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set(HeaderContentType, ContentTypeApplicationJson)
+			recorder := httptest.NewRecorder()
+
+			engine.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code)
+			assert.Equal(t, tc.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestRouterScopeReportsInvalidValidator(t *testing.T) {
+	definitions := &Router{}
+	definitions.Group("/broken").AddCustomValidators([]CustomValidator{{Name: ""}})
+
+	logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+	_, err := buildRouter(t.Context(), cfg.New(), logger, &Settings{}, definitions, gin.New())
+
+	assert.ErrorContains(t, err, "can not create validator for router /broken")
+}
+
+type routerScopeNoopValidator struct{}
+
+func (routerScopeNoopValidator) ValidateStruct(any) error {
+	return nil
+}
+
+func (routerScopeNoopValidator) Engine() any {
+	return nil
+}
+
+func TestRouterScopeKeepsGinValidator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ginValidator := binding.Validator
+	t.Cleanup(func() {
+		binding.Validator = ginValidator
+	})
+
+	handler := Bind(func(ctx context.Context, input *routerScopeInput) (Response, error) {
+		return NewTextResponse(input.Tenant), nil
+	})
+
+	newEngine := func(tenant string) *gin.Engine {
+		definitions := &Router{}
+		definitions.AddCustomValidators([]CustomValidator{newRouterScopeTenantValidator(tenant)})
+		definitions.POST("/tenant", handler)
+
+		engine := gin.New()
+		engine.Use(ErrorMiddleware())
+
+		logger := logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(t))
+		_, err := buildRouter(t.Context(), cfg.New(), logger, &Settings{}, definitions, engine)
+		require.NoError(t, err)
+
+		return engine
+	}
+
+	engines := map[string]*gin.Engine{
+		"a": newEngine("a"),
+		"b": newEngine("b"),
+	}
+	assert.Equal(t, ginValidator, binding.Validator, "the validator of gin is left alone")
+
+	assertTenants := func(validator string) {
+		for engineTenant, engine := range engines {
+			for _, tenant := range []string{"a", "b"} {
+				req := httptest.NewRequest(http.MethodPost, "/tenant", strings.NewReader(`{"tenant":"`+tenant+`"}`))
+				req.Header.Set(HeaderContentType, ContentTypeApplicationJson)
+				recorder := httptest.NewRecorder()
+
+				engine.ServeHTTP(recorder, req)
+
+				expectedCode := http.StatusBadRequest
+				if tenant == engineTenant {
+					expectedCode = http.StatusOK
+				}
+
+				assert.Equal(t, expectedCode, recorder.Code, "%s validator, engine %s, tenant %s", validator, engineTenant, tenant)
+			}
+		}
+	}
+
+	assertTenants("gin")
+
+	binding.Validator = routerScopeNoopValidator{}
+	assertTenants("replaced")
+}
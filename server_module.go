@@ -46,6 +46,7 @@ type HttpServer struct {
 	listener       net.Listener
 	settings       *Settings
 	metricRecorder ServerMetricRecorder
+	websockets     *wsTracker
 	healthy        atomic.Bool
 }
 
@@ -80,6 +81,12 @@ func NewServerWithSettings(_ context.Context, name string, definer RouterFactory
 			compressionMiddlewares         []gin.HandlerFunc
 			healthChecker                  kernel.HealthChecker
 			connectionLifeCycleInterceptor gin.HandlerFunc
+			clientIPResolver               *ClientIPResolver
+			rateLimiter                    *RateLimiter
+			idempotencyGuard               *IdempotencyGuard
+			responseCache                  *ResponseCache
+			routeMiddlewares               []routeMiddlewareFactory
+			metricRecorder                 ServerMetricRecorder
 		)
 
 		if tracingInstrumentor, err = tracing.ProvideInstrumentor(ctx, config, logger); err != nil {
@@ -90,8 +97,12 @@ func NewServerWithSettings(_ context.Context, name string, definer RouterFactory
 			return nil, fmt.Errorf("could not create sampling middleware: %w", err)
 		}
 
-		metricRecorder := newServerMetricRecorder(name)
+		if metricRecorder, err = provideServerMetricRecorder(ctx, name); err != nil {
+			return nil, fmt.Errorf("can not provide metric recorder: %w", err)
+		}
+
 		metricMiddleware, setupMetricMiddleware := NewMetricMiddleware(name, metricRecorder)
+		routeLimitsMiddleware, setupRouteLimitsMiddleware := newRouteLimitsMiddleware(settings)
 
 		if compressionMiddlewares, err = configureCompression(settings.Compression); err != nil {
 			return nil, fmt.Errorf("could not configure compression: %w", err)
@@ -101,15 +112,34 @@ func NewServerWithSettings(_ context.Context, name string, definer RouterFactory
 			return nil, fmt.Errorf("could not provide connection life cycle interceptor: %w", err)
 		}
 
+		if clientIPResolver, err = NewClientIPResolver(settings.ClientIP); err != nil {
+			return nil, fmt.Errorf("could not create client ip resolver: %w", err)
+		}
+
 		router := gin.New()
 		router.ContextWithFallback = true
 		router.UseRawPath = settings.Router.UseRawPath
+		router.RemoteIPHeaders = clientIPResolver.ginRemoteIPHeaders()
+
+		if err = router.SetTrustedProxies(settings.ClientIP.TrustedProxies); err != nil {
+			return nil, fmt.Errorf("could not set trusted proxies: %w", err)
+		}
+
+		router.Use(ClientIPMiddleware(clientIPResolver))
 		router.Use(samplingMiddleware)
 		router.Use(metricMiddleware)
 		router.Use(LoggingMiddleware(logger, settings.Logging))
 		router.Use(compressionMiddlewares...)
-		router.Use(MaxBodySizeMiddleware(settings.MaxBodyBytes))
 		router.Use(ErrorMiddlewareWithSettings(settings.Errors))
+		router.Use(routeLimitsMiddleware)
+
+		if settings.Compression.Decompression {
+			router.Use(DecompressionMiddleware(settings.Compression, metricRecorder))
+		}
+
+		router.Use(NegotiationMiddleware(settings.Negotiation))
+		router.Use(SseMiddleware(settings.Sse))
+		router.Use(UploadMiddleware(settings.Upload, metricRecorder))
 		router.Use(RecoveryWithSentry(logger))
 		router.Use(location.Default())
 		router.Use(connectionLifeCycleInterceptor)
@@ -118,7 +148,7 @@ func NewServerWithSettings(_ context.Context, name string, definer RouterFactory
 			return nil, fmt.Errorf("can not get health checker: %w", err)
 		}
 		router.GET("/health", buildHealthCheckHandler(logger, healthChecker))
-		router.Use(ConcurrentRequestLimitMiddleware(settings.Concurrency))
+		router.Use(ConcurrentRequestLimitMiddlewareWithInterfaces(settings.Concurrency, metricRecorder))
 		router.Use(ChaosMiddleware(ctx, logger, settings.Chaos))
 
 		definitions := &Router{}
@@ -126,11 +156,50 @@ func NewServerWithSettings(_ context.Context, name string, definer RouterFactory
 			return nil, fmt.Errorf("could not define routes: %w", err)
 		}
 
-		if definitionList, err = buildRouter(ctx, config, logger, settings, definitions, router); err != nil {
+		if settings.RateLimit.Enabled {
+			if rateLimiter, err = NewRateLimiter(ctx, config, logger, settings.RateLimit); err != nil {
+				return nil, fmt.Errorf("can not create rate limiter: %w", err)
+			}
+
+			routeMiddlewares = append(routeMiddlewares, func(definition Definition) gin.HandlerFunc {
+				return rateLimiter.RouteMiddleware(definition.HttpMethod, definition.getAbsolutePath())
+			})
+		}
+
+		if settings.Idempotency.Enabled {
+			if idempotencyGuard, err = NewIdempotencyGuard(ctx, config, logger, settings.Idempotency); err != nil {
+				return nil, fmt.Errorf("can not create idempotency guard: %w", err)
+			}
+
+			routeMiddlewares = append(routeMiddlewares, func(definition Definition) gin.HandlerFunc {
+				return idempotencyGuard.RouteMiddleware(definition.HttpMethod, definition.getAbsolutePath())
+			})
+		}
+
+		if settings.ResponseCache.Enabled {
+			if responseCache, err = NewResponseCache(ctx, config, logger, settings.ResponseCache); err != nil {
+				return nil, fmt.Errorf("can not create response cache: %w", err)
+			}
+
+			routeMiddlewares = append(routeMiddlewares, func(definition Definition) gin.HandlerFunc {
+				return responseCache.RouteMiddleware(definition.HttpMethod, definition.getAbsolutePath())
+			})
+		}
+
+		routeMiddlewares = append(routeMiddlewares, streamingRouteMiddleware)
+
+		if definitionList, err = buildRouter(ctx, config, logger, settings, definitions, router, routeMiddlewares...); err != nil {
 			return nil, fmt.Errorf("could not build router: %w", err)
 		}
 
 		setupMetricMiddleware(definitionList)
+		setupRouteLimitsMiddleware(definitionList)
+
+		if settings.OpenApi.Enabled {
+			if err = addOpenApiEndpoint(router, settings, definitionList); err != nil {
+				return nil, fmt.Errorf("could not add openapi endpoint: %w", err)
+			}
+		}
 
 		if err = appendMetadata(ctx, name, router); err != nil {
 			return nil, fmt.Errorf("can not append metadata: %w", err)
@@ -150,6 +219,7 @@ func NewWithInterfaces(
 	metricRecorder ServerMetricRecorder,
 ) (*HttpServer, error) {
 	connectionPressureManager := NewConnectionPressureManager(ctx, metricRecorder)
+	websockets := newWsTracker(ctx, settings.Websocket, metricRecorder)
 
 	server := &http.Server{
 		Addr:         ":" + settings.Port,
@@ -158,7 +228,9 @@ func NewWithInterfaces(
 		WriteTimeout: settings.Timeout.Write,
 		IdleTimeout:  settings.Timeout.Idle,
 		ConnState:    connectionPressureManager.ConnState,
+		ConnContext:  websockets.connContext,
 	}
+	server.RegisterOnShutdown(websockets.shutdown)
 
 	var err error
 	var listener net.Listener
@@ -183,6 +255,7 @@ func NewWithInterfaces(
 		listener:       listener,
 		settings:       settings,
 		metricRecorder: metricRecorder,
+		websockets:     websockets,
 	}
 
 	return apiServer, nil
@@ -237,6 +310,10 @@ func (s *HttpServer) waitForStop(ctx context.Context) error {
 		return fmt.Errorf("server shutdown: %w", err)
 	}
 
+	if err := s.websockets.wait(shutdownCtx); err != nil {
+		return fmt.Errorf("websocket shutdown: %w", err)
+	}
+
 	return nil
 }
 
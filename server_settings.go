@@ -3,11 +3,34 @@ package httpserver
 import "time"
 
 type (
-	// CompressionSettings control gzip support for requests and responses.
+	// ClientIPSettings configure how the caller IP is resolved from client IP headers.
+	ClientIPSettings struct {
+		// TrustedProxies lists the CIDRs or IPs of the proxies allowed to set client IP headers. Headers of requests sent
+		// by other peers are ignored.
+		TrustedProxies []string `cfg:"trusted_proxies" default:"127.0.0.0/8,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,::1/128,fc00::/7"`
+		// Headers lists the client IP headers in order of precedence. Forwarded is parsed as defined by RFC 7239.
+		Headers []string `cfg:"headers"         default:"Forwarded,X-Forwarded-For,X-Real-IP"`
+	}
+
+	// CompressionSettings control gzip, brotli and zstd support for requests and responses.
 	// By default, compressed requests are accepted and compressed responses are returned (if accepted by the client).
 	CompressionSettings struct {
-		Level         string `cfg:"level"         default:"default" validate:"oneof=none default best fast 0 1 2 3 4 5 6 7 8 9"`
-		Decompression bool   `cfg:"decompression" default:"true"`
+		// Level is the gzip compression level. none disables gzip encoded responses.
+		Level string `cfg:"level"         default:"default" validate:"oneof=none default best fast 0 1 2 3 4 5 6 7 8 9"`
+		// BrotliLevel is the brotli compression level. none disables brotli encoded responses.
+		BrotliLevel string `cfg:"brotli_level"  default:"default" validate:"oneof=none default best fast 0 1 2 3 4 5 6 7 8 9 10 11"`
+		// ZstdLevel is the zstd compression level. none disables zstd encoded responses.
+		ZstdLevel string `cfg:"zstd_level"    default:"default" validate:"oneof=none default best fast better"`
+		// MinSize is the minimum size of a response body in bytes to be compressed. Smaller responses are sent uncompressed.
+		MinSize int `cfg:"min_size"      default:"0"       validate:"min=0"`
+		// Decompression enables decoding gzip, brotli and zstd encoded request bodies. Bodies with any other content
+		// encoding are rejected.
+		Decompression bool `cfg:"decompression" default:"true"`
+		// MaxDecompressedBytes is the maximum size of a decoded request body in bytes. 0 disables the limit.
+		MaxDecompressedBytes int64 `cfg:"max_decompressed_bytes" default:"10485760" validate:"min=0"`
+		// MaxRatio is the maximum ratio between the decoded and the encoded size of a request body. It is checked once
+		// more than 1 MiB have been decoded, as small bodies can have a high ratio. 0 disables the limit.
+		MaxRatio int `cfg:"max_ratio" default:"100" validate:"min=0"`
 		// Exclude files by path, extension, or regular expression from being considered for compression.
 		// Useful if you are serving a format unknown to Gosoline.
 		Exclude CompressionExcludeSettings `cfg:"exclude"`
@@ -37,6 +60,39 @@ type (
 	// ErrorsSettings configures error responses returned by the error middleware.
 	ErrorsSettings struct {
 		Privacy string `cfg:"privacy" default:"private" validate:"oneof=public private"`
+		// Format selects the error response body: json writes {"err": "..."}, problem writes RFC 9457 application/problem+json.
+		Format string `cfg:"format"  default:"json"    validate:"oneof=json problem"`
+	}
+
+	// NegotiationSettings configures the content negotiation of negotiated responses.
+	NegotiationSettings struct {
+		// DefaultContentType is used if the client does not send an Accept header.
+		DefaultContentType string `cfg:"default_content_type" default:"application/json" validate:"oneof=application/json application/xml text/xml application/x-protobuf"`
+	}
+
+	// UploadSettings configures the binding of multipart/form-data requests. The limits are checked while the files
+	// are read. The max body size of the server and its routes still applies to the whole request.
+	UploadSettings struct {
+		// MaxFileBytes is the maximum size of a single file. A value of 0 disables the limit.
+		MaxFileBytes int64 `cfg:"max_file_bytes" default:"10485760" validate:"min=0"`
+		// MaxTotalBytes is the maximum size of all files of a request. A value of 0 disables the limit.
+		MaxTotalBytes int64 `cfg:"max_total_bytes" default:"10485760" validate:"min=0"`
+		// MaxFiles is the maximum number of files of a request. A value of 0 disables the limit.
+		MaxFiles int `cfg:"max_files" default:"32" validate:"min=0"`
+		// MaxValueBytes is the maximum size of a single form value.
+		MaxValueBytes int64 `cfg:"max_value_bytes" default:"1048576" validate:"min=1"`
+		// ContentTypes lists the allowed content types of files, like image/png or image/*. The content type of a
+		// file is sniffed from its content. An empty list allows all content types.
+		ContentTypes []string `cfg:"content_types"`
+	}
+
+	// OpenApiSettings configures the optional endpoint serving the generated OpenAPI document.
+	OpenApiSettings struct {
+		Enabled     bool   `cfg:"enabled"     default:"false"`
+		Path        string `cfg:"path"        default:"/openapi.json"`
+		Title       string `cfg:"title"`
+		Version     string `cfg:"version"     default:"1.0.0"`
+		Description string `cfg:"description"`
 	}
 
 	// ProfilingSettings configures the optional profiling HTTP server.
@@ -50,6 +106,109 @@ type (
 		Port int `cfg:"port" default:"8091"`
 	}
 
+	// RateLimitSettings configures token bucket rate limiting of requests.
+	RateLimitSettings struct {
+		Enabled bool `cfg:"enabled" default:"false"`
+		// Key selects how requests are assigned to buckets: ip, api_key, subject (provided by the auth package),
+		// or the name of a key function added with AddRateLimitKeyFunc.
+		Key string `cfg:"key" default:"ip"`
+		// ApiKeyHeader is the header read by the api_key key.
+		ApiKeyHeader string `cfg:"api_key_header" default:"X-API-KEY"`
+		// Store selects where buckets are kept: in_memory or the name of a store added with AddRateLimitStoreFactory.
+		Store string `cfg:"store" default:"in_memory"`
+		// Rate is the number of requests allowed per period. A value of 0 disables the limit.
+		Rate int `cfg:"rate" default:"100" validate:"min=0"`
+		// Period is the duration in which Rate requests are allowed.
+		Period time.Duration `cfg:"period" default:"1s" validate:"min=1000000"`
+		// Burst is the size of the bucket. A value of 0 uses Rate.
+		Burst int `cfg:"burst" default:"0" validate:"min=0"`
+		// Routes override the limit of single routes. Each overridden route uses its own buckets.
+		Routes []RateLimitRouteSettings `cfg:"routes"`
+	}
+
+	// IdempotencySettings configure the idempotency middleware of POST and PATCH routes.
+	IdempotencySettings struct {
+		Enabled bool `cfg:"enabled" default:"false"`
+		// Header is the request header carrying the idempotency key. Requests without key aren't affected.
+		Header string `cfg:"header" default:"Idempotency-Key"`
+		// Store selects where responses are kept: in_memory or the name of a store added with AddIdempotencyStoreFactory.
+		Store string `cfg:"store" default:"in_memory"`
+		// Ttl is the duration a response is replayed for retries with the same key.
+		Ttl time.Duration `cfg:"ttl" default:"24h" validate:"min=1000000000"`
+		// InFlightTtl is the maximum duration a key is locked by a request in flight, in case the server stops before
+		// it responded.
+		InFlightTtl time.Duration `cfg:"in_flight_ttl" default:"5m" validate:"min=1000000000"`
+		// MaxResponseBytes is the maximum size of a stored response body. Keys of larger responses are released.
+		MaxResponseBytes int64 `cfg:"max_response_bytes" default:"1048576" validate:"min=1"`
+	}
+
+	// ResponseCacheSettings configure the response cache of the GET routes listed in Routes. Responses are only
+	// cached if their Cache-Control header allows shared caches to store them.
+	ResponseCacheSettings struct {
+		Enabled bool `cfg:"enabled" default:"false"`
+		// Store selects where responses are kept: in_memory or the name of a store added with AddResponseCacheStoreFactory.
+		Store string `cfg:"store" default:"in_memory"`
+		// MaxEntries is the number of responses kept by the in_memory store before the least recently used ones are evicted.
+		MaxEntries int `cfg:"max_entries" default:"10000" validate:"min=1"`
+		// MaxResponseBytes is the maximum size of a cached response body.
+		MaxResponseBytes int64 `cfg:"max_response_bytes" default:"1048576" validate:"min=1"`
+		// Key lists what the cache key is derived from besides the path of a request: query, subject (provided by the
+		// auth package), or the name of a key function added with AddResponseCacheKeyFunc.
+		Key []string `cfg:"key" default:"query"`
+		// Headers lists the request headers the cache key is derived from.
+		Headers []string `cfg:"headers"`
+		// CredentialHeaders lists the request headers carrying credentials besides Authorization and Cookie. Requests
+		// with credentials are only cached by routes whose key identifies the caller, like subject.
+		CredentialHeaders []string `cfg:"credential_headers" default:"X-API-KEY"`
+		// RevalidationTimeout is the maximum duration of the background revalidation of a stale response.
+		RevalidationTimeout time.Duration `cfg:"revalidation_timeout" default:"10s" validate:"min=1000000"`
+		// Routes lists the GET routes whose responses are cached.
+		Routes []ResponseCacheRouteSettings `cfg:"routes"`
+	}
+
+	// ResponseCacheRouteSettings enables the response cache for the GET route with Path. Non-empty Key and Headers
+	// replace the ones of the server for the route.
+	ResponseCacheRouteSettings struct {
+		// Path of the route as registered, e.g. /v1/reports/:id.
+		Path string `cfg:"path"`
+		// Key lists what the cache key is derived from besides the path of a request.
+		Key []string `cfg:"key"`
+		// Headers lists the request headers the cache key is derived from.
+		Headers []string `cfg:"headers"`
+	}
+
+	// RateLimitRouteSettings overrides the rate limit of the routes matching Method and Path.
+	RateLimitRouteSettings struct {
+		// Method of the route. An empty method matches all methods.
+		Method string `cfg:"method"`
+		// Path of the route as registered, e.g. /v1/users/:id.
+		Path string `cfg:"path"`
+		// Rate is the number of requests allowed per period. A value of 0 disables the limit for the route.
+		Rate int `cfg:"rate" validate:"min=0"`
+		// Period is the duration in which Rate requests are allowed. A value of 0 uses the period of the server.
+		Period time.Duration `cfg:"period"`
+		// Burst is the size of the bucket. A value of 0 uses Rate.
+		Burst int `cfg:"burst" validate:"min=0"`
+	}
+
+	// RouteSettings override the limits of the server for the routes matching Method and Path. They take precedence
+	// over the limits set with Router.SetLimits.
+	RouteSettings struct {
+		// Method of the route. An empty method matches all methods.
+		Method string `cfg:"method"`
+		// Path of the route as registered, e.g. /v1/uploads/:id.
+		Path string `cfg:"path"`
+		// MaxBodyBytes is the maximum size of an incoming request body in bytes. A negative value disables the limit.
+		MaxBodyBytes int64 `cfg:"max_body_bytes"`
+		// Deadline is the maximum duration of the handler, replacing the handler timeout of the server. It also applies
+		// to server-sent event and WebSocket routes, which don't use the handler timeout of the server.
+		Deadline time.Duration `cfg:"deadline" validate:"min=0"`
+		// WriteTimeout replaces the write timeout of the server for the response.
+		WriteTimeout time.Duration `cfg:"write_timeout" validate:"min=0"`
+		// Priority of the requests of the route under pressure, either low, normal, high or critical.
+		Priority string `cfg:"priority" validate:"omitempty,oneof=low normal high critical"`
+	}
+
 	// RouterSettings configures Gin router behavior.
 	RouterSettings struct {
 		UseRawPath bool `cfg:"use_raw_path" default:"false"`
@@ -62,6 +221,8 @@ type (
 		Port string `cfg:"port"        default:"8080"`
 		// Mode is either debug, release, test.
 		Mode string `cfg:"mode"        default:"release" validate:"oneof=release debug test"`
+		// ClientIP settings control the trusted proxies and headers used to resolve the caller IP.
+		ClientIP ClientIPSettings `cfg:"client_ip"`
 		// Compression settings.
 		Compression CompressionSettings `cfg:"compression"`
 		// Gin Router settings.
@@ -72,13 +233,31 @@ type (
 		Logging LoggingSettings `cfg:"logging"`
 		// Errors settings.
 		Errors ErrorsSettings `cfg:"errors"`
+		// Negotiation settings control the encoding of negotiated responses.
+		Negotiation NegotiationSettings `cfg:"negotiation"`
 		// MaxBodyBytes is the maximum size of an incoming request body in bytes.
 		// A value of 0 disables the limit. Default: 10 MiB.
 		MaxBodyBytes int64 `cfg:"max_body_bytes" default:"10485760"`
+		// Routes override the max body size and timeouts of single routes.
+		Routes []RouteSettings `cfg:"routes"`
 		// Concurrency settings control request and connection pressure limits.
 		Concurrency ConcurrencySettings `cfg:"concurrency"`
+		// RateLimit settings control token bucket rate limiting of requests.
+		RateLimit RateLimitSettings `cfg:"rate_limit"`
+		// Idempotency settings control the replay of responses to retried requests with an idempotency key.
+		Idempotency IdempotencySettings `cfg:"idempotency"`
+		// ResponseCache settings control the caching of responses of GET routes.
+		ResponseCache ResponseCacheSettings `cfg:"response_cache"`
 		// Chaos settings control optional random delays and rejections for resilience testing.
 		Chaos ChaosSettings `cfg:"chaos"`
+		// Sse settings control the SSE streams of the server.
+		Sse SseSettings `cfg:"sse"`
+		// Websocket settings control WebSocket connections of the server.
+		Websocket WebsocketSettings `cfg:"websocket"`
+		// Upload settings control the limits of multipart/form-data requests.
+		Upload UploadSettings `cfg:"upload"`
+		// OpenApi settings control the endpoint serving the generated OpenAPI document.
+		OpenApi OpenApiSettings `cfg:"openapi"`
 	}
 
 	// ConcurrencySettings configures pressure limits for a HTTP server.
@@ -94,6 +273,43 @@ type (
 		// RetryAfter is written as Retry-After header when MaxRequests is reached.
 		// A value of 0 omits the header.
 		RetryAfter time.Duration `cfg:"retry_after" default:"0" validate:"min=0"`
+		// QueueSize is the maximum number of requests waiting for a free slot once the limit is reached.
+		// A value of 0 rejects requests immediately.
+		QueueSize int `cfg:"queue_size" default:"0" validate:"min=0"`
+		// QueueTimeout is the maximum duration a request waits in the queue before it is rejected.
+		// A value of 0 waits until the request is canceled.
+		QueueTimeout time.Duration `cfg:"queue_timeout" default:"1s" validate:"min=0"`
+		// PriorityHeader is the request header lowering the priority of a request, e.g. X-Request-Priority.
+		// Requests can't raise the priority of their route. An empty header keeps the priority of the route.
+		PriorityHeader string `cfg:"priority_header" default:""`
+		// LowPriorityPercent is the share of the limit requests with low priority can use. A value of 0 uses the whole limit.
+		LowPriorityPercent int `cfg:"low_priority_percent" default:"50" validate:"min=0,max=100"`
+		// NormalPriorityPercent is the share of the limit requests with normal priority can use, the remaining slots are
+		// reserved for requests with high priority. A value of 0 uses the whole limit.
+		NormalPriorityPercent int `cfg:"normal_priority_percent" default:"100" validate:"min=0,max=100"`
+		// Adaptive settings replace the fixed MaxRequests with a limit adjusted to the observed latency.
+		Adaptive AdaptiveConcurrencySettings `cfg:"adaptive"`
+	}
+
+	// AdaptiveConcurrencySettings configure the algorithm adjusting the concurrency limit.
+	AdaptiveConcurrencySettings struct {
+		// Algorithm adjusting the limit, either aimd or gradient. An empty algorithm disables the adaptive limit.
+		Algorithm string `cfg:"algorithm" default:"" validate:"omitempty,oneof=aimd gradient"`
+		// InitialLimit is the limit before the first adjustment.
+		InitialLimit int `cfg:"initial_limit" default:"20" validate:"min=1"`
+		// MinLimit is the lower bound of the limit.
+		MinLimit int `cfg:"min_limit" default:"1" validate:"min=1"`
+		// MaxLimit is the upper bound of the limit.
+		MaxLimit int `cfg:"max_limit" default:"1000" validate:"min=1"`
+		// LatencyThreshold is the latency above which the aimd algorithm decreases the limit.
+		LatencyThreshold time.Duration `cfg:"latency_threshold" default:"1s" validate:"min=1"`
+		// BackoffPercent is the percentage the aimd algorithm keeps of the limit when decreasing it.
+		BackoffPercent int `cfg:"backoff_percent" default:"90" validate:"min=1,max=99"`
+		// TolerancePercent is the latency, in percent of the long-term latency, the gradient algorithm tolerates
+		// before decreasing the limit.
+		TolerancePercent int `cfg:"tolerance_percent" default:"150" validate:"min=100"`
+		// SmoothingPercent is the weight of a new limit computed by the gradient algorithm in percent.
+		SmoothingPercent int `cfg:"smoothing_percent" default:"20" validate:"min=1,max=100"`
 	}
 
 	// TimeoutSettings configures IO timeouts.
@@ -107,10 +323,53 @@ type (
 		Idle time.Duration `cfg:"idle"     default:"60s" validate:"min=1000000000"`
 		// Drain timeout is the maximum amount of time to wait after receiving the kernel stop signal and actually shutting down the server
 		Drain time.Duration `cfg:"drain"    default:"0"   validate:"min=0"`
+		// Handler timeout is the maximum duration of a handler. Requests it doesn't respond to in time are answered with
+		// HandlerStatusCode. 0 disables the timeout, routes can override it. Server-sent event and WebSocket routes
+		// don't use it.
+		Handler time.Duration `cfg:"handler" default:"0" validate:"min=0"`
+		// HandlerStatusCode is the status code of requests which exceeded the handler timeout.
+		HandlerStatusCode int `cfg:"handler_status_code" default:"504" validate:"oneof=503 504"`
 		// Shutdown timeout is the maximum amount of time to wait for serving active requests before stopping the server
 		Shutdown time.Duration `cfg:"shutdown" default:"60s" validate:"min=1000000000"`
 	}
 
+	// WebsocketSettings configures WebSocket connections created by the BindWs family of functions.
+	WebsocketSettings struct {
+		// HandshakeTimeout is the maximum duration for completing the upgrade handshake.
+		HandshakeTimeout time.Duration `cfg:"handshake_timeout" default:"10s"     validate:"min=0"`
+		// MaxMessageBytes is the maximum size of a message received from the client. Larger messages close the connection.
+		MaxMessageBytes int64 `cfg:"max_message_bytes" default:"1048576" validate:"min=1"`
+		// PingInterval is the interval in which pings are sent to the client.
+		PingInterval time.Duration `cfg:"ping_interval"     default:"30s"     validate:"min=1000000"`
+		// PongTimeout is the maximum duration to wait for any message or pong from the client before closing the connection.
+		PongTimeout time.Duration `cfg:"pong_timeout"      default:"60s"     validate:"min=1000000"`
+		// WriteTimeout is the maximum duration for writing a single message.
+		WriteTimeout time.Duration `cfg:"write_timeout"     default:"10s"     validate:"min=1000000"`
+		// Compression enables negotiating per-message compression (RFC 7692) with clients.
+		Compression bool `cfg:"compression"       default:"true"`
+		// AllowedOrigins lists the origins allowed to connect. If empty, only same-origin requests or requests without
+		// an Origin header are accepted. Use * to allow all origins.
+		AllowedOrigins []string `cfg:"allowed_origins"`
+	}
+
+	// SseSettings configures the SSE streams created by the BindSse family of functions.
+	SseSettings struct {
+		// HeartbeatInterval is the interval in which heartbeat comments are sent to keep idle streams open. A value
+		// of 0 disables heartbeats.
+		HeartbeatInterval time.Duration `cfg:"heartbeat_interval" default:"5s" validate:"min=0"`
+	}
+
+	// SseHubSettings configures the queues of the subscribers of an SseHub.
+	SseHubSettings struct {
+		// QueueSize is the number of published events buffered per subscriber.
+		QueueSize int `cfg:"queue_size"    default:"64"   validate:"min=1"`
+		// SlowConsumer selects what happens if the queue of a subscriber is full: drop the event, disconnect the
+		// subscriber or block the publisher for up to BlockTimeout before disconnecting the subscriber.
+		SlowConsumer string `cfg:"slow_consumer" default:"drop" validate:"oneof=drop disconnect block"`
+		// BlockTimeout is the maximum duration a publisher waits for a subscriber with the block policy.
+		BlockTimeout time.Duration `cfg:"block_timeout" default:"1s"   validate:"min=0"`
+	}
+
 	// ConnectionLifeCycleAdvisorSettings configures the traffic distributor middleware controlling maximum life of client connections.
 	ConnectionLifeCycleAdvisorSettings struct {
 		Enabled                   bool          `cfg:"enabled"           default:"true"`
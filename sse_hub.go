@@ -0,0 +1,280 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/justtrackio/gosoline/pkg/appctx"
+	"github.com/justtrackio/gosoline/pkg/cfg"
+	"github.com/justtrackio/gosoline/pkg/log"
+)
+
+const (
+	// SseSlowConsumerDrop drops events for subscribers with a full queue.
+	SseSlowConsumerDrop = "drop"
+	// SseSlowConsumerDisconnect disconnects subscribers with a full queue.
+	SseSlowConsumerDisconnect = "disconnect"
+	// SseSlowConsumerBlock blocks the publisher until the queue of the subscriber has room again. Subscribers which
+	// don't catch up within the block timeout are disconnected.
+	SseSlowConsumerBlock = "block"
+)
+
+// ErrSseSlowConsumer is returned by SseHub.Subscribe if the subscriber was disconnected because it could not keep up
+// with the published events.
+var ErrSseSlowConsumer = errors.New("sse: subscriber too slow")
+
+type (
+	// SseHub publishes events to all SSE writers subscribed to a topic. Every subscriber has a bounded queue, so a
+	// slow client doesn't slow down the publisher or the other subscribers.
+	SseHub struct {
+		logger         log.Logger
+		metricRecorder ServerMetricRecorder
+		settings       SseHubSettings
+		replay         SseReplayStore
+		lck            sync.RWMutex
+		topics         map[string]map[*sseSubscriber]struct{}
+	}
+
+	sseSubscriber struct {
+		queue  chan SseEvent
+		closed chan struct{}
+		once   sync.Once
+		err    error
+	}
+
+	sseHubKey string
+)
+
+// ProvideSseHub returns the SseHub of the named server. Handlers of the server share the hub, its subscribers are
+// reported in the metrics of the server.
+func ProvideSseHub(ctx context.Context, config cfg.Config, logger log.Logger, serverName string) (*SseHub, error) {
+	return appctx.Provide(ctx, sseHubKey(serverName), func() (*SseHub, error) {
+		return NewSseHub(ctx, config, logger, serverName)
+	})
+}
+
+// NewSseHub creates an SseHub from the sse_hub settings of the named server.
+func NewSseHub(ctx context.Context, config cfg.Config, logger log.Logger, serverName string) (*SseHub, error) {
+	var err error
+	var metricRecorder ServerMetricRecorder
+
+	settings := SseHubSettings{}
+	key := HttpserverSettingsKey(serverName) + ".sse_hub"
+	if err = config.UnmarshalKey(key, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sse hub settings: %w", err)
+	}
+
+	if metricRecorder, err = provideServerMetricRecorder(ctx, serverName); err != nil {
+		return nil, fmt.Errorf("can not provide metric recorder: %w", err)
+	}
+
+	return NewSseHubWithInterfaces(logger, metricRecorder, settings)
+}
+
+// NewSseHubWithInterfaces creates an SseHub from dependencies.
+func NewSseHubWithInterfaces(logger log.Logger, metricRecorder ServerMetricRecorder, settings SseHubSettings) (*SseHub, error) {
+	switch settings.SlowConsumer {
+	case SseSlowConsumerDrop, SseSlowConsumerDisconnect, SseSlowConsumerBlock:
+	default:
+		return nil, fmt.Errorf("unknown slow consumer policy %q", settings.SlowConsumer)
+	}
+
+	if settings.QueueSize <= 0 {
+		return nil, fmt.Errorf("the queue size has to be positive, got %d", settings.QueueSize)
+	}
+
+	return &SseHub{
+		logger:         logger.WithChannel("sse-hub"),
+		metricRecorder: metricRecorder,
+		settings:       settings,
+		topics:         make(map[string]map[*sseSubscriber]struct{}),
+	}, nil
+}
+
+// Subscribe sends the events published to the given topics to the writer until the client disconnects, the context
+// is canceled or the subscriber is disconnected for being too slow. It is meant to be called at the end of a BindSse
+// handler and returns ErrClientDisconnected if the client is gone, which BindSse handles as a clean exit.
+//
+// If replay is enabled, the events of the topics a reconnecting client has missed are sent first. The subscriber is
+// registered before they are read from the store, so events published in between are neither lost nor sent twice.
+func (h *SseHub) Subscribe(ctx context.Context, writer *SseWriter, topics ...string) error {
+	subscriber := &sseSubscriber{
+		queue:  make(chan SseEvent, h.settings.QueueSize),
+		closed: make(chan struct{}),
+	}
+
+	h.add(ctx, subscriber, topics)
+	defer h.remove(ctx, subscriber, topics)
+
+	replayed, err := h.replayTo(writer, topics)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ErrClientDisconnected
+		case <-writer.ctx.Done():
+			return ErrClientDisconnected
+		case <-subscriber.closed:
+			return subscriber.err
+		case event := <-subscriber.queue:
+			// events published while replaying are queued and replayed
+			if _, ok := replayed[event.Id]; ok && event.Id != "" {
+				continue
+			}
+
+			if err := writer.SendEvent(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// replayTo sends the events of the topics the client of the writer has missed and returns their ids.
+func (h *SseHub) replayTo(writer *SseWriter, topics []string) (map[string]struct{}, error) {
+	replayed := make(map[string]struct{})
+
+	if h.replay == nil || writer.lastEventId == "" {
+		return replayed, nil
+	}
+
+	for _, topic := range topics {
+		events, err := writer.replay(topic, h.replay)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			replayed[event.Id] = struct{}{}
+		}
+	}
+
+	return replayed, nil
+}
+
+// EnableReplay records every published event once in the store, using the topic as stream, and sends subscribers the
+// events their client has missed since its Last-Event-ID. It has to be called before events are published.
+func (h *SseHub) EnableReplay(store SseReplayStore) {
+	h.replay = store
+}
+
+// Publish queues the event for all subscribers of the topic and returns the number of subscribers it was queued for.
+// If replay is enabled, the event is recorded before it is queued. Events which can't be recorded are published
+// nonetheless, but can't be replayed.
+func (h *SseHub) Publish(ctx context.Context, topic string, event SseEvent) int {
+	if h.replay != nil {
+		recorded, err := h.replay.Append(ctx, topic, event)
+		if err != nil {
+			h.logger.Warn(ctx, "can not record event of sse topic %s: %s", topic, err)
+		} else {
+			event = recorded
+		}
+	}
+
+	h.lck.RLock()
+	subscribers := make([]*sseSubscriber, 0, len(h.topics[topic]))
+	for subscriber := range h.topics[topic] {
+		subscribers = append(subscribers, subscriber)
+	}
+	h.lck.RUnlock()
+
+	queued := 0
+
+	for _, subscriber := range subscribers {
+		if h.enqueue(ctx, topic, subscriber, event) {
+			queued++
+		}
+	}
+
+	return queued
+}
+
+// Subscribers returns the number of subscribers of the topic.
+func (h *SseHub) Subscribers(topic string) int {
+	h.lck.RLock()
+	defer h.lck.RUnlock()
+
+	return len(h.topics[topic])
+}
+
+func (h *SseHub) enqueue(ctx context.Context, topic string, subscriber *sseSubscriber, event SseEvent) bool {
+	select {
+	case subscriber.queue <- event:
+		return true
+	case <-subscriber.closed:
+		return false
+	default:
+	}
+
+	switch h.settings.SlowConsumer {
+	case SseSlowConsumerDisconnect:
+		h.logger.Warn(ctx, "disconnecting slow sse subscriber of topic %s", topic)
+		subscriber.close(ErrSseSlowConsumer)
+
+		return false
+	case SseSlowConsumerBlock:
+		timer := time.NewTimer(h.settings.BlockTimeout)
+		defer timer.Stop()
+
+		select {
+		case subscriber.queue <- event:
+			return true
+		case <-subscriber.closed:
+			return false
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			h.logger.Warn(ctx, "disconnecting sse subscriber of topic %s blocking for more than %s", topic, h.settings.BlockTimeout)
+			subscriber.close(ErrSseSlowConsumer)
+
+			return false
+		}
+	default:
+		return false
+	}
+}
+
+func (h *SseHub) add(ctx context.Context, subscriber *sseSubscriber, topics []string) {
+	h.lck.Lock()
+	defer h.lck.Unlock()
+
+	for _, topic := range topics {
+		if h.topics[topic] == nil {
+			h.topics[topic] = make(map[*sseSubscriber]struct{})
+		}
+
+		h.topics[topic][subscriber] = struct{}{}
+	}
+
+	h.metricRecorder.TrackSseSubscribed(ctx)
+}
+
+func (h *SseHub) remove(ctx context.Context, subscriber *sseSubscriber, topics []string) {
+	subscriber.close(ErrClientDisconnected)
+
+	h.lck.Lock()
+	defer h.lck.Unlock()
+
+	for _, topic := range topics {
+		delete(h.topics[topic], subscriber)
+
+		if len(h.topics[topic]) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+
+	// the request context might be canceled already, but the metric has to be written nonetheless
+	h.metricRecorder.TrackSseUnsubscribed(context.WithoutCancel(ctx))
+}
+
+func (s *sseSubscriber) close(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.closed)
+	})
+}
@@ -0,0 +1,335 @@
+package httpserver_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/gosoline-project/httpserver/mocks"
+	logMocks "github.com/justtrackio/gosoline/pkg/log/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// blockingSseResponseWriter blocks every write until it is released, writing signals the start of a write.
+type blockingSseResponseWriter struct {
+	*httptest.ResponseRecorder
+	lck     sync.Mutex
+	writing chan struct{}
+	release chan struct{}
+}
+
+func newBlockingSseResponseWriter() *blockingSseResponseWriter {
+	return &blockingSseResponseWriter{
+		ResponseRecorder: httptest.NewRecorder(),
+		writing:          make(chan struct{}, 1),
+		release:          make(chan struct{}),
+	}
+}
+
+func (w *blockingSseResponseWriter) Write(data []byte) (int, error) {
+	select {
+	case w.writing <- struct{}{}:
+	default:
+	}
+
+	<-w.release
+
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	return w.ResponseRecorder.Write(data)
+}
+
+func (w *blockingSseResponseWriter) body() string {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+
+	return w.Body.String()
+}
+
+func TestSseHubTestSuite(t *testing.T) {
+	suite.Run(t, new(SseHubTestSuite))
+}
+
+type SseHubTestSuite struct {
+	suite.Suite
+
+	metricRecorder *mocks.ServerMetricRecorder
+}
+
+func (s *SseHubTestSuite) SetupTest() {
+	s.metricRecorder = mocks.NewServerMetricRecorder(s.T())
+}
+
+func (s *SseHubTestSuite) newHub(policy string, blockTimeout time.Duration) *httpserver.SseHub {
+	hub, err := httpserver.NewSseHubWithInterfaces(logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(s.T())), s.metricRecorder, httpserver.SseHubSettings{
+		QueueSize:    1,
+		SlowConsumer: policy,
+		BlockTimeout: blockTimeout,
+	})
+	s.Require().NoError(err)
+
+	return hub
+}
+
+// subscribe starts a subscriber with a writer blocking on every write and waits until it is registered.
+func (s *SseHubTestSuite) subscribe(ctx context.Context, hub *httpserver.SseHub, topic string) (*blockingSseResponseWriter, <-chan error) {
+	responseWriter := newBlockingSseResponseWriter()
+	writer := httpserver.NewSseWriter(ctx, responseWriter)
+	s.T().Cleanup(writer.Close)
+
+	subscribers := hub.Subscribers(topic)
+	result := make(chan error, 1)
+
+	go func() {
+		result <- hub.Subscribe(ctx, writer, topic)
+	}()
+
+	s.Eventually(func() bool {
+		return hub.Subscribers(topic) == subscribers+1
+	}, time.Second, time.Millisecond)
+
+	return responseWriter, result
+}
+
+func (s *SseHubTestSuite) TestFanOut() {
+	s.metricRecorder.EXPECT().TrackSseSubscribed(mock.Anything).Return().Twice()
+	s.metricRecorder.EXPECT().TrackSseUnsubscribed(mock.Anything).Return().Twice()
+
+	ctx, cancel := context.WithCancel(s.T().Context())
+	hub := s.newHub(httpserver.SseSlowConsumerDrop, 0)
+
+	first, firstResult := s.subscribe(ctx, hub, "news")
+	second, secondResult := s.subscribe(ctx, hub, "news")
+
+	s.Equal(2, hub.Publish(ctx, "news", httpserver.SseEvent{Data: "hello"}))
+	s.Equal(0, hub.Publish(ctx, "other", httpserver.SseEvent{Data: "nobody listens"}))
+
+	for _, writer := range []*blockingSseResponseWriter{first, second} {
+		<-writer.writing
+		writer.release <- struct{}{}
+
+		s.Eventually(func() bool {
+			return writer.body() == "data: hello\n\n"
+		}, time.Second, time.Millisecond)
+	}
+
+	cancel()
+
+	s.ErrorIs(<-firstResult, httpserver.ErrClientDisconnected)
+	s.ErrorIs(<-secondResult, httpserver.ErrClientDisconnected)
+	s.Equal(0, hub.Subscribers("news"))
+}
+
+func (s *SseHubTestSuite) TestReplayRecordsEventsOnce() {
+	s.metricRecorder.EXPECT().TrackSseSubscribed(mock.Anything).Return().Twice()
+	s.metricRecorder.EXPECT().TrackSseUnsubscribed(mock.Anything).Return().Twice()
+
+	ctx, cancel := context.WithCancel(s.T().Context())
+	store := httpserver.NewInMemorySseReplayStore(10, time.Minute)
+	hub := s.newHub(httpserver.SseSlowConsumerDrop, 0)
+	hub.EnableReplay(store)
+
+	first, firstResult := s.subscribe(ctx, hub, "news")
+	second, secondResult := s.subscribe(ctx, hub, "news")
+
+	s.Equal(2, hub.Publish(ctx, "news", httpserver.SseEvent{Id: "1", Data: "hello"}))
+
+	for _, writer := range []*blockingSseResponseWriter{first, second} {
+		<-writer.writing
+		writer.release <- struct{}{}
+
+		s.Eventually(func() bool {
+			return writer.body() == "id: 1\ndata: hello\n\n"
+		}, time.Second, time.Millisecond)
+	}
+
+	cancel()
+
+	s.ErrorIs(<-firstResult, httpserver.ErrClientDisconnected)
+	s.ErrorIs(<-secondResult, httpserver.ErrClientDisconnected)
+
+	events, err := store.After(s.T().Context(), "news", "")
+	s.NoError(err)
+	s.Equal([]httpserver.SseEvent{{Id: "1", Data: "hello"}}, events)
+}
+
+// publishingSseReplayStore publishes an event before the buffered events are read.
+type publishingSseReplayStore struct {
+	httpserver.SseReplayStore
+	publish func()
+}
+
+func (s *publishingSseReplayStore) After(ctx context.Context, stream string, lastEventId string) ([]httpserver.SseEvent, error) {
+	s.publish()
+
+	return s.SseReplayStore.After(ctx, stream, lastEventId)
+}
+
+func (s *SseHubTestSuite) TestReplayEventsPublishedWhileSubscribing() {
+	s.metricRecorder.EXPECT().TrackSseSubscribed(mock.Anything).Return().Once()
+	s.metricRecorder.EXPECT().TrackSseUnsubscribed(mock.Anything).Return().Once()
+
+	ctx := s.T().Context()
+	hub := s.newHub(httpserver.SseSlowConsumerDrop, 0)
+	hub.EnableReplay(&publishingSseReplayStore{
+		SseReplayStore: httpserver.NewInMemorySseReplayStore(10, time.Minute),
+		publish: func() {
+			s.Equal(1, hub.Publish(ctx, "news", httpserver.SseEvent{Id: "3", Data: "while subscribing"}))
+		},
+	})
+
+	s.Equal(0, hub.Publish(ctx, "news", httpserver.SseEvent{Id: "1", Data: "seen"}))
+	s.Equal(0, hub.Publish(ctx, "news", httpserver.SseEvent{Id: "2", Data: "missed"}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events", httpserver.BindSseN(func(ctx context.Context, writer *httpserver.SseWriter) error {
+		return hub.Subscribe(ctx, writer, "news")
+	}))
+
+	server := httptest.NewServer(router)
+	s.T().Cleanup(server.Close)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", http.NoBody)
+	s.Require().NoError(err)
+	req.Header.Set(httpserver.HeaderLastEventId, "1")
+
+	res, err := server.Client().Do(req)
+	s.Require().NoError(err)
+	defer func() {
+		s.NoError(res.Body.Close())
+	}()
+
+	reader := bufio.NewReader(res.Body)
+	readEvent := func() string {
+		var event strings.Builder
+
+		for {
+			line, err := reader.ReadString('\n')
+			s.Require().NoError(err)
+
+			if line == "\n" {
+				return event.String()
+			}
+
+			event.WriteString(line)
+		}
+	}
+
+	s.Equal("id: 2\ndata: missed\n", readEvent())
+	s.Equal("id: 3\ndata: while subscribing\n", readEvent())
+
+	s.Equal(1, hub.Publish(ctx, "news", httpserver.SseEvent{Id: "4", Data: "live"}))
+	s.Equal("id: 4\ndata: live\n", readEvent(), "events published while subscribing are sent once")
+}
+
+func (s *SseHubTestSuite) TestSlowConsumerDrop() {
+	s.metricRecorder.EXPECT().TrackSseSubscribed(mock.Anything).Return().Once()
+	s.metricRecorder.EXPECT().TrackSseUnsubscribed(mock.Anything).Return().Once()
+
+	ctx, cancel := context.WithCancel(s.T().Context())
+	defer cancel()
+
+	hub := s.newHub(httpserver.SseSlowConsumerDrop, 0)
+	writer, result := s.subscribe(ctx, hub, "news")
+
+	s.Equal(1, hub.Publish(ctx, "news", httpserver.SseEvent{Data: "1"}))
+	<-writer.writing
+	s.Equal(1, hub.Publish(ctx, "news", httpserver.SseEvent{Data: "2"}))
+	s.Equal(0, hub.Publish(ctx, "news", httpserver.SseEvent{Data: "3"}), "the queue is full")
+
+	writer.release <- struct{}{}
+	<-writer.writing
+	writer.release <- struct{}{}
+
+	s.Eventually(func() bool {
+		return writer.body() == "data: 1\n\ndata: 2\n\n"
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	s.ErrorIs(<-result, httpserver.ErrClientDisconnected)
+}
+
+func (s *SseHubTestSuite) TestSlowConsumerDisconnect() {
+	s.metricRecorder.EXPECT().TrackSseSubscribed(mock.Anything).Return().Once()
+	s.metricRecorder.EXPECT().TrackSseUnsubscribed(mock.Anything).Return().Once()
+
+	hub := s.newHub(httpserver.SseSlowConsumerDisconnect, 0)
+	writer, result := s.subscribe(s.T().Context(), hub, "news")
+
+	s.Equal(1, hub.Publish(s.T().Context(), "news", httpserver.SseEvent{Data: "1"}))
+	<-writer.writing
+	s.Equal(1, hub.Publish(s.T().Context(), "news", httpserver.SseEvent{Data: "2"}))
+	s.Equal(0, hub.Publish(s.T().Context(), "news", httpserver.SseEvent{Data: "3"}))
+
+	close(writer.release)
+
+	s.ErrorIs(<-result, httpserver.ErrSseSlowConsumer)
+	s.Equal(0, hub.Subscribers("news"))
+}
+
+func (s *SseHubTestSuite) TestSlowConsumerBlock() {
+	s.metricRecorder.EXPECT().TrackSseSubscribed(mock.Anything).Return().Once()
+	s.metricRecorder.EXPECT().TrackSseUnsubscribed(mock.Anything).Return().Once()
+
+	ctx, cancel := context.WithCancel(s.T().Context())
+	defer cancel()
+
+	hub := s.newHub(httpserver.SseSlowConsumerBlock, time.Minute)
+	writer, result := s.subscribe(ctx, hub, "news")
+
+	s.Equal(1, hub.Publish(ctx, "news", httpserver.SseEvent{Data: "1"}))
+	<-writer.writing
+	s.Equal(1, hub.Publish(ctx, "news", httpserver.SseEvent{Data: "2"}))
+
+	go func() {
+		writer.release <- struct{}{}
+		<-writer.writing
+		writer.release <- struct{}{}
+		<-writer.writing
+		writer.release <- struct{}{}
+	}()
+
+	s.Equal(1, hub.Publish(ctx, "news", httpserver.SseEvent{Data: "3"}), "the publisher waits for the subscriber")
+
+	s.Eventually(func() bool {
+		return writer.body() == "data: 1\n\ndata: 2\n\ndata: 3\n\n"
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	s.ErrorIs(<-result, httpserver.ErrClientDisconnected)
+}
+
+func (s *SseHubTestSuite) TestBlockTimeout() {
+	s.metricRecorder.EXPECT().TrackSseSubscribed(mock.Anything).Return().Once()
+	s.metricRecorder.EXPECT().TrackSseUnsubscribed(mock.Anything).Return().Once()
+
+	hub := s.newHub(httpserver.SseSlowConsumerBlock, 10*time.Millisecond)
+	writer, result := s.subscribe(s.T().Context(), hub, "news")
+
+	s.Equal(1, hub.Publish(s.T().Context(), "news", httpserver.SseEvent{Data: "1"}))
+	<-writer.writing
+	s.Equal(1, hub.Publish(s.T().Context(), "news", httpserver.SseEvent{Data: "2"}))
+	s.Equal(0, hub.Publish(s.T().Context(), "news", httpserver.SseEvent{Data: "3"}), "the subscriber is disconnected after the timeout")
+
+	close(writer.release)
+
+	s.ErrorIs(<-result, httpserver.ErrSseSlowConsumer)
+}
+
+func (s *SseHubTestSuite) TestInvalidPolicy() {
+	_, err := httpserver.NewSseHubWithInterfaces(logMocks.NewLoggerMock(logMocks.WithMockAll, logMocks.WithTestingT(s.T())), s.metricRecorder, httpserver.SseHubSettings{
+		QueueSize:    1,
+		SlowConsumer: "ignore",
+	})
+	s.EqualError(err, `unknown slow consumer policy "ignore"`)
+}
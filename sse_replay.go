@@ -0,0 +1,120 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/justtrackio/gosoline/pkg/cache"
+	"github.com/justtrackio/gosoline/pkg/clock"
+)
+
+const (
+	// DefaultSseReplaySize is the number of events kept per stream by the in-memory replay store if no size is given.
+	DefaultSseReplaySize = 100
+	// DefaultSseReplayTtl is the time an idle stream is kept by the in-memory replay store if no ttl is given.
+	DefaultSseReplayTtl = 10 * time.Minute
+
+	sseReplayStoreMaxSize   = 10000
+	sseReplayStorePruneSize = 100
+)
+
+//go:generate go run github.com/vektra/mockery/v2 --name SseReplayStore --with-expecter
+type (
+	// SseReplayStore buffers the recent events of SSE streams, so a reconnecting client can receive the events it
+	// missed. A stream is any name shared by the writers sending the same events, e.g. a topic or a user id. Events
+	// are appended once by their publisher, not by every writer sending them.
+	SseReplayStore interface {
+		// Append records the event for the stream and returns it as it has to be sent. Events without id are
+		// assigned an id unique within the store.
+		Append(ctx context.Context, stream string, event SseEvent) (SseEvent, error)
+		// After returns the buffered events of the stream which were appended after the event with the given id.
+		// If the id is no longer (or not at all) buffered, all buffered events are returned.
+		After(ctx context.Context, stream string, lastEventId string) ([]SseEvent, error)
+	}
+
+	// SseResumeInput can be embedded into the input of a BindSse handler to receive the id of the last event a
+	// reconnecting client has seen.
+	SseResumeInput struct {
+		LastEventId string `header:"Last-Event-ID"`
+	}
+
+	inMemorySseReplayStore struct {
+		size    int
+		prefix  string
+		seq     atomic.Uint64
+		streams cache.Cache[sseReplayBuffer]
+	}
+
+	sseReplayBuffer struct {
+		events []SseEvent
+	}
+)
+
+// NewInMemorySseReplayStore creates a replay store keeping the last size events of each stream in memory. A stream
+// is dropped if no event was appended for the given ttl. Zero values use DefaultSseReplaySize and DefaultSseReplayTtl.
+func NewInMemorySseReplayStore(size int, ttl time.Duration) SseReplayStore {
+	return NewInMemorySseReplayStoreWithInterfaces(clock.Provider, size, ttl)
+}
+
+// NewInMemorySseReplayStoreWithInterfaces creates an in-memory replay store using the provided clock.
+func NewInMemorySseReplayStoreWithInterfaces(providedClock clock.Clock, size int, ttl time.Duration) SseReplayStore {
+	if size <= 0 {
+		size = DefaultSseReplaySize
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultSseReplayTtl
+	}
+
+	return &inMemorySseReplayStore{
+		size: size,
+		// ids of a previous instance must not match the events of this one
+		prefix:  strconv.FormatInt(providedClock.Now().UnixNano(), 36),
+		streams: cache.New[sseReplayBuffer](sseReplayStoreMaxSize, sseReplayStorePruneSize, ttl),
+	}
+}
+
+func (s *inMemorySseReplayStore) Append(_ context.Context, stream string, event SseEvent) (SseEvent, error) {
+	if event.Id == "" {
+		event.Id = fmt.Sprintf("%s-%d", s.prefix, s.seq.Add(1))
+	}
+
+	s.streams.Mutate(stream, func(buffer *sseReplayBuffer) sseReplayBuffer {
+		var events []SseEvent
+
+		if buffer != nil {
+			events = buffer.events
+		}
+
+		// readers only see the events up to the length of their slice, so appending in place is safe
+		events = append(events, event)
+
+		if len(events) > s.size {
+			events = events[len(events)-s.size:]
+		}
+
+		return sseReplayBuffer{
+			events: events,
+		}
+	})
+
+	return event, nil
+}
+
+func (s *inMemorySseReplayStore) After(_ context.Context, stream string, lastEventId string) ([]SseEvent, error) {
+	buffer, ok := s.streams.Get(stream)
+	if !ok {
+		return nil, nil
+	}
+
+	for i := len(buffer.events) - 1; i >= 0; i-- {
+		if buffer.events[i].Id == lastEventId {
+			return buffer.events[i+1:], nil
+		}
+	}
+
+	return buffer.events, nil
+}
@@ -0,0 +1,49 @@
+package httpserver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gosoline-project/httpserver"
+	"github.com/justtrackio/gosoline/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemorySseReplayStore(t *testing.T) {
+	store := httpserver.NewInMemorySseReplayStoreWithInterfaces(clock.NewFakeClock(), 3, time.Minute)
+
+	ids := make([]string, 0, 4)
+	for _, data := range []string{"a", "b", "c", "d"} {
+		event, err := store.Append(t.Context(), "news", httpserver.SseEvent{Data: data})
+		require.NoError(t, err)
+		require.NotEmpty(t, event.Id)
+		assert.Equal(t, data, event.Data)
+
+		ids = append(ids, event.Id)
+	}
+
+	assert.Len(t, ids, 4)
+	assert.NotEqual(t, ids[0], ids[1], "ids must be unique")
+
+	events, err := store.After(t.Context(), "news", ids[2])
+	require.NoError(t, err)
+	assert.Equal(t, []httpserver.SseEvent{{Id: ids[3], Data: "d"}}, events)
+
+	events, err = store.After(t.Context(), "news", ids[3])
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	events, err = store.After(t.Context(), "news", ids[0])
+	require.NoError(t, err)
+	assert.Len(t, events, 3, "only the last events are kept, all of them are returned for an evicted id")
+	assert.Equal(t, ids[1], events[0].Id)
+
+	events, err = store.After(t.Context(), "other", ids[0])
+	require.NoError(t, err)
+	assert.Empty(t, events, "streams are kept separately")
+
+	event, err := store.Append(t.Context(), "other", httpserver.SseEvent{Id: "custom", Data: "e"})
+	require.NoError(t, err)
+	assert.Equal(t, "custom", event.Id)
+}
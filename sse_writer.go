@@ -9,8 +9,13 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/justtrackio/gosoline/pkg/encoding/json"
 )
 
+// SseEventError is the event type of the error event sent by the BindSse family if a handler fails.
+const SseEventError = "error"
+
 // ErrClientDisconnected is returned when the client has disconnected.
 var ErrClientDisconnected = errors.New("sse: client disconnected")
 
@@ -32,6 +37,26 @@ type (
 		Retry int
 	}
 
+	// SseJsonEvent is a Server-Sent Event with a payload of type T which is sent JSON encoded.
+	SseJsonEvent[T any] struct {
+		// Event specifies the event type (mapped to "event:" field).
+		Event string
+		// Data is the event payload, it is encoded as JSON and mapped to the "data:" field.
+		Data T
+		// Id specifies the event ID (mapped to "id:" field).
+		Id string
+		// Retry specifies the reconnection time in milliseconds (mapped to "retry:" field).
+		Retry int
+	}
+
+	// SseError is the payload of the error event sent by the BindSse family if a handler fails. Details of internal
+	// server errors are hidden unless the errors privacy of the server is public.
+	SseError struct {
+		Status     int              `json:"status"`
+		Err        string           `json:"err"`
+		Violations []FieldViolation `json:"violations,omitempty"`
+	}
+
 	// SseResponseWriter is the interface required for SSE streaming.
 	SseResponseWriter interface {
 		http.ResponseWriter
@@ -40,52 +65,54 @@ type (
 
 	// SseWriter provides methods to send Server-Sent Events to a client.
 	SseWriter struct {
-		ctx    context.Context
-		cancel context.CancelFunc
-		writer SseResponseWriter
-		mu     sync.Mutex
+		ctx               context.Context
+		cancel            context.CancelFunc
+		writer            SseResponseWriter
+		mu                sync.Mutex
+		heartbeatInterval time.Duration
+		heartbeatDone     chan struct{}
+		lastEventId       string
 	}
 )
 
 // DefaultSseHeartbeatInterval is the interval used for SSE heartbeat comments.
 const DefaultSseHeartbeatInterval = 5 * time.Second
 
-// NewSseWriter creates a new SSE writer that sends events to the provided response writer.
-// It sets the necessary SSE headers (Content-Type, Cache-Control, Connection).
+// NewSseWriter creates a new SSE writer that sends events to the provided response writer
+// and sends heartbeats every DefaultSseHeartbeatInterval. It sets the necessary SSE headers (Content-Type, Cache-Control, Connection).
 //
 // The context is used to detect client disconnects. When the context is cancelled,
 // subsequent Send/SendEvent calls will return ErrClientDisconnected.
 //
 // Note: This function does NOT set CORS headers. Configure CORS via middleware if needed.
 //
-// IMPORTANT: SSE responses should NOT be gzip-compressed, as compression buffers data
-// and defeats real-time streaming. The gin-contrib/gzip middleware automatically skips
-// compression when the client sends "Accept: text/event-stream" (which browser EventSource
-// does by default). For non-browser clients, ensure they send this Accept header, or
-// configure compression exclusions for your SSE endpoints.
+// The compression middleware of the server never compresses responses with the text/event-stream
+// content type, regardless of the headers sent by the client.
 func NewSseWriter(ctx context.Context, writer SseResponseWriter) *SseWriter {
+	return NewSseWriterWithSettings(ctx, writer, SseSettings{
+		HeartbeatInterval: DefaultSseHeartbeatInterval,
+	})
+}
+
+// NewSseWriterWithSettings creates a new SSE writer like NewSseWriter, using the heartbeat
+// interval of the settings. A heartbeat interval of 0 disables heartbeats.
+func NewSseWriterWithSettings(ctx context.Context, writer SseResponseWriter, settings SseSettings) *SseWriter {
 	writer.Header().Set(HeaderContentType, ContentTypeEventStream)
 	writer.Header().Set(HeaderCacheControl, HeaderValueNoCache)
 	writer.Header().Set(HeaderConnection, HeaderValueKeepAlive)
 
-	// Warn if gzip is active (should not happen if client sent Accept: text/event-stream)
-	// We delete the Content-Encoding header as a safety measure, though ideally the gzip
-	// middleware should not have activated in the first place.
-	if writer.Header().Get(HeaderContentEncoding) == HeaderValueGzip {
-		writer.Header().Del(HeaderContentEncoding)
-		writer.Header().Del(HeaderVary)
-		// Note: The underlying gzip writer may still be active. This is a best-effort fix.
-		// Properly exclude SSE paths from compression via middleware configuration.
-	}
-
 	writerCtx, cancel := context.WithCancel(ctx)
 	sseWriter := &SseWriter{
-		ctx:    writerCtx,
-		cancel: cancel,
-		writer: writer,
+		ctx:               writerCtx,
+		cancel:            cancel,
+		writer:            writer,
+		heartbeatInterval: settings.HeartbeatInterval,
 	}
 
-	go sseWriter.heartbeatLoop()
+	if sseWriter.heartbeatInterval > 0 {
+		sseWriter.heartbeatDone = make(chan struct{})
+		go sseWriter.heartbeatLoop()
+	}
 
 	return sseWriter
 }
@@ -96,6 +123,62 @@ func (w *SseWriter) Send(data string) error {
 	return w.SendEvent(SseEvent{Data: data})
 }
 
+// SendJson writes a data-only SSE event with the JSON encoded data.
+func SendJson[T any](writer *SseWriter, data T) error {
+	return SendJsonEvent(writer, SseJsonEvent[T]{Data: data})
+}
+
+// SendJsonEvent writes a full SSE event with the JSON encoded data of the event.
+func SendJsonEvent[T any](writer *SseWriter, event SseJsonEvent[T]) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("can not encode sse event data: %w", err)
+	}
+
+	return writer.SendEvent(SseEvent{
+		Event: event.Event,
+		Data:  string(data),
+		Id:    event.Id,
+		Retry: event.Retry,
+	})
+}
+
+// LastEventId returns the value of the Last-Event-ID header a reconnecting client has sent. It is empty for
+// writers not created by the BindSse family or if the client connects for the first time.
+func (w *SseWriter) LastEventId() string {
+	return w.lastEventId
+}
+
+// Replay sends the events of the stream the client has missed since LastEventId. The events have to be appended to
+// the store by their publisher before they are sent to the writers. Writers subscribed to an SseHub with replay
+// enabled don't need to call it, the hub replays the missed events itself.
+//
+// Clients connecting for the first time don't receive buffered events.
+func (w *SseWriter) Replay(stream string, store SseReplayStore) error {
+	if w.lastEventId == "" {
+		return nil
+	}
+
+	_, err := w.replay(stream, store)
+
+	return err
+}
+
+func (w *SseWriter) replay(stream string, store SseReplayStore) ([]SseEvent, error) {
+	events, err := store.After(w.ctx, stream, w.lastEventId)
+	if err != nil {
+		return nil, fmt.Errorf("can not read events of sse stream %s after %s: %w", stream, w.lastEventId, err)
+	}
+
+	for _, event := range events {
+		if err = w.SendEvent(event); err != nil {
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
+
 // SendEvent writes a full SSE event with optional fields.
 //
 // The event is formatted according to the SSE specification:
@@ -136,16 +219,22 @@ func (w *SseWriter) SendEvent(event SseEvent) error {
 	return w.write(buf.Bytes())
 }
 
-// Close stops any background heartbeats and releases resources.
+// Close stops any background heartbeats and releases resources. It waits for a heartbeat being written, so the
+// response writer isn't used anymore once Close returns.
 func (w *SseWriter) Close() {
 	if w.cancel != nil {
 		w.cancel()
 	}
+
+	if w.heartbeatDone != nil {
+		<-w.heartbeatDone
+	}
 }
 
 func (w *SseWriter) heartbeatLoop() {
-	ticker := time.NewTicker(DefaultSseHeartbeatInterval)
+	ticker := time.NewTicker(w.heartbeatInterval)
 	defer ticker.Stop()
+	defer close(w.heartbeatDone)
 
 	for {
 		select {
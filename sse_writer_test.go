@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gosoline-project/httpserver"
+	"github.com/gosoline-project/httpserver/mocks"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -146,20 +147,6 @@ func (s *SseWriterTestSuite) TestNoCorsHeaders() {
 	s.Empty(s.rec.Header().Get(httpserver.HeaderAccessControlExposeHeaders))
 }
 
-func (s *SseWriterTestSuite) TestGzipDetection() {
-	// Create a new recorder with gzip headers already set
-	rec := httptest.NewRecorder()
-	rec.Header().Set(httpserver.HeaderContentEncoding, httpserver.HeaderValueGzip)
-	rec.Header().Set(httpserver.HeaderVary, httpserver.HeaderAcceptEncoding)
-
-	writer := httpserver.NewSseWriter(s.ctx, rec)
-	defer writer.Close()
-
-	// Verify gzip headers are removed
-	s.Empty(rec.Header().Get(httpserver.HeaderContentEncoding))
-	s.Empty(rec.Header().Get(httpserver.HeaderVary))
-}
-
 func (s *SseWriterTestSuite) TestBrowserEventSourceCompatibility() {
 	// This test verifies the SSE format is compatible with browser EventSource API
 
@@ -207,9 +194,50 @@ func (s *SseWriterTestSuite) TestBrowserEventSourceCompatibility() {
 func (s *SseWriterTestSuite) TestHeartbeatsEnabledByDefault() {
 	rec := httptest.NewRecorder()
 	writer := httpserver.NewSseWriter(s.ctx, rec)
-	defer writer.Close()
 
 	time.Sleep(6 * time.Second)
+	writer.Close()
 
 	s.Contains(rec.Body.String(), ": heartbeat\n\n")
 }
+
+func (s *SseWriterTestSuite) TestHeartbeatsDisabled() {
+	rec := httptest.NewRecorder()
+	writer := httpserver.NewSseWriterWithSettings(s.ctx, rec, httpserver.SseSettings{})
+	defer writer.Close()
+
+	s.NoError(writer.Send("hello"))
+	time.Sleep(20 * time.Millisecond)
+
+	s.Equal("data: hello\n\n", rec.Body.String())
+}
+
+func (s *SseWriterTestSuite) TestSendJson() {
+	type update struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	s.NoError(httpserver.SendJson(s.writer, update{Name: "a", Count: 1}))
+	s.NoError(httpserver.SendJsonEvent(s.writer, httpserver.SseJsonEvent[update]{
+		Event: "update",
+		Id:    "2",
+		Data:  update{Name: "line\nbreak", Count: 2},
+	}))
+
+	s.Equal("data: {\"name\":\"a\",\"count\":1}\n\nevent: update\nid: 2\ndata: {\"name\":\"line\\nbreak\",\"count\":2}\n\n", s.rec.Body.String())
+}
+
+func (s *SseWriterTestSuite) TestSendJson_EncodeError() {
+	err := httpserver.SendJson(s.writer, func() {})
+	s.ErrorContains(err, "can not encode sse event data")
+	s.Empty(s.rec.Body.String())
+}
+
+func (s *SseWriterTestSuite) TestReplay_FirstConnect() {
+	store := mocks.NewSseReplayStore(s.T())
+
+	s.NoError(s.writer.Replay("news", store))
+	s.NoError(s.writer.Send("hello"))
+	s.Equal("data: hello\n\n", s.rec.Body.String(), "sent events are not recorded by the writer")
+}
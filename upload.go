@@ -0,0 +1,551 @@
+package httpserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	// DefaultUploadMaxBytes is the size limit of a single file and of all files of a request if the upload settings
+	// of the server aren't available.
+	DefaultUploadMaxBytes = 10 << 20
+	// DefaultUploadMaxFiles is the limit of files of a request if the upload settings of the server aren't available.
+	DefaultUploadMaxFiles = 32
+	// DefaultUploadMaxValueBytes is the size limit of a form value if the upload settings of the server aren't available.
+	DefaultUploadMaxValueBytes = 1 << 20
+	// uploadSniffBytes is the number of bytes http.DetectContentType considers.
+	uploadSniffBytes = 512
+)
+
+var (
+	// ErrUploadTooLarge is returned with status 413 if a file, all files or a form value exceed their size limit.
+	ErrUploadTooLarge = errors.New("upload too large")
+	// ErrUploadTooManyFiles is returned with status 413 if a request contains more files than allowed.
+	ErrUploadTooManyFiles = errors.New("too many files uploaded")
+	// ErrUploadContentType is returned with status 415 if the sniffed content type of a file isn't allowed.
+	ErrUploadContentType = errors.New("content type of upload not allowed")
+)
+
+type (
+	// UploadFile is a file of a multipart/form-data request. Input fields of type *UploadFile or []*UploadFile are
+	// bound to the files of the form field named by their form tag. These files are read into memory within the
+	// upload limits of the server, which can be replaced per field with the upload tag:
+	//
+	//	Avatar *httpserver.UploadFile `form:"avatar" upload:"max_bytes=1048576,content_types=image/png image/jpeg"`
+	UploadFile struct {
+		fieldName   string
+		fileName    string
+		contentType string
+		header      textproto.MIMEHeader
+		reader      io.Reader
+		size        int64
+	}
+
+	// Uploads streams the files of a multipart/form-data request without buffering them. An input field of type
+	// *Uploads is bound to the files of the request, which the handler reads with Next. The form values preceding
+	// the first file are bound to the other fields of the input, form values following it are skipped.
+	Uploads struct {
+		ctx            context.Context
+		metricRecorder ServerMetricRecorder
+		settings       UploadSettings
+		reader         *multipart.Reader
+		pending        *multipart.Part
+		current        *uploadFileReader
+		files          int
+		read           atomic.Int64
+		total          int64
+	}
+
+	uploadFileReader struct {
+		uploads  *Uploads
+		file     *UploadFile
+		reader   io.Reader
+		limits   uploadLimits
+		tracked  bool
+		rejected bool
+	}
+
+	uploadLimits struct {
+		maxBytes     int64
+		contentTypes []string
+	}
+
+	uploadField struct {
+		index    []int
+		multiple bool
+		limits   uploadLimits
+	}
+
+	uploadFields struct {
+		files  map[string]uploadField
+		stream []int
+	}
+
+	uploadBinding struct {
+		ctx            context.Context
+		settings       UploadSettings
+		metricRecorder ServerMetricRecorder
+		validator      binding.StructValidator
+	}
+)
+
+var (
+	uploadFileType    = reflect.TypeFor[*UploadFile]()
+	uploadFileSetType = reflect.TypeFor[[]*UploadFile]()
+	uploadsType       = reflect.TypeFor[*Uploads]()
+)
+
+// FieldName returns the name of the form field of the file.
+func (f *UploadFile) FieldName() string {
+	return f.fieldName
+}
+
+// FileName returns the name of the file sent by the client without its directories.
+func (f *UploadFile) FileName() string {
+	return f.fileName
+}
+
+// ContentType returns the content type sniffed from the content of the file. The content type sent by the client
+// is part of Header.
+func (f *UploadFile) ContentType() string {
+	return f.contentType
+}
+
+// Header returns the MIME header of the part of the file.
+func (f *UploadFile) Header() textproto.MIMEHeader {
+	return f.header
+}
+
+// Size returns the number of bytes read from the file so far. It is the size of the whole file once it is read.
+func (f *UploadFile) Size() int64 {
+	return f.size
+}
+
+// Read reads the content of the file. It fails with ErrUploadTooLarge once a size limit is exceeded.
+func (f *UploadFile) Read(p []byte) (int, error) {
+	return f.reader.Read(p)
+}
+
+// Next returns the next file of the request. The rest of the current file is skipped. It returns io.EOF after the
+// last file.
+func (u *Uploads) Next() (*UploadFile, error) {
+	for {
+		part, err := u.nextPart()
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FileName() == "" {
+			continue
+		}
+
+		return u.open(part, u.getLimits())
+	}
+}
+
+// Progress returns the number of bytes of the files read so far and the content length of the request, which includes
+// the form values and the multipart encoding. The content length is -1 if the client didn't send it.
+func (u *Uploads) Progress() (read int64, total int64) {
+	return u.read.Load(), u.total
+}
+
+func newUploads(ctx context.Context, metricRecorder ServerMetricRecorder, settings UploadSettings, reader *multipart.Reader, total int64) *Uploads {
+	return &Uploads{
+		ctx:            ctx,
+		metricRecorder: metricRecorder,
+		settings:       settings,
+		reader:         reader,
+		total:          total,
+	}
+}
+
+func (u *Uploads) getLimits() uploadLimits {
+	return uploadLimits{
+		maxBytes:     u.settings.MaxFileBytes,
+		contentTypes: u.settings.ContentTypes,
+	}
+}
+
+func (u *Uploads) nextPart() (*multipart.Part, error) {
+	if part := u.pending; part != nil {
+		u.pending = nil
+
+		return part, nil
+	}
+
+	if u.current != nil {
+		u.current.track()
+		u.current = nil
+	}
+
+	part, err := u.reader.NextPart()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, newBindError(err)
+	}
+
+	return part, err
+}
+
+func (u *Uploads) readValue(part *multipart.Part) (string, error) {
+	value, err := io.ReadAll(io.LimitReader(part, u.settings.MaxValueBytes+1))
+	if err != nil {
+		return "", newBindError(err)
+	}
+
+	if int64(len(value)) > u.settings.MaxValueBytes {
+		return "", newBindError(fmt.Errorf("%w: form value %q exceeds %d bytes", ErrUploadTooLarge, part.FormName(), u.settings.MaxValueBytes))
+	}
+
+	return string(value), nil
+}
+
+// open checks the number of files and the content type of the file of the part and returns the file, which checks
+// the size limits while it is read.
+func (u *Uploads) open(part *multipart.Part, limits uploadLimits) (*UploadFile, error) {
+	u.files++
+
+	if u.settings.MaxFiles > 0 && u.files > u.settings.MaxFiles {
+		u.trackRejected()
+
+		return nil, newBindError(fmt.Errorf("%w: the limit is %d files", ErrUploadTooManyFiles, u.settings.MaxFiles))
+	}
+
+	buffered := bufio.NewReaderSize(part, uploadSniffBytes)
+
+	head, err := buffered.Peek(uploadSniffBytes)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, newBindError(err)
+	}
+
+	file := &UploadFile{
+		fieldName:   part.FormName(),
+		fileName:    part.FileName(),
+		contentType: http.DetectContentType(head),
+		header:      part.Header,
+	}
+
+	if !isUploadContentTypeAllowed(file.contentType, limits.contentTypes) {
+		u.trackRejected()
+
+		return nil, newBindError(fmt.Errorf("%w: file %q is %s", ErrUploadContentType, file.fileName, file.contentType))
+	}
+
+	u.current = &uploadFileReader{
+		uploads: u,
+		file:    file,
+		reader:  buffered,
+		limits:  limits,
+	}
+	file.reader = u.current
+
+	return file, nil
+}
+
+func (u *Uploads) trackRejected() {
+	if u.metricRecorder != nil {
+		u.metricRecorder.TrackUploadRejected(u.ctx)
+	}
+}
+
+func (r *uploadFileReader) Read(p []byte) (int, error) {
+	if r.rejected {
+		return 0, r.tooLarge()
+	}
+
+	n, err := r.reader.Read(p)
+	r.file.size += int64(n)
+	read := r.uploads.read.Add(int64(n))
+
+	if r.limits.maxBytes > 0 && r.file.size > r.limits.maxBytes || r.uploads.settings.MaxTotalBytes > 0 && read > r.uploads.settings.MaxTotalBytes {
+		r.rejected = true
+		r.uploads.trackRejected()
+
+		return n, r.tooLarge()
+	}
+
+	if errors.Is(err, io.EOF) {
+		r.track()
+
+		return n, err
+	}
+
+	if err != nil {
+		return n, newBindError(err)
+	}
+
+	return n, nil
+}
+
+func (r *uploadFileReader) tooLarge() error {
+	if r.limits.maxBytes > 0 && r.file.size > r.limits.maxBytes {
+		return newBindError(fmt.Errorf("%w: file %q exceeds %d bytes", ErrUploadTooLarge, r.file.fileName, r.limits.maxBytes))
+	}
+
+	return newBindError(fmt.Errorf("%w: the files exceed %d bytes", ErrUploadTooLarge, r.uploads.settings.MaxTotalBytes))
+}
+
+// track records the bytes read from the file once it is read completely or skipped.
+func (r *uploadFileReader) track() {
+	if r.tracked || r.rejected {
+		return
+	}
+
+	r.tracked = true
+
+	if r.uploads.metricRecorder != nil {
+		r.uploads.metricRecorder.TrackUploadedBytes(r.uploads.ctx, r.file.size)
+	}
+}
+
+func isUploadContentTypeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	for _, entry := range allowed {
+		entry = strings.ToLower(entry)
+
+		if prefix, ok := strings.CutSuffix(entry, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") || entry == mediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newUploadBinding(ginCtx *gin.Context) *uploadBinding {
+	return &uploadBinding{
+		ctx:            ginCtx.Request.Context(),
+		settings:       getUploadSettings(ginCtx),
+		metricRecorder: getUploadMetricRecorder(ginCtx),
+		validator:      getScopedValidator(ginCtx),
+	}
+}
+
+func (b *uploadBinding) Name() string {
+	return binding.FormMultipart.Name()
+}
+
+// Bind reads the form values and files of a multipart/form-data request. Files of form fields without input field
+// are skipped. The form values are made available as the form of the request, so other form binders don't read the
+// body again.
+func (b *uploadBinding) Bind(request *http.Request, obj any) error {
+	value := reflect.ValueOf(obj)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("can not bind multipart form to %T", obj)
+	}
+
+	fields, err := getUploadFields(value.Elem().Type(), b.settings)
+	if err != nil {
+		return err
+	}
+
+	_, params, err := mime.ParseMediaType(request.Header.Get(HeaderContentType))
+	if err != nil {
+		return err
+	}
+
+	if params["boundary"] == "" {
+		return http.ErrMissingBoundary
+	}
+
+	uploads := newUploads(b.ctx, b.metricRecorder, b.settings, multipart.NewReader(request.Body, params["boundary"]), request.ContentLength)
+	values := make(map[string][]string)
+	files := make(map[string][]*UploadFile)
+
+	for {
+		part, err := uploads.nextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if part.FileName() == "" {
+			formValue, err := uploads.readValue(part)
+			if err != nil {
+				return err
+			}
+
+			values[part.FormName()] = append(values[part.FormName()], formValue)
+
+			continue
+		}
+
+		if fields.stream != nil {
+			uploads.pending = part
+
+			break
+		}
+
+		field, ok := fields.files[part.FormName()]
+		if !ok {
+			if _, err = io.Copy(io.Discard, part); err != nil {
+				return newBindError(err)
+			}
+
+			continue
+		}
+
+		file, err := uploads.open(part, field.limits)
+		if err != nil {
+			return err
+		}
+
+		content, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+
+		file.reader = bytes.NewReader(content)
+		files[file.fieldName] = append(files[file.fieldName], file)
+	}
+
+	request.MultipartForm = &multipart.Form{Value: values, File: map[string][]*multipart.FileHeader{}}
+	request.PostForm = values
+	request.Form = request.URL.Query()
+
+	for key, formValues := range values {
+		request.Form[key] = append(request.Form[key], formValues...)
+	}
+
+	if err = binding.MapFormWithTag(obj, values, "form"); err != nil {
+		return err
+	}
+
+	for name, field := range fields.files {
+		if len(files[name]) == 0 {
+			continue
+		}
+
+		if field.multiple {
+			value.Elem().FieldByIndex(field.index).Set(reflect.ValueOf(files[name]))
+		} else {
+			value.Elem().FieldByIndex(field.index).Set(reflect.ValueOf(files[name][0]))
+		}
+	}
+
+	if fields.stream != nil {
+		value.Elem().FieldByIndex(fields.stream).Set(reflect.ValueOf(uploads))
+	}
+
+	if b.validator != nil {
+		return b.validator.ValidateStruct(obj)
+	}
+
+	if binding.Validator == nil {
+		return nil
+	}
+
+	return binding.Validator.ValidateStruct(obj)
+}
+
+func isUploadType(typ reflect.Type) bool {
+	return typ == uploadFileType || typ == uploadFileSetType || typ == uploadsType
+}
+
+func getUploadFields(typ reflect.Type, settings UploadSettings) (*uploadFields, error) {
+	fields := &uploadFields{
+		files: make(map[string]uploadField),
+	}
+
+	if err := collectUploadFields(fields, typ, nil, settings); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func collectUploadFields(fields *uploadFields, typ reflect.Type, index []int, settings UploadSettings) error {
+	for i := range typ.NumField() {
+		structField := typ.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct {
+			if err := collectUploadFields(fields, structField.Type, fieldIndex, settings); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !structField.IsExported() {
+			continue
+		}
+
+		switch structField.Type {
+		case uploadsType:
+			fields.stream = fieldIndex
+		case uploadFileType, uploadFileSetType:
+			name, _, _ := strings.Cut(structField.Tag.Get("form"), ",")
+			if name == "" {
+				name = structField.Name
+			}
+
+			limits, err := parseUploadLimits(structField.Tag.Get("upload"), settings)
+			if err != nil {
+				return fmt.Errorf("invalid upload tag of field %s: %w", structField.Name, err)
+			}
+
+			fields.files[name] = uploadField{
+				index:    fieldIndex,
+				multiple: structField.Type == uploadFileSetType,
+				limits:   limits,
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseUploadLimits returns the limits of an upload tag. Limits missing in the tag are taken from the settings.
+func parseUploadLimits(tag string, settings UploadSettings) (uploadLimits, error) {
+	limits := uploadLimits{
+		maxBytes:     settings.MaxFileBytes,
+		contentTypes: settings.ContentTypes,
+	}
+
+	if tag == "" {
+		return limits, nil
+	}
+
+	for option := range strings.SplitSeq(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+
+		switch key {
+		case "max_bytes":
+			maxBytes, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || maxBytes < 0 {
+				return limits, fmt.Errorf("max_bytes has to be a non-negative number of bytes: %q", value)
+			}
+
+			limits.maxBytes = maxBytes
+		case "content_types":
+			limits.contentTypes = strings.Fields(value)
+		default:
+			return limits, fmt.Errorf("unknown option %q", key)
+		}
+	}
+
+	return limits, nil
+}
@@ -0,0 +1,279 @@
+package httpserver_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gosoline-project/httpserver"
+	"github.com/gosoline-project/httpserver/mocks"
+	"github.com/justtrackio/gosoline/pkg/test/matcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const uploadTestPng = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+type uploadTestPart struct {
+	name     string
+	fileName string
+	content  string
+}
+
+type uploadTestInput struct {
+	Name   string                   `form:"name"`
+	Avatar *httpserver.UploadFile   `form:"avatar" binding:"required" upload:"max_bytes=16,content_types=image/png"`
+	Docs   []*httpserver.UploadFile `form:"docs"`
+}
+
+type uploadTestStreamInput struct {
+	Name    string `form:"name"`
+	Uploads *httpserver.Uploads
+}
+
+func newUploadTestSettings() httpserver.UploadSettings {
+	return httpserver.UploadSettings{
+		MaxFileBytes:  1024,
+		MaxTotalBytes: 2048,
+		MaxFiles:      3,
+		MaxValueBytes: 32,
+	}
+}
+
+func newUploadTestRoutes(settings httpserver.UploadSettings, metricRecorder httpserver.ServerMetricRecorder) func(r *gin.Engine) {
+	return func(r *gin.Engine) {
+		r.Use(httpserver.UploadMiddleware(settings, metricRecorder))
+		r.POST("/files", httpserver.Bind(func(ctx context.Context, input *uploadTestInput) (httpserver.Response, error) {
+			avatar, err := io.ReadAll(input.Avatar)
+			if err != nil {
+				return nil, err
+			}
+
+			docs := make([]string, 0, len(input.Docs))
+			for _, doc := range input.Docs {
+				docs = append(docs, fmt.Sprintf("%s:%s:%d", doc.FileName(), doc.ContentType(), doc.Size()))
+			}
+
+			return httpserver.NewJsonResponse(gin.H{
+				"name":   input.Name,
+				"avatar": fmt.Sprintf("%s:%s:%d:%t", input.Avatar.FileName(), input.Avatar.ContentType(), input.Avatar.Size(), string(avatar) == uploadTestPng),
+				"docs":   docs,
+			}), nil
+		}))
+		r.POST("/stream", httpserver.Bind(func(ctx context.Context, input *uploadTestStreamInput) (httpserver.Response, error) {
+			files := make([]string, 0)
+
+			for {
+				file, err := input.Uploads.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				if err != nil {
+					return nil, err
+				}
+
+				content, err := io.ReadAll(file)
+				if err != nil {
+					return nil, err
+				}
+
+				files = append(files, fmt.Sprintf("%s:%s:%s", file.FieldName(), file.FileName(), content))
+			}
+
+			read, total := input.Uploads.Progress()
+
+			return httpserver.NewJsonResponse(gin.H{
+				"name":  input.Name,
+				"files": files,
+				"read":  read,
+				"total": total > read,
+			}), nil
+		}))
+	}
+}
+
+func postUploadTestForm(t *testing.T, router http.Handler, path string, parts ...uploadTestPart) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for _, part := range parts {
+		var err error
+		var partWriter io.Writer
+
+		if part.fileName == "" {
+			partWriter, err = writer.CreateFormField(part.name)
+		} else {
+			partWriter, err = writer.CreateFormFile(part.name, part.fileName)
+		}
+		require.NoError(t, err)
+
+		_, err = partWriter.Write([]byte(part.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	return serveTestRequest(router, http.MethodPost, path, body, map[string]string{
+		httpserver.HeaderContentType: writer.FormDataContentType(),
+	})
+}
+
+func TestUploadBindFiles(t *testing.T) {
+	router := newTestRouter(newUploadTestRoutes(newUploadTestSettings(), nil))
+	rec := postUploadTestForm(t, router, "/files",
+		uploadTestPart{name: "name", content: "gopher"},
+		uploadTestPart{name: "avatar", fileName: "avatar.png", content: uploadTestPng},
+		uploadTestPart{name: "docs", fileName: "../notes.txt", content: "hello"},
+		uploadTestPart{name: "docs", fileName: "data.json", content: `{"a":1}`},
+		uploadTestPart{name: "unknown", fileName: "skipped.bin", content: "skipped"},
+	)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"name": "gopher",
+		"avatar": "avatar.png:image/png:16:true",
+		"docs": ["notes.txt:text/plain; charset=utf-8:5", "data.json:text/plain; charset=utf-8:7"]
+	}`, rec.Body.String())
+}
+
+func TestUploadBindErrors(t *testing.T) {
+	avatar := uploadTestPart{name: "avatar", fileName: "avatar.png", content: uploadTestPng}
+
+	cases := []struct {
+		name         string
+		parts        []uploadTestPart
+		expectStatus int
+		expectErr    string
+	}{
+		{
+			name:         "missing required file",
+			parts:        []uploadTestPart{{name: "name", content: "gopher"}},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "file exceeds the limit of its field",
+			parts:        []uploadTestPart{{name: "avatar", fileName: "avatar.png", content: uploadTestPng + "more"}},
+			expectStatus: http.StatusRequestEntityTooLarge,
+			expectErr:    `upload too large: file \"avatar.png\" exceeds 16 bytes`,
+		},
+		{
+			name:         "file exceeds the limit of the server",
+			parts:        []uploadTestPart{avatar, {name: "docs", fileName: "big.txt", content: strings.Repeat("a", 1025)}},
+			expectStatus: http.StatusRequestEntityTooLarge,
+			expectErr:    `upload too large: file \"big.txt\" exceeds 1024 bytes`,
+		},
+		{
+			name: "files exceed the total limit",
+			parts: []uploadTestPart{
+				avatar,
+				{name: "docs", fileName: "a.txt", content: strings.Repeat("a", 1020)},
+				{name: "docs", fileName: "b.txt", content: strings.Repeat("b", 1020)},
+			},
+			expectStatus: http.StatusRequestEntityTooLarge,
+			expectErr:    "upload too large: the files exceed 2048 bytes",
+		},
+		{
+			name:         "too many files",
+			parts:        []uploadTestPart{avatar, avatar, avatar, avatar},
+			expectStatus: http.StatusRequestEntityTooLarge,
+			expectErr:    "too many files uploaded: the limit is 3 files",
+		},
+		{
+			name:         "form value exceeds the limit",
+			parts:        []uploadTestPart{{name: "name", content: strings.Repeat("a", 33)}, avatar},
+			expectStatus: http.StatusRequestEntityTooLarge,
+			expectErr:    `upload too large: form value \"name\" exceeds 32 bytes`,
+		},
+		{
+			name:         "sniffed content type not allowed",
+			parts:        []uploadTestPart{{name: "avatar", fileName: "avatar.png", content: "<html></html>"}},
+			expectStatus: http.StatusUnsupportedMediaType,
+			expectErr:    `content type of upload not allowed: file \"avatar.png\" is text/html; charset=utf-8`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newTestRouter(newUploadTestRoutes(newUploadTestSettings(), nil))
+			rec := postUploadTestForm(t, router, "/files", tc.parts...)
+
+			assert.Equal(t, tc.expectStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tc.expectErr)
+		})
+	}
+}
+
+func TestUploadStream(t *testing.T) {
+	router := newTestRouter(newUploadTestRoutes(newUploadTestSettings(), nil))
+	rec := postUploadTestForm(t, router, "/stream",
+		uploadTestPart{name: "name", content: "gopher"},
+		uploadTestPart{name: "first", fileName: "a.txt", content: "aaa"},
+		uploadTestPart{name: "skipped", content: "value"},
+		uploadTestPart{name: "second", fileName: "b.txt", content: "bbbb"},
+	)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"name": "gopher", "files": ["first:a.txt:aaa", "second:b.txt:bbbb"], "read": 7, "total": true}`, rec.Body.String())
+}
+
+func TestUploadStreamLimits(t *testing.T) {
+	cases := []struct {
+		name         string
+		contentTypes []string
+		parts        []uploadTestPart
+		expectStatus int
+	}{
+		{
+			name:         "content type not allowed",
+			contentTypes: []string{"image/*"},
+			parts: []uploadTestPart{
+				{name: "first", fileName: "avatar.png", content: uploadTestPng},
+				{name: "second", fileName: "notes.txt", content: "hello"},
+			},
+			expectStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "file exceeds the limit of the server",
+			parts:        []uploadTestPart{{name: "first", fileName: "big.txt", content: strings.Repeat("a", 1025)}},
+			expectStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := newUploadTestSettings()
+			settings.ContentTypes = tc.contentTypes
+
+			router := newTestRouter(newUploadTestRoutes(settings, nil))
+			rec := postUploadTestForm(t, router, "/stream", tc.parts...)
+
+			assert.Equal(t, tc.expectStatus, rec.Code)
+		})
+	}
+}
+
+func TestUploadMetrics(t *testing.T) {
+	metricRecorder := mocks.NewServerMetricRecorder(t)
+	metricRecorder.EXPECT().TrackUploadedBytes(matcher.Context, int64(16)).Once()
+	metricRecorder.EXPECT().TrackUploadedBytes(matcher.Context, int64(5)).Once()
+	metricRecorder.EXPECT().TrackUploadRejected(matcher.Context).Once()
+
+	router := newTestRouter(newUploadTestRoutes(newUploadTestSettings(), metricRecorder))
+
+	rec := postUploadTestForm(t, router, "/files",
+		uploadTestPart{name: "avatar", fileName: "avatar.png", content: uploadTestPng},
+		uploadTestPart{name: "docs", fileName: "notes.txt", content: "hello"},
+	)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = postUploadTestForm(t, router, "/files", uploadTestPart{name: "avatar", fileName: "avatar.png", content: "text"})
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
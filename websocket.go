@@ -0,0 +1,432 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"github.com/justtrackio/gosoline/pkg/encoding/json"
+)
+
+// Close codes defined by RFC 6455 which are commonly used by handlers.
+const (
+	WsCloseNormal          = websocket.CloseNormalClosure
+	WsCloseGoingAway       = websocket.CloseGoingAway
+	WsCloseUnsupportedData = websocket.CloseUnsupportedData
+	WsClosePolicyViolation = websocket.ClosePolicyViolation
+	WsCloseMessageTooBig   = websocket.CloseMessageTooBig
+	WsCloseInternalError   = websocket.CloseInternalServerErr
+)
+
+// wsMaxCloseReasonBytes is the maximum length of a close reason, a control frame payload is limited to 125 bytes.
+const wsMaxCloseReasonBytes = 123
+
+// ErrWsClosed is returned when the WebSocket connection has been closed. Errors reporting a close code are of type
+// *WsCloseError and match ErrWsClosed with errors.Is.
+var ErrWsClosed = errors.New("websocket: connection closed")
+
+// defaultWebsocketSettings are used if the handler isn't served by a server of this package.
+var defaultWebsocketSettings = WebsocketSettings{
+	HandshakeTimeout: 10 * time.Second,
+	MaxMessageBytes:  1048576,
+	PingInterval:     30 * time.Second,
+	PongTimeout:      60 * time.Second,
+	WriteTimeout:     10 * time.Second,
+	Compression:      true,
+}
+
+type (
+	// WsCloseError describes the close code and reason of a WebSocket connection. Handlers can return it to close
+	// the connection with a specific code.
+	WsCloseError struct {
+		Code   int
+		Reason string
+	}
+
+	// WsStream sends messages of type S to and receives messages of type R from a WebSocket client. Messages are
+	// encoded as JSON text messages. Messages of type []byte are sent and received as binary messages, messages
+	// of type string as text messages without encoding.
+	//
+	// Control frames of the client, like its close frame and the pongs answering the pings of the server, are read
+	// together with the messages. Once a message was read, they are only processed after the handler received it,
+	// so handlers which only send have to keep calling Receive, e.g. in a goroutine, to notice a closing client.
+	WsStream[R any, S any] struct {
+		ctx      context.Context
+		cancel   context.CancelCauseFunc
+		conn     *websocket.Conn
+		settings WebsocketSettings
+		messages chan wsMessage[R]
+		writeLck sync.Mutex
+		once     sync.Once
+	}
+
+	wsMessage[R any] struct {
+		message *R
+		err     error
+	}
+
+	wsCloser interface {
+		Close(code int, reason string) error
+	}
+
+	// wsTracker keeps the WebSocket connections of a server. Hijacked connections aren't tracked by the connection
+	// pressure manager and aren't closed by http.Server.Shutdown, so the tracker counts them as open connections
+	// and closes them with a going away frame on shutdown.
+	wsTracker struct {
+		ctx            context.Context
+		settings       WebsocketSettings
+		metricRecorder ServerMetricRecorder
+		lck            sync.Mutex
+		streams        map[wsCloser]struct{}
+		done           chan struct{}
+	}
+
+	wsTrackerKey struct{}
+)
+
+// NewWsCloseError creates an error closing a WebSocket connection with the given code and reason.
+func NewWsCloseError(code int, reason string) *WsCloseError {
+	return &WsCloseError{
+		Code:   code,
+		Reason: reason,
+	}
+}
+
+func (e *WsCloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: connection closed with code %d", e.Code)
+	}
+
+	return fmt.Sprintf("websocket: connection closed with code %d: %s", e.Code, e.Reason)
+}
+
+func (e *WsCloseError) Is(target error) bool {
+	return target == ErrWsClosed
+}
+
+func newWsTracker(ctx context.Context, settings WebsocketSettings, metricRecorder ServerMetricRecorder) *wsTracker {
+	// settings which weren't read from the config might miss values
+	if settings.MaxMessageBytes <= 0 || settings.PingInterval <= 0 || settings.PongTimeout <= 0 || settings.WriteTimeout <= 0 {
+		settings = defaultWebsocketSettings
+	}
+
+	return &wsTracker{
+		ctx:            ctx,
+		settings:       settings,
+		metricRecorder: metricRecorder,
+		streams:        make(map[wsCloser]struct{}),
+		done:           make(chan struct{}, 1),
+	}
+}
+
+// connContext makes the tracker available to the BindWs handlers serving requests of the connection.
+func (t *wsTracker) connContext(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, wsTrackerKey{}, t)
+}
+
+func getWsTracker(ctx context.Context) *wsTracker {
+	if tracker, ok := ctx.Value(wsTrackerKey{}).(*wsTracker); ok {
+		return tracker
+	}
+
+	return nil
+}
+
+func (t *wsTracker) add(stream wsCloser) {
+	t.lck.Lock()
+	defer t.lck.Unlock()
+
+	t.streams[stream] = struct{}{}
+	t.metricRecorder.TrackConnectionOpened(t.ctx)
+}
+
+func (t *wsTracker) remove(stream wsCloser) {
+	t.lck.Lock()
+	defer t.lck.Unlock()
+
+	if _, ok := t.streams[stream]; !ok {
+		return
+	}
+
+	delete(t.streams, stream)
+	t.metricRecorder.TrackConnectionClosed(t.ctx)
+
+	if len(t.streams) == 0 {
+		select {
+		case t.done <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// shutdown closes all connections with a going away frame. It is registered with http.Server.RegisterOnShutdown.
+func (t *wsTracker) shutdown() {
+	t.lck.Lock()
+	streams := make([]wsCloser, 0, len(t.streams))
+	for stream := range t.streams {
+		streams = append(streams, stream)
+	}
+	t.lck.Unlock()
+
+	for _, stream := range streams {
+		_ = stream.Close(WsCloseGoingAway, "server shutting down")
+	}
+}
+
+// wait blocks until all connections have been closed or the context is done.
+func (t *wsTracker) wait(ctx context.Context) error {
+	for {
+		t.lck.Lock()
+		open := len(t.streams)
+		t.lck.Unlock()
+
+		if open == 0 {
+			return nil
+		}
+
+		select {
+		case <-t.done:
+		case <-ctx.Done():
+			return fmt.Errorf("%d websocket connections are still open: %w", open, ctx.Err())
+		}
+	}
+}
+
+func newWsUpgrader(settings WebsocketSettings) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		HandshakeTimeout:  settings.HandshakeTimeout,
+		EnableCompression: settings.Compression,
+		CheckOrigin:       newWsOriginChecker(settings.AllowedOrigins),
+	}
+}
+
+func newWsOriginChecker(allowedOrigins []string) func(req *http.Request) bool {
+	if len(allowedOrigins) == 0 {
+		// the default of the upgrader only allows same-origin requests
+		return nil
+	}
+
+	if slices.Contains(allowedOrigins, "*") {
+		return func(_ *http.Request) bool {
+			return true
+		}
+	}
+
+	return func(req *http.Request) bool {
+		origin := req.Header.Get(HeaderOrigin)
+		if origin == "" {
+			return true
+		}
+
+		if u, err := url.Parse(origin); err != nil || u.Host == "" {
+			return false
+		}
+
+		return slices.ContainsFunc(allowedOrigins, func(allowed string) bool {
+			return strings.EqualFold(allowed, origin)
+		})
+	}
+}
+
+func newWsStream[R any, S any](ctx context.Context, conn *websocket.Conn, settings WebsocketSettings) *WsStream[R, S] {
+	streamCtx, cancel := context.WithCancelCause(ctx)
+
+	stream := &WsStream[R, S]{
+		ctx:      streamCtx,
+		cancel:   cancel,
+		conn:     conn,
+		settings: settings,
+		messages: make(chan wsMessage[R]),
+	}
+
+	conn.SetReadLimit(settings.MaxMessageBytes)
+	conn.EnableWriteCompression(settings.Compression)
+
+	go stream.readLoop()
+	go stream.pingLoop()
+
+	return stream
+}
+
+// Context returns a context which is canceled once the connection is closed.
+func (s *WsStream[R, S]) Context() context.Context {
+	return s.ctx
+}
+
+// Receive waits for the next message of the client. It returns a *WsCloseError or an error matching ErrWsClosed
+// once the connection has been closed. Messages which can't be decoded are reported as error without closing the
+// connection.
+func (s *WsStream[R, S]) Receive() (*R, error) {
+	select {
+	case msg := <-s.messages:
+		return msg.message, msg.err
+	case <-s.ctx.Done():
+		return nil, context.Cause(s.ctx)
+	}
+}
+
+// Send writes a message to the client.
+func (s *WsStream[R, S]) Send(message S) error {
+	var err error
+	var data []byte
+
+	messageType := websocket.TextMessage
+
+	switch value := any(message).(type) {
+	case []byte:
+		messageType = websocket.BinaryMessage
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		if data, err = json.Marshal(message); err != nil {
+			return fmt.Errorf("can not encode websocket message: %w", err)
+		}
+	}
+
+	if err = s.ctx.Err(); err != nil {
+		return context.Cause(s.ctx)
+	}
+
+	s.writeLck.Lock()
+	defer s.writeLck.Unlock()
+
+	if err = s.conn.SetWriteDeadline(time.Now().Add(s.settings.WriteTimeout)); err != nil {
+		return s.fail(err)
+	}
+
+	if err = s.conn.WriteMessage(messageType, data); err != nil {
+		return s.fail(err)
+	}
+
+	return nil
+}
+
+// Close sends a close frame with the given code and reason to the client and closes the connection. Closing an
+// already closed connection is a no-op.
+func (s *WsStream[R, S]) Close(code int, reason string) error {
+	var err error
+
+	s.once.Do(func() {
+		reason = truncateWsCloseReason(reason)
+		closeErr := NewWsCloseError(code, reason)
+
+		if context.Cause(s.ctx) == nil {
+			message := websocket.FormatCloseMessage(code, reason)
+			err = s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(s.settings.WriteTimeout))
+			s.cancel(closeErr)
+		}
+
+		if closeConnErr := s.conn.Close(); closeConnErr != nil && err == nil && !errors.Is(closeConnErr, net.ErrClosed) {
+			err = closeConnErr
+		}
+	})
+
+	return err
+}
+
+// truncateWsCloseReason makes the reason fit into a close frame. The reason of a close frame has to be valid UTF-8, so
+// it is truncated on a rune boundary.
+func truncateWsCloseReason(reason string) string {
+	reason = strings.ToValidUTF8(reason, "")
+
+	if len(reason) <= wsMaxCloseReasonBytes {
+		return reason
+	}
+
+	end := wsMaxCloseReasonBytes
+	for end > 0 && !utf8.RuneStart(reason[end]) {
+		end--
+	}
+
+	return reason[:end]
+}
+
+func (s *WsStream[R, S]) readLoop() {
+	var err error
+	var data []byte
+
+	extendDeadline := func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(s.settings.PongTimeout))
+	}
+
+	s.conn.SetPongHandler(extendDeadline)
+
+	for {
+		if err = extendDeadline(""); err != nil {
+			_ = s.fail(err)
+
+			return
+		}
+
+		if _, data, err = s.conn.ReadMessage(); err != nil {
+			_ = s.fail(err)
+
+			return
+		}
+
+		msg := wsMessage[R]{message: new(R)}
+
+		switch value := any(msg.message).(type) {
+		case *[]byte:
+			*value = data
+		case *string:
+			*value = string(data)
+		default:
+			if err = json.Unmarshal(data, msg.message); err != nil {
+				msg = wsMessage[R]{err: fmt.Errorf("can not decode websocket message: %w", err)}
+			}
+		}
+
+		select {
+		case s.messages <- msg:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *WsStream[R, S]) pingLoop() {
+	ticker := time.NewTicker(s.settings.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.settings.WriteTimeout)); err != nil {
+				_ = s.fail(err)
+
+				return
+			}
+		}
+	}
+}
+
+// fail closes the connection after a read or write error and returns the error reported to the handler.
+func (s *WsStream[R, S]) fail(err error) error {
+	var closeErr *websocket.CloseError
+
+	switch {
+	case errors.As(err, &closeErr):
+		s.cancel(NewWsCloseError(closeErr.Code, closeErr.Text))
+	case errors.Is(err, websocket.ErrReadLimit):
+		_ = s.Close(WsCloseMessageTooBig, "message too big")
+	default:
+		s.cancel(fmt.Errorf("%w: %w", ErrWsClosed, err))
+	}
+
+	_ = s.Close(WsCloseNormal, "")
+
+	return context.Cause(s.ctx)
+}
@@ -0,0 +1,144 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type wsTestMetricRecorder struct {
+	ServerMetricRecorder
+	connections atomic.Int64
+}
+
+func (r *wsTestMetricRecorder) TrackConnectionOpened(_ context.Context) {
+	r.connections.Add(1)
+}
+
+func (r *wsTestMetricRecorder) TrackConnectionClosed(_ context.Context) {
+	r.connections.Add(-1)
+}
+
+func newWsTestServer(t *testing.T, settings WebsocketSettings, handler gin.HandlerFunc) (*wsTracker, *wsTestMetricRecorder, string) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := &wsTestMetricRecorder{}
+	tracker := newWsTracker(t.Context(), settings, recorder)
+
+	router := gin.New()
+	router.GET("/ws", handler)
+
+	server := httptest.NewUnstartedServer(router)
+	server.Config.ConnContext = tracker.connContext
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return tracker, recorder, "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+}
+
+func TestWsTrackerShutdown(t *testing.T) {
+	started := make(chan struct{})
+	handlerErr := make(chan error, 1)
+
+	tracker, recorder, url := newWsTestServer(t, defaultWebsocketSettings, BindWsN(func(ctx context.Context, stream *WsStream[string, string]) error {
+		close(started)
+		<-ctx.Done()
+		handlerErr <- context.Cause(ctx)
+
+		return nil
+	}))
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	<-started
+	assert.Equal(t, int64(1), recorder.connections.Load())
+
+	tracker.shutdown()
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "unexpected error %v", err)
+
+	var closeErr *WsCloseError
+	require.True(t, errors.As(<-handlerErr, &closeErr))
+	assert.Equal(t, WsCloseGoingAway, closeErr.Code)
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, tracker.wait(ctx))
+	assert.Equal(t, int64(0), recorder.connections.Load())
+}
+
+func TestWsMaxMessageBytes(t *testing.T) {
+	settings := defaultWebsocketSettings
+	settings.MaxMessageBytes = 8
+
+	_, _, url := newWsTestServer(t, settings, BindWsN(func(ctx context.Context, stream *WsStream[string, string]) error {
+		for {
+			if _, err := stream.Receive(); err != nil {
+				return err
+			}
+		}
+	}))
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("more than eight bytes")))
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), "unexpected error %v", err)
+}
+
+func TestWsPingKeepalive(t *testing.T) {
+	settings := defaultWebsocketSettings
+	settings.PingInterval = 10 * time.Millisecond
+
+	_, _, url := newWsTestServer(t, settings, BindWsN(func(ctx context.Context, stream *WsStream[string, string]) error {
+		<-ctx.Done()
+
+		return nil
+	}))
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+
+		return nil
+	})
+
+	go func() {
+		_, _, _ = conn.ReadMessage()
+	}()
+
+	select {
+	case <-pinged:
+	case <-time.After(time.Second):
+		assert.Fail(t, "no ping received")
+	}
+}